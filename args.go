@@ -0,0 +1,385 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// argsCheckAnnotation marks the commands whose Args function has been wrapped to check
+// their positional arguments, see Arg.
+const argsCheckAnnotation = "cobraflags-args-check"
+
+// Arg represents a positional argument of a command, with the same typed, validated
+// access as flags: the value of the argument at its position, or else of its environment
+// variable, or else the default. T is the value type of one of the flag kinds: string,
+// int, bool, uint8 or []string. A []string argument takes all remaining arguments, and
+// its environment variable holds comma-separated items (see WithEnvSeparator); it must
+// be the last argument.
+//
+// Register wires the arguments into the Args function of the command, which cobra runs
+// before the command: it rejects surplus arguments, missing required ones, and values that
+// cannot be converted to T or fail validation, naming the argument. The validation of a
+// []string argument runs even if no items are given, so that e.g. ExactCount rejects an
+// empty list. An Args function set on the command before is run first. Without one, the
+// arguments are checked as cobra does: a root command with subcommands rejects them as
+// unknown commands, so it needs an Args function such as cobra.ArbitraryArgs to take
+// positional arguments.
+//
+// Example usage:
+//
+//	srcArg := &cobraflags.Arg[string]{Name: "source", Required: true}
+//	countArg := &cobraflags.Arg[int]{Name: "count", Value: 1}
+//	srcArg.Register(copyCmd)
+//	countArg.Register(copyCmd)
+//
+//	copyCmd.RunE = func(cmd *cobra.Command, args []string) error {
+//		return copyFile(srcArg.Get(), countArg.Get())
+//	}
+//
+// With CobraOnInitialize("MYAPP", rootCmd), the count may also be given as MYAPP_COUNT.
+type Arg[T any] struct {
+	Name           string         // Argument name, shown in errors and used to derive the environment variable
+	Position       int            // 1-based position among the arguments; 0 takes the one after the arguments registered before
+	EnvVar         string         // Explicit environment variable name (derived from the prefix and Name if empty)
+	Required       bool           // Whether the argument must be given, on the command line or in the environment
+	Value          T              // Default value
+	ValidateFunc   func(T) error  // Custom validation function (runs before Validator)
+	Validator      Validator      // Custom validator implementing the Validator interface
+	ValidationMode ValidationMode // How ValidateFunc and Validator combine when both are set
+
+	mu  sync.RWMutex // guards cmd and pos
+	cmd *cobra.Command
+	pos int // 0-based position
+}
+
+// registeredArg is implemented by *Arg[T] to let the Args function of a command check
+// the arguments regardless of their value type.
+type registeredArg interface {
+	argName() string
+	position() int
+	variadic() bool
+	check(cmd *cobra.Command, args []string) error
+}
+
+// commandArgs stores the positional arguments registered on every command, in registration order.
+var commandArgs = make(map[*cobra.Command][]registeredArg)
+var commandArgsMutex sync.RWMutex
+
+// argsOf returns the positional arguments registered on cmd, ordered by position.
+func argsOf(cmd *cobra.Command) []registeredArg {
+	commandArgsMutex.RLock()
+	defer commandArgsMutex.RUnlock()
+
+	args := slices.Clone(commandArgs[cmd])
+	slices.SortStableFunc(args, func(a, b registeredArg) int { return a.position() - b.position() })
+	return args
+}
+
+// Register registers the argument with the given cobra command.
+// It panics if the argument cannot be registered; use RegisterE to handle the error instead.
+func (a *Arg[T]) Register(cmd *cobra.Command) {
+	noError(a.RegisterE(cmd))
+}
+
+// RegisterE registers the argument with the given cobra command. It returns an error if T
+// is not a supported type, or if the position is already taken or follows a []string
+// argument, which takes all remaining arguments.
+func (a *Arg[T]) RegisterE(cmd *cobra.Command) error {
+	var zero T
+	if _, err := parseArg[T]("", nil); errors.Is(err, errUnsupportedArg) {
+		return fmt.Errorf("argument %q: unsupported value type %T", a.Name, zero)
+	}
+
+	setupMutex.Lock()
+	defer setupMutex.Unlock()
+	commandArgsMutex.Lock()
+	defer commandArgsMutex.Unlock()
+
+	registered := commandArgs[cmd]
+	pos := a.Position - 1
+	if a.Position <= 0 {
+		pos = 0
+		for _, other := range registered {
+			pos = max(pos, other.position()+1)
+		}
+	}
+	for _, other := range registered {
+		switch {
+		case other.position() == pos:
+			return fmt.Errorf("argument %q: position %d is already taken by argument %q on command %q",
+				a.Name, pos+1, other.argName(), cmd.Name())
+		case other.variadic() && other.position() < pos, a.isVariadic() && other.position() > pos:
+			return fmt.Errorf("argument %q: a []string argument must be the last one on command %q", a.Name, cmd.Name())
+		}
+	}
+
+	a.mu.Lock()
+	a.cmd, a.pos = cmd, pos
+	a.mu.Unlock()
+	commandArgs[cmd] = append(registered, a)
+	installArgsCheck(cmd)
+	return nil
+}
+
+// Get retrieves the current value of the argument: the one given at its position on the
+// command line, or else the value of its environment variable, or else the default.
+// Values that cannot be converted to T yield the default. Like StringFlag.GetString, it
+// does not validate the value.
+func (a *Arg[T]) Get() T {
+	v, _, err := a.load()
+	if err != nil {
+		return a.Value
+	}
+	return v
+}
+
+// GetE retrieves the current value of the argument like Get, and validates it (see
+// FlagBase.ValidateFunc and FlagBase.Validator). It returns an error if the argument is not
+// registered, if the value cannot be converted to T or is invalid, or if the argument is
+// required but not given.
+func (a *Arg[T]) GetE() (T, error) {
+	var zero T
+	v, given, err := a.load()
+	if err != nil {
+		return zero, err
+	}
+	if !given && a.Required {
+		return zero, a.missing()
+	}
+	if _, err := a.validate(v); err != nil {
+		notifyFlag(EventValidationFailed, a.command(), a.Name, err)
+		return zero, fmt.Errorf(translate(a.command(), MessageArgumentError), a.Name, err)
+	}
+	return v, nil
+}
+
+// command returns the command the argument is registered on, or nil if it is not registered.
+func (a *Arg[T]) command() *cobra.Command {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.cmd
+}
+
+// load returns the current value of the argument, and whether it was given on the command
+// line or in the environment.
+func (a *Arg[T]) load() (T, bool, error) {
+	cmd := a.command()
+	if cmd == nil {
+		return a.Value, false, fmt.Errorf("%w: argument %q", ErrNotRegistered, a.Name)
+	}
+	return a.resolve(cmd, cmd.Flags().Args())
+}
+
+// resolve returns the value of the argument among args, or else from its environment
+// variable, or else the default, and whether it was given.
+func (a *Arg[T]) resolve(cmd *cobra.Command, args []string) (T, bool, error) {
+	a.mu.RLock()
+	pos := a.pos
+	a.mu.RUnlock()
+
+	var raw []string
+	switch {
+	case pos < len(args) && a.isVariadic():
+		raw = args[pos:]
+	case pos < len(args):
+		raw = args[pos : pos+1]
+	default:
+		envVar := a.envVar(cmd)
+		value, ok := os.LookupEnv(envVar)
+		if envVar == "" || !ok || value == "" {
+			return a.Value, false, nil
+		}
+		raw = []string{value}
+		if a.isVariadic() {
+			sep := configFor(cmd).envSeparator
+			if sep == "" {
+				sep = ","
+			}
+			raw = strings.Split(value, sep)
+		}
+	}
+
+	v, err := parseArg[T](raw[0], raw)
+	if err != nil {
+		return a.Value, true, fmt.Errorf(translate(cmd, MessageArgumentError), a.Name, fmt.Errorf("invalid value %q: %w", raw[0], err))
+	}
+	return v, true, nil
+}
+
+// envVar returns the environment variable of the argument: the explicit EnvVar, or the
+// name derived from the prefix passed to CobraOnInitialize, if it ran for the command.
+func (a *Arg[T]) envVar(cmd *cobra.Command) string {
+	if a.EnvVar != "" {
+		return a.EnvVar
+	}
+	cfg, ok := lookupConfig(cmd)
+	if !ok {
+		return ""
+	}
+	name, _ := envVarFor(cfg.envPrefix, cfg, &pflag.Flag{}, a.Name)
+	return name
+}
+
+// validate applies the validation of the argument, like FlagBase.validate.
+func (a *Arg[T]) validate(v T) (T, error) {
+	return (&FlagBase[T]{ValidateFunc: a.ValidateFunc, Validator: a.Validator, ValidationMode: a.ValidationMode}).validate(v)
+}
+
+// missing returns the error for the required argument that is not given.
+func (a *Arg[T]) missing() error {
+	cmd := a.command()
+	if envVar := a.envVar(cmd); envVar != "" {
+		return fmt.Errorf(translate(cmd, MessageRequiredArgument), a.Name, envVar)
+	}
+	return fmt.Errorf(translate(cmd, MessageRequiredArgumentNoEnv), a.Name)
+}
+
+// isVariadic reports whether the argument takes all remaining arguments.
+func (a *Arg[T]) isVariadic() bool {
+	_, ok := any(a.Value).([]string)
+	return ok
+}
+
+func (a *Arg[T]) argName() string { return a.Name }
+
+func (a *Arg[T]) variadic() bool { return a.isVariadic() }
+
+func (a *Arg[T]) position() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.pos
+}
+
+// check returns an error if the argument is invalid among args, see Register.
+func (a *Arg[T]) check(cmd *cobra.Command, args []string) error {
+	v, given, err := a.resolve(cmd, args)
+	if err != nil {
+		return err
+	}
+	if !given && a.Required {
+		return a.missing()
+	}
+	if !given && !a.isVariadic() {
+		return nil // Validators of []string arguments also check the count, which may be zero.
+	}
+	if _, err := a.validate(v); err != nil {
+		notifyFlag(EventValidationFailed, cmd, a.Name, err)
+		return fmt.Errorf(translate(cmd, MessageArgumentError), a.Name, err)
+	}
+	return nil
+}
+
+// installArgsCheck wraps the Args function of cmd to check the positional arguments
+// registered on it, once.
+func installArgsCheck(cmd *cobra.Command) {
+	if cmd.Annotations[argsCheckAnnotation] != "" {
+		return
+	}
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[argsCheckAnnotation] = "true"
+
+	previous := cmd.Args
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if err := validateArgs(previous, cmd, args); err != nil {
+			return err
+		}
+		return checkArgs(cmd, args)
+	}
+}
+
+// validateArgs checks args with fn, the Args function of cmd replaced by a wrapper, or as
+// cobra does if it was nil: a root command with subcommands rejects arguments as unknown
+// commands, which cobra checks only while Args is nil, while other commands accept any.
+func validateArgs(fn cobra.PositionalArgs, cmd *cobra.Command, args []string) error {
+	if fn != nil {
+		return fn(cmd, args)
+	}
+	if !cmd.HasSubCommands() || cmd.HasParent() || len(args) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unknown command %q for %q%s", args[0], cmd.CommandPath(), suggestionsFor(cmd, args[0]))
+}
+
+// suggestionsFor returns the commands suggested for the unknown command arg of cmd, the way
+// cobra appends them to its error.
+func suggestionsFor(cmd *cobra.Command, arg string) string {
+	if cmd.DisableSuggestions {
+		return ""
+	}
+	if cmd.SuggestionsMinimumDistance <= 0 {
+		cmd.SuggestionsMinimumDistance = 2
+	}
+	var sb strings.Builder
+	if suggestions := cmd.SuggestionsFor(arg); len(suggestions) > 0 {
+		sb.WriteString("\n\nDid you mean this?\n")
+		for _, s := range suggestions {
+			_, _ = fmt.Fprintf(&sb, "\t%v\n", s)
+		}
+	}
+	return sb.String()
+}
+
+// checkArgs returns an error for surplus arguments and for each positional argument of
+// cmd that is invalid among args.
+func checkArgs(cmd *cobra.Command, args []string) error {
+	registered := argsOf(cmd)
+	if len(registered) == 0 {
+		return nil
+	}
+	last := registered[len(registered)-1]
+	if !last.variadic() && len(args) > last.position()+1 {
+		return fmt.Errorf("accepts at most %d arg(s), received %d", last.position()+1, len(args))
+	}
+
+	var errs []error
+	for _, arg := range registered {
+		if err := arg.check(cmd, args); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// errUnsupportedArg is returned by parseArg for unsupported value types.
+var errUnsupportedArg = errors.New("unsupported argument type")
+
+// parseArg converts the value of a positional argument to T: s, or all of items for []string.
+func parseArg[T any](s string, items []string) (T, error) {
+	var zero T
+	var v any
+	var err error
+	switch any(zero).(type) {
+	case string:
+		v = s
+	case []string:
+		v = slices.Clone(items)
+	case int:
+		v, err = strconv.Atoi(s)
+	case uint8:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, 8)
+		v = uint8(n)
+	case bool:
+		v, err = parseBool(s)
+	default:
+		return zero, errUnsupportedArg
+	}
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) {
+			err = numErr.Err
+		}
+		return zero, err
+	}
+	return v.(T), nil
+}
@@ -0,0 +1,156 @@
+package cobraflags_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestArg(t *testing.T) {
+	c := qt.New(t)
+
+	type args struct {
+		source *cobraflags.Arg[string]
+		count  *cobraflags.Arg[int]
+		rest   *cobraflags.Arg[[]string]
+	}
+	execute := func(argv ...string) (args, error) {
+		cmd := newCobraCommand()
+		a := args{
+			source: &cobraflags.Arg[string]{Name: "source", Required: true},
+			count: &cobraflags.Arg[int]{Name: "count", Value: 1, ValidateFunc: func(n int) error {
+				if n < 1 {
+					return errors.New("must be positive")
+				}
+				return nil
+			}},
+			rest: &cobraflags.Arg[[]string]{Name: "rest"},
+		}
+		a.source.Register(cmd)
+		a.count.Register(cmd)
+		a.rest.Register(cmd)
+		cobraflags.CobraOnInitialize("ARGAPP", cmd)
+		cmd.SetArgs(argv)
+		return a, cmd.Execute()
+	}
+
+	a, err := execute("in.txt", "3", "x", "y")
+	c.Assert(err, qt.IsNil)
+	c.Assert(a.source.Get(), qt.Equals, "in.txt")
+	count, err := a.count.GetE()
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, 3)
+	c.Assert(a.rest.Get(), qt.DeepEquals, []string{"x", "y"})
+
+	// Arguments that are not given fall back to the environment, then to the default.
+	a, err = execute("in.txt")
+	c.Assert(err, qt.IsNil)
+	c.Assert(a.count.Get(), qt.Equals, 1)
+	c.Assert(a.rest.Get(), qt.IsNil)
+
+	c.Setenv("ARGAPP_COUNT", "5")
+	c.Setenv("ARGAPP_REST", "a,b")
+	a, err = execute("in.txt")
+	c.Assert(err, qt.IsNil)
+	c.Assert(a.count.Get(), qt.Equals, 5)
+	c.Assert(a.rest.Get(), qt.DeepEquals, []string{"a", "b"})
+
+	c.Setenv("ARGAPP_SOURCE", "env.txt")
+	a, err = execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(a.source.Get(), qt.Equals, "env.txt")
+}
+
+func TestArg_Errors(t *testing.T) {
+	c := qt.New(t)
+
+	execute := func(argv ...string) error {
+		cmd := newCobraCommand()
+		cmd.Args = cobra.MaximumNArgs(2)
+		(&cobraflags.Arg[string]{Name: "source", Required: true}).Register(cmd)
+		(&cobraflags.Arg[uint8]{Name: "level", ValidateFunc: func(n uint8) error {
+			if n > 9 {
+				return errors.New("must be at most 9")
+			}
+			return nil
+		}}).Register(cmd)
+		cobraflags.CobraOnInitialize("ARGERRAPP", cmd)
+		cmd.SetArgs(argv)
+		return cmd.Execute()
+	}
+
+	c.Assert(execute(), qt.ErrorMatches,
+		`required argument "source" not set, give it on the command line or in the environment variable ARGERRAPP_SOURCE`)
+	c.Assert(execute("in.txt", "12"), qt.ErrorMatches, `argument "level": must be at most 9`)
+	c.Assert(execute("in.txt", "high"), qt.ErrorMatches, `argument "level": invalid value "high": invalid syntax`)
+	// The Args function set before runs first.
+	c.Assert(execute("a", "1", "b"), qt.ErrorMatches, `accepts at most 2 arg\(s\), received 3`)
+}
+
+func TestArg_TooManyArgs(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.Arg[bool]{Name: "force"}).Register(cmd)
+	cmd.SetArgs([]string{"true", "extra"})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `accepts at most 1 arg\(s\), received 2`)
+}
+
+func TestArg_UnknownCommand(t *testing.T) {
+	c := qt.New(t)
+
+	newRoot := func(args cobra.PositionalArgs) (*cobra.Command, *cobraflags.Arg[string]) {
+		root := newCobraCommand()
+		root.Args = args
+		root.AddCommand(&cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}})
+		nameArg := &cobraflags.Arg[string]{Name: "name"}
+		nameArg.Register(root)
+		return root, nameArg
+	}
+
+	// Like cobra, a root command with subcommands rejects unknown commands.
+	root, _ := newRoot(nil)
+	root.SetArgs([]string{"serv"})
+	c.Assert(root.Execute(), qt.ErrorMatches, "unknown command \"serv\" for \"myapp\"\n\nDid you mean this\\?\n\tserve\n")
+
+	root, nameArg := newRoot(cobra.ArbitraryArgs)
+	root.SetArgs([]string{"serv"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(nameArg.Get(), qt.Equals, "serv")
+}
+
+func TestArg_Position(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	dest := &cobraflags.Arg[string]{Name: "dest", Position: 2}
+	source := &cobraflags.Arg[string]{Name: "source", Position: 1}
+	dest.Register(cmd)
+	source.Register(cmd)
+	cmd.SetArgs([]string{"a", "b"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(source.Get(), qt.Equals, "a")
+	c.Assert(dest.Get(), qt.Equals, "b")
+
+	err := (&cobraflags.Arg[string]{Name: "other", Position: 2}).RegisterE(cmd)
+	c.Assert(err, qt.ErrorMatches, `argument "other": position 2 is already taken by argument "dest" on command "myapp"`)
+
+	(&cobraflags.Arg[[]string]{Name: "rest"}).Register(cmd)
+	err = (&cobraflags.Arg[string]{Name: "after"}).RegisterE(cmd)
+	c.Assert(err, qt.ErrorMatches, `argument "after": a \[\]string argument must be the last one on command "myapp"`)
+}
+
+func TestArg_RegisterErrors(t *testing.T) {
+	c := qt.New(t)
+
+	err := (&cobraflags.Arg[time.Duration]{Name: "timeout"}).RegisterE(newCobraCommand())
+	c.Assert(err, qt.ErrorMatches, `argument "timeout": unsupported value type time.Duration`)
+
+	_, err = (&cobraflags.Arg[string]{Name: "source"}).GetE()
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrNotRegistered)
+}
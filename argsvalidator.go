@@ -0,0 +1,130 @@
+package cobraflags
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+)
+
+// The validators below are meant for positional arguments (see Arg), whose Args function
+// reports their errors prefixed with the argument name, e.g.
+// `argument "files": file "a.txt" does not exist`. They work for flags as well.
+
+// All returns a Validator that runs the given validators in order and returns the first
+// error, so that an Arg or a flag can combine several of them:
+//
+//	filesArg := &cobraflags.Arg[[]string]{
+//		Name:      "files",
+//		Validator: cobraflags.All(cobraflags.ExactCount(2), cobraflags.FileExists()),
+//	}
+func All(validators ...Validator) Validator {
+	return allOf(validators)
+}
+
+// allOf is the Validator returned by All.
+type allOf []Validator
+
+// Validate runs the validators in order and returns the first error.
+func (a allOf) Validate(value any) error {
+	for _, v := range a {
+		if err := v.Validate(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExactCount returns a Validator that accepts a []string value with exactly n items, such as
+// the remaining arguments taken by an Arg[[]string].
+func ExactCount(n int) Validator {
+	return exactCount(n)
+}
+
+// exactCount is the Validator returned by ExactCount.
+type exactCount int
+
+// Validate checks that value is a []string with the expected number of items.
+func (n exactCount) Validate(value any) error {
+	items, ok := value.([]string)
+	if !ok {
+		return fmt.Errorf("invalid value type, expected %T, got %T", items, value)
+	}
+	if len(items) != int(n) {
+		return fmt.Errorf("expected %d value(s), got %d", n, len(items))
+	}
+	return nil
+}
+
+// Range returns a Validator that accepts the values between lo and hi, inclusive.
+// Note, T must be the same type as the value, e.g. Range[uint8](1, 9) for an Arg[uint8].
+func Range[T cmp.Ordered](lo, hi T) Validator {
+	return valueRange[T]{lo: lo, hi: hi}
+}
+
+// valueRange is the Validator returned by Range.
+type valueRange[T cmp.Ordered] struct {
+	lo, hi T
+}
+
+// Validate checks that value is between the bounds of the range.
+func (r valueRange[T]) Validate(value any) error {
+	v, ok := value.(T)
+	if !ok {
+		return fmt.Errorf("invalid value type, expected %T, got %T", v, value)
+	}
+	if v < r.lo || v > r.hi {
+		return fmt.Errorf("invalid value %v, must be between %v and %v", v, r.lo, r.hi)
+	}
+	return nil
+}
+
+// EachMatches returns a Validator that accepts a string value, or a []string value whose
+// items all match, if it matches the regular expression expr. It panics if expr cannot be
+// compiled, like regexp.MustCompile.
+func EachMatches(expr string) Validator {
+	re := regexp.MustCompile(expr)
+	return eachItem(func(item string) error {
+		if !re.MatchString(item) {
+			return fmt.Errorf("invalid value %q, must match %s", item, re)
+		}
+		return nil
+	})
+}
+
+// FileExists returns a Validator that accepts a string value, or a []string value whose
+// items all do, if it is the path of an existing file or directory.
+func FileExists() Validator {
+	return eachItem(func(path string) error {
+		_, err := os.Stat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return fmt.Errorf("file %q does not exist", path)
+		case err != nil:
+			return err
+		}
+		return nil
+	})
+}
+
+// eachItem is a Validator that checks a string value, or each item of a []string value.
+type eachItem func(item string) error
+
+// Validate checks value, or each of its items.
+func (f eachItem) Validate(value any) error {
+	switch v := value.(type) {
+	case string:
+		return f(v)
+	case []string:
+		for _, item := range v {
+			if err := f(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid value type, expected string or []string, got %T", value)
+	}
+}
@@ -0,0 +1,81 @@
+package cobraflags_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestArgValidators(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "a.txt", "a")
+	existing := filepath.Join(dir, "a.txt")
+	missing := filepath.Join(dir, "b.txt")
+
+	tests := []struct {
+		name      string
+		validator cobraflags.Validator
+		value     any
+		wantErr   string
+	}{
+		{"exact count", cobraflags.ExactCount(2), []string{"a", "b"}, ""},
+		{"exact count mismatch", cobraflags.ExactCount(2), []string{"a"}, `expected 2 value\(s\), got 1`},
+		{"range", cobraflags.Range(1, 9), 9, ""},
+		{"range below", cobraflags.Range(1, 9), 0, `invalid value 0, must be between 1 and 9`},
+		{"range wrong type", cobraflags.Range(1, 9), uint8(3), `invalid value type, expected int, got uint8`},
+		{"each matches", cobraflags.EachMatches(`^[a-z]+$`), []string{"ab", "c"}, ""},
+		{"each matches string", cobraflags.EachMatches(`^[a-z]+$`), "A", `invalid value "A", must match \^\[a-z\]\+\$`},
+		{"each matches item", cobraflags.EachMatches(`^[a-z]+$`), []string{"ab", "1"}, `invalid value "1", must match .*`},
+		{"each matches wrong type", cobraflags.EachMatches(`x`), 1, `invalid value type, expected string or \[\]string, got int`},
+		{"file exists", cobraflags.FileExists(), existing, ""},
+		{"file missing", cobraflags.FileExists(), []string{existing, missing}, `file ".*b.txt" does not exist`},
+		{"all", cobraflags.All(cobraflags.ExactCount(1), cobraflags.FileExists()), []string{existing}, ""},
+		{"all first error", cobraflags.All(cobraflags.ExactCount(1), cobraflags.FileExists()), []string{missing, missing}, `expected 1 value\(s\), got 2`},
+	}
+	for _, tt := range tests {
+		c.Run(tt.name, func(c *qt.C) {
+			err := tt.validator.Validate(tt.value)
+			if tt.wantErr == "" {
+				c.Assert(err, qt.IsNil)
+				return
+			}
+			c.Assert(err, qt.ErrorMatches, tt.wantErr)
+		})
+	}
+}
+
+func TestArgValidators_Args(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "a.txt", "a")
+	existing := filepath.Join(dir, "a.txt")
+
+	cmd := newCobraCommand()
+	(&cobraflags.Arg[uint8]{Name: "level", Validator: cobraflags.Range[uint8](1, 9)}).Register(cmd)
+	(&cobraflags.Arg[[]string]{
+		Name:      "files",
+		Validator: cobraflags.All(cobraflags.ExactCount(2), cobraflags.FileExists()),
+	}).Register(cmd)
+
+	cmd.SetArgs([]string{"3", existing, filepath.Join(dir, "b.txt")})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `argument "files": file ".*b.txt" does not exist`)
+
+	cmd.SetArgs([]string{"0", existing})
+	c.Assert(cmd.Execute(), qt.ErrorMatches,
+		"argument \"level\": invalid value 0, must be between 1 and 9\nargument \"files\": expected 2 value\\(s\\), got 1")
+
+	cmd.SetArgs([]string{"3"})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `argument "files": expected 2 value\(s\), got 0`)
+
+	cmd.SetArgs(nil)
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `argument "files": expected 2 value\(s\), got 0`)
+
+	cmd.SetArgs([]string{"3", existing, existing})
+	c.Assert(cmd.Execute(), qt.IsNil)
+}
@@ -0,0 +1,49 @@
+package cobraflags
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// backend is the store of values that flags are bound to and read from. Flags, presetting
+// and the other key-level accesses reach the store of their command tree only through it
+// (see store.values), so that Viper can be replaced, e.g. by a future major version or a
+// lighter store, without changing them, and the binding logic can be exercised with a fake.
+// Only reading configuration sources into the store (config files, --set, remote and value
+// stores) and the environment variable settings still use the Viper instance itself.
+type backend interface {
+	// Get returns the raw value of key, before it is converted to the type of a flag.
+	Get(key string) any
+	GetString(key string) string
+	GetInt(key string) int
+	GetInt64(key string) int64
+	GetStringSlice(key string) []string
+	// IsSet reports whether key has a value from any source, including bound flags.
+	IsSet(key string) bool
+	// InConfig reports whether key has a value from a configuration file.
+	InConfig(key string) bool
+	// AllKeys returns the lower-cased keys that have a value from any source.
+	AllKeys() []string
+	// Set overrides the value of key. A nil value drops the override.
+	Set(key string, value any)
+	// BindPFlag makes key resolve to the value of flag while it is changed.
+	BindPFlag(key string, flag *pflag.Flag) error
+	// BindEnv makes key resolve to the value of the given environment variables.
+	BindEnv(input ...string) error
+}
+
+var _ backend = (*viper.Viper)(nil)
+
+// values returns the backend flags read the values of the store from: the one set on
+// the store, if any, or else its Viper instance, escaping the keys if they are
+// case-sensitive (see WithCaseSensitiveKeys).
+func (st *store) values() backend {
+	var b backend = st.v
+	if st.b != nil {
+		b = st.b
+	}
+	if st.caseSensitive.Load() {
+		return caseBackend{b}
+	}
+	return b
+}
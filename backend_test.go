@@ -0,0 +1,135 @@
+package cobraflags_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// fakeBackend is a map-based backend: the values of changed bound flags take precedence
+// over those set with Set, which take precedence over the configuration.
+type fakeBackend struct {
+	config map[string]any
+	set    map[string]any
+	flags  map[string]*pflag.Flag
+}
+
+var _ cobraflags.Backend = (*fakeBackend)(nil)
+
+func newFakeBackend(config map[string]any) *fakeBackend {
+	return &fakeBackend{
+		config: config,
+		set:    make(map[string]any),
+		flags:  make(map[string]*pflag.Flag),
+	}
+}
+
+func (b *fakeBackend) Get(key string) any {
+	key = strings.ToLower(key)
+	if f, ok := b.flags[key]; ok && f.Changed {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			return sv.GetSlice()
+		}
+		return f.Value.String()
+	}
+	if value, ok := b.set[key]; ok {
+		return value
+	}
+	if value, ok := b.config[key]; ok {
+		return value
+	}
+	if f, ok := b.flags[key]; ok {
+		return f.DefValue
+	}
+	return nil
+}
+
+func (b *fakeBackend) GetString(key string) string        { return cast.ToString(b.Get(key)) }
+func (b *fakeBackend) GetInt(key string) int              { return cast.ToInt(b.Get(key)) }
+func (b *fakeBackend) GetInt64(key string) int64          { return cast.ToInt64(b.Get(key)) }
+func (b *fakeBackend) GetStringSlice(key string) []string { return cast.ToStringSlice(b.Get(key)) }
+func (b *fakeBackend) BindEnv(...string) error            { return nil } // The environment is not read.
+
+func (b *fakeBackend) InConfig(key string) bool {
+	_, ok := b.config[strings.ToLower(key)]
+	return ok
+}
+
+func (b *fakeBackend) BindPFlag(key string, f *pflag.Flag) error {
+	b.flags[strings.ToLower(key)] = f
+	return nil
+}
+
+func (b *fakeBackend) IsSet(key string) bool {
+	key = strings.ToLower(key)
+	if f, ok := b.flags[key]; ok && f.Changed {
+		return true
+	}
+	_, set := b.set[key]
+	return set || b.InConfig(key)
+}
+
+func (b *fakeBackend) AllKeys() []string {
+	var keys []string
+	for _, m := range []map[string]any{b.config, b.set} {
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	for key := range b.flags {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+func (b *fakeBackend) Set(key string, value any) {
+	if value == nil {
+		delete(b.set, strings.ToLower(key))
+		return
+	}
+	b.set[strings.ToLower(key)] = value
+}
+
+// TestBackend tests that flags are bound to, preset from and read through the backend
+// of their command tree, without touching its Viper instance.
+func TestBackend(t *testing.T) {
+	c := qt.New(t)
+	c.Cleanup(cobraflags.ResetState)
+
+	cmd := newCobraCommand()
+	hostFlag := &cobraflags.StringFlag{Name: "host", Value: "localhost"}
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags"}
+	cobraflags.Register(cmd, hostFlag, portFlag, tagsFlag)
+
+	b := newFakeBackend(map[string]any{"host": "config.example.com", "tags": []any{"a", "b"}})
+	cobraflags.SetBackend(cmd, b)
+	cobraflags.CobraOnInitialize("FAKEAPP", cmd)
+	cmd.SetArgs([]string{"--port", "8080"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	c.Assert(hostFlag.GetString(), qt.Equals, "config.example.com")
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(tagsFlag.GetStringSlice(), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(b.flags["host"], qt.Equals, cmd.Flags().Lookup("host"))
+	c.Assert(b.flags["port"], qt.Equals, cmd.Flags().Lookup("port"))
+
+	sources := make(map[string]cobraflags.Source)
+	for _, info := range cobraflags.FlagsOf(cmd) {
+		sources[info.Name] = info.Source
+	}
+	c.Assert(sources, qt.DeepEquals, map[string]cobraflags.Source{
+		"host": cobraflags.SourceConfig,
+		"port": cobraflags.SourceFlag,
+		"tags": cobraflags.SourceConfig,
+	})
+	c.Assert(cobraflags.ViperFor(cmd).AllKeys(), qt.HasLen, 0)
+
+}
@@ -0,0 +1,120 @@
+package cobraflags_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// Run the benchmarks without the tests, since every command tree initialized by a test
+// is initialized again on each execution: go test -run '^$' -bench .
+
+func BenchmarkGetString(b *testing.B) {
+	cmd := newCobraCommand()
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	nameFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("BENCHAPP", cmd)
+	b.Cleanup(func() { cobraflags.ResetCommandState(cmd) })
+
+	cmd.SetArgs([]string{"--name", "bench"})
+	if err := cmd.Execute(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = nameFlag.GetString()
+	}
+}
+
+func BenchmarkGetString_NotInitialized(b *testing.B) {
+	cmd := newCobraCommand()
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	nameFlag.Register(cmd)
+	b.Cleanup(func() { cobraflags.ResetCommandState(cmd) })
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = nameFlag.GetString()
+	}
+}
+
+// newBenchTree returns a command tree with the given number of subcommands and flags per
+// subcommand, together with the arguments that execute its last subcommand.
+func newBenchTree(commands, flags int) (*cobra.Command, []string) {
+	root := newCobraCommand()
+	(&cobraflags.BoolFlag{Name: "verbose", Persistent: true}).Register(root)
+	var sub *cobra.Command
+	for i := range commands {
+		sub = &cobra.Command{Use: fmt.Sprintf("sub%d", i), Run: func(*cobra.Command, []string) {}}
+		root.AddCommand(sub)
+		for j := range flags {
+			(&cobraflags.StringFlag{Name: fmt.Sprintf("%s-flag%d", sub.Name(), j), Value: "default"}).Register(sub)
+		}
+	}
+	cobraflags.CobraOnInitialize("BENCHAPP", root)
+	return root, []string{sub.Name(), "--" + sub.Name() + "-flag0", "value"}
+}
+
+func BenchmarkExecute(b *testing.B) {
+	root, args := newBenchTree(200, 10)
+	b.Cleanup(func() { cobraflags.ResetCommandState(root) })
+
+	root.SetArgs(args)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := root.Execute(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStartup measures building, initializing and executing a large command tree.
+func BenchmarkStartup(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		root, args := newBenchTree(200, 10)
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			b.Fatal(err)
+		}
+		cobraflags.ResetCommandState(root)
+	}
+}
+
+func BenchmarkGetStringSlice(b *testing.B) {
+	for _, expand := range []bool{false, true} {
+		b.Run(fmt.Sprintf("ExpandEnv=%t", expand), func(b *testing.B) {
+			cmd := newCobraCommand()
+			tagsFlag := &cobraflags.StringSliceFlag{Name: "tags", ExpandEnv: expand}
+			tagsFlag.Register(cmd)
+			cobraflags.CobraOnInitialize("BENCHAPP", cmd)
+			b.Cleanup(func() { cobraflags.ResetCommandState(cmd) })
+
+			cmd.SetArgs([]string{"--tags", "a,b,c,d"})
+			if err := cmd.Execute(); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = tagsFlag.GetStringSlice()
+			}
+		})
+	}
+}
+
+func BenchmarkGetStringSlice_NotInitialized(b *testing.B) {
+	cmd := newCobraCommand()
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags", Value: []string{"a", "b", "c", "d"}}
+	tagsFlag.Register(cmd)
+	b.Cleanup(func() { cobraflags.ResetCommandState(cmd) })
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = tagsFlag.GetStringSlice()
+	}
+}
@@ -0,0 +1,180 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrInvalidBuilder is returned by Builder.RegisterE when the options given to a builder
+// contradict each other, e.g. a default for a required flag, which is never used.
+var ErrInvalidBuilder = errors.New("invalid flag builder")
+
+// Builder is a chainable alternative to declaring flags as struct literals.
+// It is parameterized by the flag's value type T and the concrete flag type F,
+// so that defaults and validation functions of the wrong type are rejected at
+// compile time and Register returns the typed flag for later value retrieval.
+//
+// Builders are created with NewString, NewBool, NewInt, NewUint8 and NewStringSlice.
+//
+// Example usage:
+//
+//	configFlag := cobraflags.NewString("config").
+//		Short("c").
+//		Default("config.yaml").
+//		Usage("Path to configuration file").
+//		Env("MYAPP_CONFIG").
+//		Validate(validator).
+//		Register(cmd)
+//
+//	path := configFlag.GetString()
+//
+// Register panics if the options contradict each other (see ErrInvalidBuilder) or the flag
+// cannot be registered; RegisterE returns the error instead.
+type Builder[T any, F Flag] struct {
+	base       *FlagBase[T]
+	flag       F
+	hasDefault bool // whether Default was called
+	local      bool // whether Local was called
+}
+
+func newBuilder[T any, F Flag](flag F, base *FlagBase[T]) *Builder[T, F] {
+	return &Builder[T, F]{base: base, flag: flag}
+}
+
+// NewString starts building a StringFlag with the given name.
+func NewString(name string) *Builder[string, *StringFlag] {
+	f := &StringFlag{Name: name}
+	return newBuilder(f, pStringFlag(f))
+}
+
+// NewBool starts building a BoolFlag with the given name.
+func NewBool(name string) *Builder[bool, *BoolFlag] {
+	f := &BoolFlag{Name: name}
+	return newBuilder(f, pBoolFlag(f))
+}
+
+// NewInt starts building an IntFlag with the given name.
+func NewInt(name string) *Builder[int, *IntFlag] {
+	f := &IntFlag{Name: name}
+	return newBuilder(f, pIntFlag(f))
+}
+
+// NewUint8 starts building a Uint8Flag with the given name.
+func NewUint8(name string) *Builder[uint8, *Uint8Flag] {
+	f := &Uint8Flag{Name: name}
+	return newBuilder(f, pUint8Flag(f))
+}
+
+// NewStringSlice starts building a StringSliceFlag with the given name.
+func NewStringSlice(name string) *Builder[[]string, *StringSliceFlag] {
+	f := &StringSliceFlag{Name: name}
+	return newBuilder(f, pStringSliceFlag(f))
+}
+
+// Short sets the single character shorthand of the flag.
+func (b *Builder[T, F]) Short(shorthand string) *Builder[T, F] {
+	b.base.Shorthand = shorthand
+	return b
+}
+
+// Usage sets the help text of the flag.
+func (b *Builder[T, F]) Usage(usage string) *Builder[T, F] {
+	b.base.Usage = usage
+	return b
+}
+
+// Default sets the default value of the flag.
+func (b *Builder[T, F]) Default(value T) *Builder[T, F] {
+	b.base.Value = value
+	b.hasDefault = true
+	return b
+}
+
+// Required marks the flag as required.
+func (b *Builder[T, F]) Required() *Builder[T, F] {
+	b.base.Required = true
+	return b
+}
+
+// Persistent makes the flag available to all subcommands.
+func (b *Builder[T, F]) Persistent() *Builder[T, F] {
+	b.base.Persistent = true
+	return b
+}
+
+// Local restricts the flag to the command it is registered on. This is the default, but
+// declares that the flag must not be made persistent: RegisterE fails if Persistent is
+// called as well, e.g. by code the builder is handed to.
+func (b *Builder[T, F]) Local() *Builder[T, F] {
+	b.local = true
+	return b
+}
+
+// ViperKey sets a custom Viper configuration key for the flag.
+func (b *Builder[T, F]) ViperKey(key string) *Builder[T, F] {
+	b.base.ViperKey = key
+	return b
+}
+
+// Env sets an explicit environment variable name for the flag.
+func (b *Builder[T, F]) Env(name string) *Builder[T, F] {
+	b.base.EnvVar = name
+	return b
+}
+
+// Validate sets the Validator of the flag.
+func (b *Builder[T, F]) Validate(v Validator) *Builder[T, F] {
+	b.base.Validator = v
+	return b
+}
+
+// ValidateFunc sets the typed validation function of the flag.
+func (b *Builder[T, F]) ValidateFunc(fn func(T) error) *Builder[T, F] {
+	b.base.ValidateFunc = fn
+	return b
+}
+
+// ValidationMode sets how the validation function and the Validator of the flag
+// combine when both are set.
+func (b *Builder[T, F]) ValidationMode(mode ValidationMode) *Builder[T, F] {
+	b.base.ValidationMode = mode
+	return b
+}
+
+// Build returns the configured flag without registering it.
+func (b *Builder[T, F]) Build() F {
+	return b.flag
+}
+
+// Register registers the configured flag with the given cobra command and returns it.
+// It panics if the flag cannot be registered; use RegisterE to handle the error instead.
+func (b *Builder[T, F]) Register(cmd *cobra.Command) F {
+	flag, err := b.RegisterE(cmd)
+	noError(err)
+	return flag
+}
+
+// RegisterE registers the configured flag with the given cobra command and returns it.
+// It returns an error wrapping ErrInvalidBuilder if the options contradict each other: a
+// default for a required flag, or a Local flag made Persistent. Otherwise it returns the
+// errors of the flag's RegisterE, e.g. for a duplicate name.
+func (b *Builder[T, F]) RegisterE(cmd *cobra.Command) (F, error) {
+	if err := b.check(); err != nil {
+		return b.flag, err
+	}
+	return b.flag, b.flag.RegisterE(cmd)
+}
+
+// check returns an error if the options given to the builder contradict each other.
+func (b *Builder[T, F]) check() error {
+	var errs []error
+	if b.base.Required && b.hasDefault {
+		errs = append(errs, fmt.Errorf("%w: flag %q is required and has a default, which is never used", ErrInvalidBuilder, b.base.Name))
+	}
+	if b.base.Persistent && b.local {
+		errs = append(errs, fmt.Errorf("%w: flag %q is local and persistent", ErrInvalidBuilder, b.base.Name))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,140 @@
+package cobraflags_test
+
+import (
+	"fmt"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestBuilder_Register(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	flag := cobraflags.NewString("config").
+		Short("c").
+		Default("config.yaml").
+		Usage("Path to configuration file").
+		Register(cmd)
+
+	cmd.SetArgs([]string{"-c", "other.yaml"})
+	err := cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(flag.GetString(), qt.Equals, "other.yaml")
+	c.Assert(flag.Usage, qt.Equals, "Path to configuration file")
+}
+
+func TestBuilder_Default(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	countFlag := cobraflags.NewInt("count").Default(5).Register(cmd)
+	tagsFlag := cobraflags.NewStringSlice("tags").Default([]string{"a", "b"}).Register(cmd)
+
+	err := cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(countFlag.GetInt(), qt.Equals, 5)
+	c.Assert(tagsFlag.GetStringSlice(), qt.DeepEquals, []string{"a", "b"})
+}
+
+func TestBuilder_Required(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	cobraflags.NewBool("force").Required().Register(cmd)
+
+	cmd.SetArgs(make([]string, 0))
+	err := cmd.Execute()
+
+	c.Assert(err, qt.ErrorMatches, `required flag\(s\) "force" not set`)
+}
+
+func TestBuilder_RegisterE(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	flag, err := cobraflags.NewInt("port").Default(8080).RegisterE(cmd)
+	c.Assert(err, qt.IsNil)
+	c.Assert(flag.Name, qt.Equals, "port")
+
+	_, err = cobraflags.NewInt("port").RegisterE(cmd)
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrDuplicateFlag)
+
+	_, err = cobraflags.NewString("mode").Default("dev").Required().RegisterE(cmd)
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrInvalidBuilder)
+	c.Assert(err, qt.ErrorMatches, `.*"mode" is required and has a default.*`)
+
+	_, err = cobraflags.NewBool("verbose").Local().Persistent().RegisterE(cmd)
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrInvalidBuilder)
+	c.Assert(err, qt.ErrorMatches, `.*"verbose" is local and persistent`)
+
+	// Rejected flags are not registered.
+	c.Assert(cmd.Flags().Lookup("mode"), qt.IsNil)
+	c.Assert(cmd.PersistentFlags().Lookup("verbose"), qt.IsNil)
+
+	// Without contradicting options, the same settings are fine.
+	_, err = cobraflags.NewString("mode").Required().RegisterE(cmd)
+	c.Assert(err, qt.IsNil)
+	_, err = cobraflags.NewBool("verbose").Local().RegisterE(cmd)
+	c.Assert(err, qt.IsNil)
+}
+
+func TestBuilder_RegisterPanics(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	c.Assert(func() {
+		cobraflags.NewString("mode").Required().Default("dev").Register(cmd)
+	}, qt.PanicMatches, `.*invalid flag builder.*`)
+}
+
+func TestBuilder_Env(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("CUSTOM_LEVEL_VAR", "7")
+
+	cmd := newCobraCommand()
+	flag := cobraflags.NewUint8("level").Env("CUSTOM_LEVEL_VAR").Register(cmd)
+	cobraflags.CobraOnInitialize("BUILDERENV", cmd)
+
+	cmd.SetArgs(make([]string, 0))
+	err := cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(flag.GetUint8(), qt.Equals, uint8(7))
+}
+
+func TestBuilder_Validation(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	flag := cobraflags.NewInt("port").
+		Default(80).
+		ValidateFunc(func(port int) error {
+			if port < 1024 {
+				return fmt.Errorf("port %d is privileged", port)
+			}
+			return nil
+		}).
+		Validate(cobraflags.ValidatorFunc[int](func(int) error { return nil })).
+		Register(cmd)
+
+	err := cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	_, err = flag.GetIntE()
+	c.Assert(err, qt.ErrorMatches, "port 80 is privileged")
+}
+
+func TestBuilder_Build(t *testing.T) {
+	c := qt.New(t)
+
+	flag := cobraflags.NewString("name").ViperKey("app.name").Persistent().Build()
+
+	c.Assert(flag.Name, qt.Equals, "name")
+	c.Assert(flag.ViperKey, qt.Equals, "app.name")
+	c.Assert(flag.Persistent, qt.IsTrue)
+}
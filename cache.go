@@ -0,0 +1,229 @@
+package cobraflags
+
+import (
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// generations hands out the generations of settled stores, see store.settle.
+var generations atomic.Uint64
+
+// cachedValue is a value of a flag read from a store in a given generation, after the
+// flag had been set a given number of times (see trackedValue), with the error of checking
+// the raw value, see FlagBase.rawCheck.
+type cachedValue[T any] struct {
+	st      *store
+	gen     uint64
+	sets    uint64
+	value   T
+	invalid error
+}
+
+// lock takes the write lock of the store to change its values, and discards the values
+// cached from it.
+func (st *store) lock() {
+	st.mu.Lock()
+	st.resolved.Store(0)
+}
+
+// settle lets flags cache the values read from the store until it changes again.
+// The values of a command tree are settled once the executed command has been
+// initialized and its hooks have run, right before its Run function.
+func (st *store) settle() {
+	st.resolved.CompareAndSwap(0, generations.Add(1))
+}
+
+// storesOf returns the stores of cmd and its subcommands: the one of the root command,
+// or one per command, see WithCommandScopedViper.
+func storesOf(cmd *cobra.Command) []*store {
+	vipersMutex.Lock()
+	defer vipersMutex.Unlock()
+
+	var stores []*store
+	for key, st := range vipers {
+		if isAncestor(cmd, key) || isAncestor(key, cmd) {
+			stores = append(stores, st)
+		}
+	}
+	return stores
+}
+
+// settleStores settles the stores of the command tree of cmd and publishes the values
+// of its Atomic flags.
+func settleStores(cmd *cobra.Command) {
+	root := cmd.Root()
+	for _, st := range storesOf(root) {
+		st.settle()
+	}
+	walkCommands(root, func(c *cobra.Command) {
+		for _, entry := range registeredOn(c) {
+			entry.base.publish()
+		}
+	})
+}
+
+// InvalidateCache discards the values cached for the flags of cmd and its subcommands.
+//
+// Once a command has been executed, the flags of its tree cache their values, so that
+// frequent Get calls do not go through Viper each time. The cache is discarded whenever
+// cobraflags changes the values, e.g. on the next execution or a configuration reload
+// (see WatchConfig), and whenever a flag is set, e.g. with cmd.Flags().Set while the
+// command is running. Values set on the Viper instance directly (see ViperFor) are not
+// noticed: call InvalidateCache after calling its Set method, or use SetValue instead.
+//
+// The values published by flags with FlagBase.Atomic set are discarded as well, until
+// they are published again on the next execution.
+func InvalidateCache(cmd *cobra.Command) {
+	for _, st := range storesOf(cmd) {
+		st.resolved.Store(0)
+	}
+	walkCommands(cmd, func(c *cobra.Command) {
+		for _, entry := range registeredOn(c) {
+			entry.base.unpublish()
+		}
+	})
+}
+
+// publish publishes the current value of the flag for lock-free reads, if it is Atomic
+// and the value can be read and passes the check of the raw value, see rawCheck.
+func (s *FlagBase[T]) publish() {
+	if !s.Atomic {
+		return
+	}
+	s.mu.RLock()
+	read := s.read
+	s.mu.RUnlock()
+
+	s.published.Store(nil)
+	v, err := s.checkedValue(read)
+	if err != nil {
+		return
+	}
+	s.published.Store(&v)
+}
+
+// unpublish discards the value published by publish.
+func (s *FlagBase[T]) unpublish() {
+	s.published.Store(nil)
+}
+
+// cached returns the value of the flag cached for the store in its current generation,
+// or else the stamp to cache the value read now with, see storeCache.
+func (s *FlagBase[T]) cached(st *store) (cachedValue[T], bool) {
+	stamp := cachedValue[T]{st: st, gen: st.resolved.Load(), sets: s.sets.Load()}
+	if stamp.gen != 0 {
+		if c := s.cache.Load(); c != nil && c.st == st && c.gen == stamp.gen && c.sets == stamp.sets {
+			return *c, true
+		}
+	}
+	return stamp, false
+}
+
+// storeCache caches a value read with the stamp returned by cached, unless the store
+// or the flag has changed since.
+func (s *FlagBase[T]) storeCache(c cachedValue[T]) {
+	if c.gen != 0 && c.st.resolved.Load() == c.gen && s.sets.Load() == c.sets {
+		s.cache.Store(&c)
+	}
+}
+
+// changed discards the value cached and published for the flag once its pflag.Flag has
+// been set, see trackedValue.
+func (s *FlagBase[T]) changed() {
+	s.sets.Add(1)
+	s.published.Store(nil)
+}
+
+// trackedValue wraps the pflag.Value of a registered flag to notify the flags sharing it
+// whenever it is set, so that they discard the values cached for them.
+type trackedValue struct {
+	pflag.Value
+	onSet []func()
+}
+
+// trackedSlice is a trackedValue for slice values, which keeps them a pflag.SliceValue.
+type trackedSlice struct {
+	trackedValue
+	restart bool // whether the next Set replaces the items instead of appending, see restoreSlice
+}
+
+// track makes the value of f a trackedValue calling onSet whenever it is set, or adds
+// onSet to it if it is one already.
+//
+// pflag only recognizes its own slice values as empty by the default "[]", so an empty
+// default is given as "" instead, which it recognizes for any value, to keep it out of
+// the help output.
+func track(f *pflag.Flag, onSet func()) {
+	switch v := f.Value.(type) {
+	case *trackedValue:
+		v.onSet = append(v.onSet, onSet)
+	case *trackedSlice:
+		v.onSet = append(v.onSet, onSet)
+	case pflag.SliceValue:
+		f.Value = &trackedSlice{trackedValue: trackedValue{Value: f.Value, onSet: []func(){onSet}}}
+		if f.DefValue == "[]" {
+			f.DefValue = ""
+		}
+	default:
+		f.Value = &trackedValue{Value: f.Value, onSet: []func(){onSet}}
+	}
+}
+
+// Set sets the value and notifies the flags.
+func (v *trackedValue) Set(s string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	v.notify()
+	return nil
+}
+
+// notify calls the functions registered with track.
+func (v *trackedValue) notify() {
+	for _, onSet := range v.onSet {
+		onSet()
+	}
+}
+
+// Set parses the items and appends them to the slice, or replaces its items if it has
+// been restored since it was last set, and notifies the flags.
+func (v *trackedSlice) Set(s string) error {
+	if v.restart {
+		previous := v.GetSlice()
+		if err := v.Value.(pflag.SliceValue).Replace(nil); err != nil {
+			return err
+		}
+		if err := v.trackedValue.Set(s); err != nil {
+			_ = v.Value.(pflag.SliceValue).Replace(previous)
+			return err
+		}
+		v.restart = false
+		return nil
+	}
+	return v.trackedValue.Set(s)
+}
+
+// Append appends an item to the slice and notifies the flags.
+func (v *trackedSlice) Append(s string) error {
+	if err := v.Value.(pflag.SliceValue).Append(s); err != nil {
+		return err
+	}
+	v.notify()
+	return nil
+}
+
+// Replace replaces the items of the slice and notifies the flags.
+func (v *trackedSlice) Replace(items []string) error {
+	if err := v.Value.(pflag.SliceValue).Replace(items); err != nil {
+		return err
+	}
+	v.notify()
+	return nil
+}
+
+// GetSlice returns the items of the slice.
+func (v *trackedSlice) GetSlice() []string {
+	return v.Value.(pflag.SliceValue).GetSlice()
+}
@@ -0,0 +1,184 @@
+package cobraflags_test
+
+import (
+	"sync"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestValueCache(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	validations := 0
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80, ValidateFunc: func(int) error {
+		validations++
+		return nil
+	}}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("CACHEAPP", cmd)
+
+	cmd.SetArgs([]string{"--port", "1"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 1)
+
+	// Direct changes are not seen until the cache is invalidated.
+	v := cobraflags.ViperFor(cmd)
+	v.Set("port", 2)
+	c.Assert(portFlag.GetInt(), qt.Equals, 1)
+	cobraflags.InvalidateCache(cmd)
+	c.Assert(portFlag.GetInt(), qt.Equals, 2)
+
+	// GetE still validates on every call.
+	for range 2 {
+		value, err := portFlag.GetIntE()
+		c.Assert(err, qt.IsNil)
+		c.Assert(value, qt.Equals, 2)
+	}
+	c.Assert(validations, qt.Equals, 2)
+
+	// The next execution discards the cache.
+	v.Set("port", nil)
+	cmd.SetArgs([]string{"--port", "3"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 3)
+}
+
+func TestValueCache_NotInitialized(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	nameFlag.Register(cmd)
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(nameFlag.GetString(), qt.Equals, "default")
+
+	// Without CobraOnInitialize, values are read from Viper every time.
+	cobraflags.ViperFor(cmd).Set("name", "live")
+	c.Assert(nameFlag.GetString(), qt.Equals, "live")
+}
+
+func TestValueCache_Restore(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	nameFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("CACHERESTOREAPP", cmd)
+	state := cobraflags.Snapshot(cmd)
+
+	cmd.SetArgs([]string{"--name", "cli"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(nameFlag.GetString(), qt.Equals, "cli")
+
+	cobraflags.Restore(cmd, state)
+	c.Assert(nameFlag.GetString(), qt.Equals, "default")
+}
+
+func TestAtomicFlag(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("ATOMICAPP_VERBOSE", "true")
+
+	cmd := newCobraCommand()
+	verboseFlag := &cobraflags.BoolFlag{Name: "verbose", Atomic: true}
+	levelFlag := &cobraflags.StringFlag{Name: "level", Value: "info", Atomic: true}
+	cobraflags.Register(cmd, verboseFlag, levelFlag)
+	cobraflags.CobraOnInitialize("ATOMICAPP", cmd)
+
+	v := cobraflags.ViperFor(cmd)
+	cmd.PersistentPreRun = func(*cobra.Command, []string) {
+		v.Set("level", "debug") // Not published yet, so seen right away.
+		c.Check(levelFlag.GetString(), qt.Equals, "debug")
+	}
+	cmd.Run = func(*cobra.Command, []string) {
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.Check(verboseFlag.GetBool(), qt.IsTrue)
+				c.Check(levelFlag.GetString(), qt.Equals, "debug")
+			}()
+		}
+		wg.Wait()
+	}
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	// The published value stays until the cache is invalidated.
+	v.Set("level", "warn")
+	c.Assert(levelFlag.GetString(), qt.Equals, "debug")
+	value, err := levelFlag.GetStringE()
+	c.Assert(err, qt.IsNil)
+	c.Assert(value, qt.Equals, "debug")
+
+	cobraflags.InvalidateCache(cmd)
+	c.Assert(levelFlag.GetString(), qt.Equals, "warn")
+}
+
+func TestValueCache_SetWhileRunning(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	levelFlag := &cobraflags.StringFlag{Name: "level", Value: "info"}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags"}
+	modeFlag := &cobraflags.StringFlag{Name: "mode", Value: "dev", Atomic: true}
+	cobraflags.Register(cmd, levelFlag, tagsFlag, modeFlag)
+	cobraflags.CobraOnInitialize("CACHESETAPP", cmd)
+
+	cmd.Run = func(cmd *cobra.Command, _ []string) {
+		c.Check(levelFlag.GetString(), qt.Equals, "info")
+		c.Check(cmd.Flags().Set("level", "debug"), qt.IsNil)
+		c.Check(levelFlag.GetString(), qt.Equals, "debug")
+
+		c.Check(tagsFlag.GetStringSlice(), qt.HasLen, 0)
+		c.Check(cmd.Flags().Set("tags", "a,b"), qt.IsNil)
+		c.Check(tagsFlag.GetStringSlice(), qt.DeepEquals, []string{"a", "b"})
+
+		c.Check(modeFlag.GetString(), qt.Equals, "dev")
+		cobraflags.SetValue(cmd, "mode", "prod")
+		c.Check(modeFlag.GetString(), qt.Equals, "prod")
+	}
+	c.Assert(cmd.Execute(), qt.IsNil)
+}
+
+func TestAtomicFlag_OtherTree(t *testing.T) {
+	c := qt.New(t)
+
+	first, second := newCobraCommand(), newCobraCommand()
+	levelFlag := &cobraflags.StringFlag{Name: "level", Value: "info", Atomic: true}
+	levelFlag.Register(first)
+	(&cobraflags.StringFlag{Name: "level", Value: "info", Atomic: true}).Register(second)
+	cobraflags.CobraOnInitialize("ATOMICFIRSTAPP", first)
+	cobraflags.CobraOnInitialize("ATOMICSECONDAPP", second)
+
+	c.Assert(first.Execute(), qt.IsNil)
+	cobraflags.ViperFor(first).Set("level", "debug")
+
+	// Executing another tree leaves the published values of the first one alone.
+	c.Assert(second.Execute(), qt.IsNil)
+	c.Assert(levelFlag.GetString(), qt.Equals, "info")
+
+	c.Assert(first.Execute(), qt.IsNil)
+	c.Assert(levelFlag.GetString(), qt.Equals, "debug")
+}
+
+func TestAtomicFlag_InvalidValue(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80, Atomic: true}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("ATOMICINVALIDAPP", cmd)
+	cobraflags.ViperFor(cmd).Set("port", 1.5)
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	// The value is not published, so the check is not skipped.
+	for range 2 {
+		_, err := portFlag.GetIntE()
+		c.Assert(err, qt.ErrorMatches, `.*flag "port".*1\.5.*`)
+	}
+}
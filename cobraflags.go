@@ -1,14 +1,62 @@
 package cobraflags
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
-const viperKeyAnnotation = "viper-key"
+const (
+	viperKeyAnnotation       = "viper-key"
+	envVarAnnotation         = "env-var"
+	envAliasesAnnotation     = "env-aliases"
+	resolvedEnvVarAnnotation = "cobraflags-env-var" // set by CobraOnInitialize
+	sourceAnnotation         = "cobraflags-source"  // set by CobraOnInitialize when presetting a value
+	usageAnnotation          = "cobraflags-usage"   // the usage text before CobraOnInitialize appended the env var
+	noEnvUsageAnnotation     = "cobraflags-no-env-usage"
+	allowEmptyEnvAnnotation  = "cobraflags-allow-empty-env"
+	fileEnvAnnotation        = "cobraflags-file-env"
+	envSeparatorAnnotation   = "cobraflags-env-separator"
+	trimSpaceAnnotation      = "cobraflags-trim-space"
+	noEnvAnnotation          = "cobraflags-no-env" // set on flags cobraflags adds itself that are never preset from the environment
+	cloneAnnotation          = "cobraflags-clone"  // set on clones, which are bound under a key of their own, see FlagBase.bindingKey
+)
+
+var (
+	// ErrDuplicateFlag is returned by RegisterE when a flag with the same name
+	// is already registered on the command. The error names the source locations
+	// of both registrations, if the first one was made through cobraflags.
+	// Set FlagBase.Reuse to share the existing flag instead.
+	ErrDuplicateFlag = errors.New("flag already registered")
+
+	// ErrViperKeyCollision is returned by RegisterE when another flag registered on the
+	// command has the same Viper key, so that both would read the same value.
+	ErrViperKeyCollision = errors.New("viper key collision")
+
+	// ErrNotRegistered is returned by the Get...E methods of a flag that has not been
+	// registered with a command yet.
+	ErrNotRegistered = errors.New("flag not registered")
+
+	// ErrInvalidShorthand is returned by RegisterE when the shorthand is longer
+	// than one character or is already used by another flag.
+	ErrInvalidShorthand = errors.New("invalid shorthand")
+
+	// ErrFlagType is returned by the Get...E methods of a registered flag whose value has
+	// another type, e.g. by GetIntE of a StringFlag found with Lookup. The other getters
+	// panic with it.
+	ErrFlagType = errors.New("flag type mismatch")
+)
 
 // flagGetter is an interface for getting flag values.
 type flagGetter interface {
@@ -31,8 +79,13 @@ type flagGetterE interface {
 // Flag is an interface for a flag that can be registered with a cobra command.
 type Flag interface {
 	// Register registers the flag with the given cobra command.
+	// It panics if the flag cannot be registered.
 	Register(*cobra.Command)
 
+	// RegisterE registers the flag with the given cobra command
+	// and returns an error if the flag cannot be registered.
+	RegisterE(*cobra.Command) error
+
 	flagGetter
 	flagGetterE
 }
@@ -45,15 +98,79 @@ type Flag interface {
 //   - ValidateFunc: A simple function that takes the flag's value type and returns an error
 //   - Validator: An interface-based validator that can be reused across different flag types
 //
-// When both ValidateFunc and Validator are set, ValidateFunc takes precedence and Validator is ignored.
+// When both ValidateFunc and Validator are set, ValidationMode decides how they combine.
+// By default, ValidateFunc runs first and Validator only if ValidateFunc succeeds.
 //
 // The ViperKey field allows using different configuration keys than flag names for Viper binding.
 // If ViperKey is empty, the flag will fall back to using its Name for Viper binding.
+// Viper keys are case-insensitive, as Viper lower-cases all keys: "app.ConfigFile" and
+// "app.configfile" are the same key, and configuration files may spell it either way,
+// unless the command tree is initialized with WithCaseSensitiveKeys.
 // This enables:
 //   - Using different configuration keys than flag names
 //   - Supporting nested configuration structures (e.g., "app.config.file")
 //   - Maintaining backward compatibility when renaming flags
 //
+// The EnvVar field overrides the environment variable name that CobraOnInitialize
+// would otherwise derive from the prefix and the Viper key. EnvAliases lists former
+// names of the variable, which are still honored when it is not set, but log a warning
+// (once per name) suggesting the new one.
+//
+// Deprecated marks the flag as deprecated, with a hint such as "use --mode instead". pflag
+// hides it from the help and prints a notice when it is used on the command line; when its
+// value comes from the environment or a configuration file instead, a warning is logged
+// as the command is about to run (see WithDeprecationWarnings).
+//
+// RenamedFrom lists former names of the flag, to rename it without breaking existing
+// scripts and deployments. The former names are still accepted, with a deprecation notice,
+// as command-line flags, as the environment variables derived from them and as keys of the
+// configuration file, and set the flag itself. Using a former and the current name together
+// is an error (see ErrRenamedFlagConflict).
+//
+// Experimental flags ship preview features: setting them, from any source, makes the
+// execution fail with ErrExperimentalFlag unless experimental flags are unlocked with
+// the environment variable <prefix>_EXPERIMENTAL=1 or WithExperimentalFlags. Once
+// unlocked, a warning is logged for each experimental flag in use.
+//
+// Sensitive marks the value of the flag as a secret, such as a password. It is replaced
+// with "***" wherever cobraflags shows it: the default in the help output, PrintExplain
+// and LogEffectiveConfig, the dumps, print-config and the error messages quoting it.
+// Flags whose name ends with a word like "password" or "token", e.g. "db-password", and
+// values read from secret files, are treated as secrets as well.
+//
+// DefaultFunc computes the default from the effective values of other flags, e.g. a
+// metrics address next to the listen address. It is called once the command is about to
+// run, before its PersistentPreRun and PreRun hooks, if the flag is not set by any source;
+// see Defaults. The source of the computed value is SourceDerived. DerivedFlag supports
+// any value type.
+//
+// DependsOn names flags that must be set, from any source, whenever this flag is set, e.g.
+// --tls-cert for --tls-key. CobraOnInitialize checks that they are available to the command
+// and do not form a cycle (see ErrInvalidDependency); the execution fails, naming all
+// missing flags, if they are not set (see ErrMissingDependency).
+//
+// Concurrency: Register, RegisterE and the Get/GetE methods are safe for concurrent use,
+// also together with CobraOnInitialize, e.g. when plugins register their flags from several
+// goroutines; registrations are serialized internally, so that of two flags with the same
+// name exactly one is registered. Registration and initialization must be complete before
+// the command is executed. Reads of a command tree's Viper instance are serialized against
+// flag binding and against the environment presets applied during CobraOnInitialize.
+// Command-line parsing done by cobra itself is not synchronized, so values must not be read
+// concurrently with the argument parsing phase of Execute; reads from Run or from other
+// goroutines once initialization has started are safe.
+//
+// Flags read in hot paths, such as feature toggles or the verbosity, can set Atomic. Their
+// effective value is then published once the executed command is about to run, and again
+// after a configuration reload, so that Get and GetE read it with a single atomic load,
+// without locking or Viper. Until it is published, e.g. in PersistentPreRun hooks, and
+// after InvalidateCache, values are read as usual.
+//
+// Flags are bound to their Viper key by CobraOnInitialize, or else on first read. With
+// EagerBind, Register binds the flag right away if its command tree already has a Viper
+// instance, e.g. one obtained with ViperFor or injected with WithViper, so that the first
+// read costs no more than later ones, and the key is known to Viper from the start. The
+// command must be part of its final tree by then.
+//
 // Example usage:
 //
 //	flag := &StringFlag{
@@ -69,29 +186,64 @@ type Flag interface {
 //		},
 //	}
 type FlagBase[T any] struct {
-	Name         string        // Flag name used for command line arguments
-	ViperKey     string        // Custom Viper configuration key (falls back to Name if empty)
-	Shorthand    string        // Single character shorthand for the flag
-	Usage        string        // Help text for the flag
-	Required     bool          // Whether the flag is required
-	Persistent   bool          // Whether the flag is persistent across subcommands
-	Value        T             // Default value
-	ValidateFunc func(T) error // Custom validation function (takes precedence over Validator)
-	Validator    Validator     // Custom validator implementing the Validator interface
-
-	flag     *pflag.Flag
-	bindOnce sync.Once
+	Name           string         // Flag name used for command line arguments
+	ViperKey       string         // Custom Viper configuration key (falls back to Name if empty)
+	EnvVar         string         // Explicit environment variable name (derived from the prefix and ViperKey if empty)
+	EnvAliases     []string       // Deprecated environment variable names, still honored with a warning
+	RenamedFrom    []string       // Former flag names, still honored on the command line, in the environment and in config files
+	AllowEmptyEnv  bool           // Whether a set but empty environment variable overrides the default
+	FileEnv        bool           // Whether the value may be read from the file named by the <env var>_FILE variable
+	ExpandEnv      bool           // Whether ${VAR} references in the value are expanded, see WithExpandEnv
+	EnvSeparator   string         // Separator of slice items in environment variables, see WithEnvSeparator
+	TrimSpace      bool           // Whether whitespace around environment and config values is removed, see WithTrimSpace
+	Shorthand      string         // Single character shorthand for the flag
+	Usage          string         // Help text for the flag
+	Group          string         // Heading the flag is listed under by InstallHelp, e.g. "Server"
+	Advanced       bool           // Whether the flag is hidden from the help, except for --help-all (see WithHelpAll)
+	Experimental   bool           // Whether the flag is only accepted once experimental flags are unlocked, see WithExperimentalFlags
+	NoEnvUsage     bool           // Whether to leave Usage unchanged instead of appending the environment variable
+	NoCompletion   bool           // Whether to leave the shell completion of the values to cobra.Command.RegisterFlagCompletionFunc
+	FileExtensions []string       // Extensions of the files shells complete the value with, e.g. "yaml", see WithFileCompletion
+	DirCompletion  bool           // Whether shells complete the value with directories only, see WithDirCompletion
+	Required       bool           // Whether the flag is required
+	DependsOn      []string       // Names of the flags that must be set whenever this flag is set
+	Deprecated     string         // Hint shown when the deprecated flag is used, e.g. "use --mode instead"; hides it from help
+	Sensitive      bool           // Whether the value is a secret, redacted wherever it is shown
+	Persistent     bool           // Whether the flag is persistent across subcommands
+	Value          T              // Default value
+	DefaultFunc    DefaultFunc[T] // Computes the default from other flags when the command runs, see Defaults
+	ValidateFunc   func(T) error  // Custom validation function (runs before Validator)
+	Validator      Validator      // Custom validator implementing the Validator interface
+	ValidationMode ValidationMode // How ValidateFunc and Validator combine when both are set
+	OnChange       func(T)        // Called with the new value when a config reload changes it, see WatchConfig
+	Reuse          bool           // Whether to share an existing flag of the same name and type instead of failing, see ErrDuplicateFlag
+	Atomic         bool           // Whether the value is published for lock-free reads once the command runs (see Concurrency above)
+	EagerBind      bool           // Whether Register binds the flag to its Viper key right away, if the tree has a Viper instance
+
+	mu        sync.RWMutex // guards flag, cmd, read, frozen, boundTo and boundIn
+	flag      *pflag.Flag
+	cmd       *cobra.Command
+	read      readFunc[T]
+	frozen    *T                             // snapshot returned by getters while frozen, see Freeze
+	boundTo   *store                         // the store the flag has been bound into, see bind
+	boundIn   uint64                         // the assignment of stores boundTo was valid in, see assignments
+	cache     atomic.Pointer[cachedValue[T]] // the value last read, see InvalidateCache
+	sets      atomic.Uint64                  // counts the times the pflag.Flag has been set, see trackedValue
+	published atomic.Pointer[T]              // the value published for lock-free reads, see Atomic
+	origin    any                            // the flag this one was cloned from, whose bindings it may share
 
 	flagGetter
 	flagGetterE
 }
 
+// readFunc reads the value of type T stored under key from a Viper instance.
+type readFunc[T any] func(b backend, key string) T
+
 // validate applies custom validation logic if defined and returns the value or an error if validation fails.
 //
-// Validation precedence (in order):
-//  1. ValidateFunc - if set, this function is called and Validator is ignored
-//  2. Validator - if set and ValidateFunc is nil, the Validate method is called
-//  3. No validation - if neither is set, the value is returned as-is
+// ValidateFunc runs before Validator. Whether Validator runs as well, and which errors are
+// returned, depends on the ValidationMode (see ValidationFirstError, ValidationRunAll and
+// ValidationFuncOnly). If neither is set, the value is returned as-is.
 //
 // Returns:
 //   - On success: the original value and nil error
@@ -100,21 +252,335 @@ type FlagBase[T any] struct {
 // This method is called internally by GetE methods to ensure validation
 // occurs before returning values to the caller.
 func (s *FlagBase[T]) validate(v T) (result T, err error) {
+	var errs []error
 	if s.ValidateFunc != nil {
-		err = s.ValidateFunc(v)
-		if err != nil {
-			return result, err
+		if err := s.ValidateFunc(v); err != nil {
+			if s.ValidationMode != ValidationRunAll {
+				return result, err
+			}
+			errs = append(errs, err)
 		}
 	}
 
-	if s.Validator != nil {
-		err = s.Validator.Validate(v)
-		if err != nil {
-			return result, err
+	if s.Validator != nil && (s.ValidateFunc == nil || s.ValidationMode != ValidationFuncOnly) {
+		if err := s.Validator.Validate(v); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
-	return v, nil
+	switch len(errs) {
+	case 0:
+		return v, nil
+	case 1:
+		return result, errs[0]
+	default:
+		return result, errors.Join(errs...)
+	}
+}
+
+// Validate checks v with the ValidateFunc and Validator of the flag, combined as set by
+// ValidationMode, the way the Get...E methods do. It lets adapters for other flag libraries
+// validate values with the same rules, see the urfavecli package.
+func (s *FlagBase[T]) Validate(v T) error {
+	_, err := s.validate(v)
+	return err
+}
+
+// bind returns the store of the Viper instance the flag is bound to, together with the
+// key the flag is bound to, see bindingKey. Flags are bound once by CobraOnInitialize (see bindInto); flags read
+// before that are bound here on first use.
+//
+// If the flag has not been registered yet, or cannot be bound, an error is returned
+// and the flag stays unbound, so that the next call tries again. The flag is bound
+// anew if its command tree got a new Viper instance, e.g. after ResetState.
+func (s *FlagBase[T]) bind() (*store, string, error) {
+	s.mu.RLock()
+	flag, cmd, boundTo, boundIn := s.flag, s.cmd, s.boundTo, s.boundIn
+	s.mu.RUnlock()
+
+	if cmd == nil {
+		return nil, s.getViperKey(), fmt.Errorf("%w: %q", ErrNotRegistered, s.Name)
+	}
+	viperKey := s.bindingKey(cmd)
+
+	assigned := assignments.Load()
+	if boundTo != nil && boundIn == assigned {
+		return boundTo, viperKey, nil
+	}
+
+	st := storeFor(cmd)
+	st.mu.Lock() // Binding does not change values, so the cache is kept.
+	err := st.bindFlag(viperKey, flag)
+	st.mu.Unlock()
+	if err != nil {
+		return nil, viperKey, fmt.Errorf("binding flag %q to Viper key %q: %w", s.Name, viperKey, err)
+	}
+
+	s.mu.Lock()
+	s.boundTo, s.boundIn = st, assigned
+	s.mu.Unlock()
+	notifyFlag(EventBound, cmd, s.Name, nil)
+	return st, viperKey, nil
+}
+
+// bindEagerly binds the flag on registration if EagerBind is set and its command tree
+// has a Viper instance already.
+func (s *FlagBase[T]) bindEagerly() error {
+	if !s.EagerBind {
+		return nil
+	}
+	assigned := assignments.Load()
+
+	s.mu.RLock()
+	flag, cmd := s.flag, s.cmd
+	s.mu.RUnlock()
+
+	st := existingStoreFor(cmd)
+	if st == nil {
+		return nil
+	}
+	viperKey := s.bindingKey(cmd)
+	st.mu.Lock() // Binding does not change values, so the cache is kept.
+	err := st.bindFlag(viperKey, flag)
+	st.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("binding flag %q to Viper key %q: %w", s.Name, viperKey, err)
+	}
+
+	s.mu.Lock()
+	s.boundTo, s.boundIn = st, assigned
+	s.mu.Unlock()
+	notifyFlag(EventBound, cmd, s.Name, nil)
+	return nil
+}
+
+// bindInto binds the flag to st, the store of cmd whose write lock is held, while cmd is
+// initialized, so that getters need not bind it. Flags registered on other commands,
+// e.g. shared with Reuse, are bound when their own command is initialized.
+func (s *FlagBase[T]) bindInto(cmd *cobra.Command, st *store) error {
+	assigned := assignments.Load()
+
+	s.mu.RLock()
+	flag, owner := s.flag, s.cmd
+	s.mu.RUnlock()
+	if owner != cmd {
+		return nil
+	}
+
+	viperKey := s.bindingKey(cmd)
+	if err := st.bindFlag(viperKey, flag); err != nil {
+		return fmt.Errorf("binding flag %q to Viper key %q: %w", s.Name, viperKey, err)
+	}
+
+	s.mu.Lock()
+	s.boundTo, s.boundIn = st, assigned
+	s.mu.Unlock()
+	notifyFlag(EventBound, cmd, s.Name, nil)
+	return nil
+}
+
+// get returns the current value of the flag, read from its Viper instance with the given read function.
+// If the flag cannot be bound, the error handler is invoked and the zero value is returned.
+func (s *FlagBase[T]) get(read readFunc[T]) T {
+	if p := s.published.Load(); p != nil {
+		return *p
+	}
+	v, err := s.load(read)
+	if err != nil {
+		noError(err)
+		return v
+	}
+	v, _ = s.expand(v)
+	return v
+}
+
+// load returns the current value of the flag, read from its Viper instance with the given
+// read function or from the cache (see InvalidateCache), or the frozen value. The error is
+// that of binding the flag.
+func (s *FlagBase[T]) load(read readFunc[T]) (T, error) {
+	c, err := s.resolve(read)
+	return c.value, err
+}
+
+// resolve returns the current value of the flag like load, together with the error of
+// checking the raw value stored in Viper for it (see rawCheck), which is cached with it.
+// Frozen values have been converted already and are not checked.
+func (s *FlagBase[T]) resolve(read readFunc[T]) (cachedValue[T], error) {
+	s.mu.RLock()
+	frozen := s.frozen
+	s.mu.RUnlock()
+	if frozen != nil {
+		return cachedValue[T]{value: *frozen}, nil
+	}
+
+	st, viperKey, err := s.bind()
+	if err != nil {
+		return cachedValue[T]{}, err
+	}
+
+	c, ok := s.cached(st)
+	if ok {
+		return c, nil
+	}
+	check := s.rawCheck()
+	var raw any
+	st.mu.RLock()
+	c.value = read(st.values(), viperKey)
+	if check != nil {
+		raw = st.values().Get(viperKey)
+	}
+	st.mu.RUnlock()
+
+	if check != nil {
+		if err := check(raw); err != nil {
+			if s.secret() {
+				err = redactError(err, valueStrings(raw)...)
+			}
+			c.invalid = fmt.Errorf(translate(s.command(), MessageFlagError), s.Name, err)
+		}
+	}
+	s.storeCache(c)
+	return c, nil
+}
+
+// rawCheck returns the check applied to the raw value stored in Viper for the flag before
+// it is converted to T, so that conversions losing information are reported by getE and
+// must, or nil if there is none for T.
+func (s *FlagBase[T]) rawCheck() func(raw any) error {
+	switch any(s).(type) {
+	case *FlagBase[int]:
+		return isInt
+	case *FlagBase[uint8]:
+		return uint8InRange
+	case *FlagBase[bool]:
+		return isBool
+	}
+	return nil
+}
+
+// value returns the current value of the flag like get, together with the error of
+// binding the flag or of expanding environment variable references in it, if any
+// (see WithExpandEnv).
+func (s *FlagBase[T]) value(read readFunc[T]) (T, error) {
+	if p := s.published.Load(); p != nil {
+		return *p, nil
+	}
+	v, err := s.load(read)
+	if err != nil {
+		return v, err
+	}
+	return s.expand(v)
+}
+
+// checkedValue returns the current value of the flag like value, or the error of checking
+// the raw value (see rawCheck). Published values have passed the check.
+func (s *FlagBase[T]) checkedValue(read readFunc[T]) (T, error) {
+	if p := s.published.Load(); p != nil {
+		return *p, nil
+	}
+	c, err := s.resolve(read)
+	if err == nil {
+		err = c.invalid
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return s.expand(c.value)
+}
+
+// getE returns the current value of the flag like get, checks the raw value (see rawCheck)
+// and validates it.
+func (s *FlagBase[T]) getE(read readFunc[T]) (T, error) {
+	v, err := s.checkedValue(read)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	result, err := s.validate(v)
+	if err != nil {
+		if s.secret() {
+			err = redactError(err, valueStrings(v)...)
+		}
+		notifyFlag(EventValidationFailed, s.command(), s.Name, err)
+	}
+	return result, err
+}
+
+// must returns the current value of the flag like get, and panics with an error naming
+// the flag, the offending value and its source if validation fails.
+func (s *FlagBase[T]) must(read readFunc[T]) T {
+	v, err := s.checkedValue(read)
+	if err != nil {
+		panic(fmt.Errorf("cobraflags: %w", err))
+	}
+	if _, err := s.validate(v); err != nil {
+		if s.secret() {
+			err = redactError(err, valueStrings(v)...)
+			notifyFlag(EventValidationFailed, s.command(), s.Name, err)
+			panic(fmt.Errorf("cobraflags: invalid value %s for flag %q (from %s): %w", redacted, s.Name, s.source(), err))
+		}
+		notifyFlag(EventValidationFailed, s.command(), s.Name, err)
+		panic(fmt.Errorf("cobraflags: invalid value %v for flag %q (from %s): %w", v, s.Name, s.source(), err))
+	}
+	return v
+}
+
+// clone returns a copy of the flag definition (all exported fields) without any
+// registration or binding state, so it can be registered independently.
+func (s *FlagBase[T]) clone() *FlagBase[T] {
+	origin := s.origin
+	if origin == nil {
+		origin = s
+	}
+	return &FlagBase[T]{
+		origin:         origin,
+		Name:           s.Name,
+		ViperKey:       s.ViperKey,
+		EnvVar:         s.EnvVar,
+		EnvAliases:     slices.Clone(s.EnvAliases),
+		RenamedFrom:    slices.Clone(s.RenamedFrom),
+		AllowEmptyEnv:  s.AllowEmptyEnv,
+		FileEnv:        s.FileEnv,
+		ExpandEnv:      s.ExpandEnv,
+		EnvSeparator:   s.EnvSeparator,
+		TrimSpace:      s.TrimSpace,
+		Shorthand:      s.Shorthand,
+		Usage:          s.Usage,
+		Group:          s.Group,
+		Advanced:       s.Advanced,
+		Experimental:   s.Experimental,
+		NoEnvUsage:     s.NoEnvUsage,
+		NoCompletion:   s.NoCompletion,
+		FileExtensions: slices.Clone(s.FileExtensions),
+		DirCompletion:  s.DirCompletion,
+		Required:       s.Required,
+		DependsOn:      slices.Clone(s.DependsOn),
+		Deprecated:     s.Deprecated,
+		Sensitive:      s.Sensitive,
+		Persistent:     s.Persistent,
+		Value:          s.Value,
+		DefaultFunc:    s.DefaultFunc,
+		ValidateFunc:   s.ValidateFunc,
+		Validator:      s.Validator,
+		ValidationMode: s.ValidationMode,
+		OnChange:       s.OnChange,
+		Reuse:          s.Reuse,
+		Atomic:         s.Atomic,
+		EagerBind:      s.EagerBind,
+	}
+}
+
+// registerClones registers a fresh clone of a flag on each of the given commands
+// and returns the clones in the same order.
+func registerClones[F Flag](clone func() F, cmds []*cobra.Command) []F {
+	clones := make([]F, 0, len(cmds))
+	for _, cmd := range cmds {
+		c := clone()
+		c.Register(cmd)
+		clones = append(clones, c)
+	}
+	return clones
 }
 
 // getViperKey returns the Viper configuration key to use for this flag.
@@ -137,6 +603,241 @@ func (s *FlagBase[T]) getViperKey() string {
 	return s.Name
 }
 
+// bindingKey returns the key the flag is bound to in the store of cmd, the command it is
+// registered on: its Viper key, or for clones (see RegisterOn) a key of their own, scoped to
+// the path of cmd. Clones on sibling commands would otherwise share one binding, and all
+// read the value parsed for whichever of them was bound last. Their environment variables
+// and configuration values are still looked up under the Viper key, and preset on each clone
+// by CobraOnInitialize.
+func (s *FlagBase[T]) bindingKey(cmd *cobra.Command) string {
+	if s.origin == nil {
+		return s.getViperKey()
+	}
+	return clonedKey(cmd, s.getViperKey())
+}
+
+// clonedKey returns the key of a clone with the given Viper key registered on cmd, see
+// bindingKey. It contains no dots, so that Viper does not nest it below the parents of
+// the Viper key.
+func clonedKey(cmd *cobra.Command, viperKey string) string {
+	return strings.NewReplacer(".", "/", " ", "/").Replace("cobraflags-clone:" + cmd.CommandPath() + ":" + viperKey)
+}
+
+// command returns the command the flag is registered on, or nil if it is not registered.
+func (s *FlagBase[T]) command() *cobra.Command {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.cmd
+}
+
+// register defines the flag on the command's local or persistent flag set (depending on Persistent)
+// using the given define function, marks it as required if needed, annotates it and adds
+// self (the concrete flag wrapping s) to the command's registry. The read function is
+// remembered for introspection.
+//
+// The name and shorthand are checked upfront, so that conflicts are reported as errors
+// instead of the panics pflag raises when a flag is redefined.
+func (s *FlagBase[T]) register(cmd *cobra.Command, self Flag, read readFunc[T], define func(flags *pflag.FlagSet)) (err error) {
+	defer func() { // Runs once setupMutex is released.
+		if err == nil {
+			notifyFlag(EventRegistered, cmd, s.Name, nil)
+		}
+	}()
+	setupMutex.Lock()
+	defer setupMutex.Unlock()
+
+	var flags *pflag.FlagSet
+	if s.Persistent {
+		flags = cmd.PersistentFlags()
+	} else {
+		flags = cmd.Flags()
+	}
+
+	site := registrationSite()
+	if s.Reuse {
+		if existing, owner := lookupShared(cmd, s.Name); existing != nil {
+			if err := s.reuse(owner, existing, read, define); err != nil {
+				return err
+			}
+			return s.bindEagerly()
+		}
+	}
+	if err := s.checkRegistration(cmd, site); err != nil {
+		return err
+	}
+	if err := s.checkViperKey(cmd); err != nil {
+		return err
+	}
+	if err := s.checkRenamed(cmd); err != nil {
+		return err
+	}
+
+	define(flags)
+	s.defineRenamed(flags, define)
+
+	if s.Required {
+		if err := cobra.MarkFlagRequired(flags, s.Name); err != nil {
+			return fmt.Errorf("marking flag %q as required: %w", s.Name, err)
+		}
+	}
+	if s.Deprecated != "" {
+		if err := flags.MarkDeprecated(s.Name, s.Deprecated); err != nil {
+			return fmt.Errorf("marking flag %q as deprecated: %w", s.Name, err)
+		}
+	}
+	if err := s.registerCompletion(cmd, flags.Lookup(s.Name)); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.flag = flags.Lookup(s.Name)
+	track(s.flag, s.changed)
+	s.cmd = cmd
+	s.read = read
+	s.flagGetter, s.flagGetterE = mismatch(s.Name, s.flag), mismatch(s.Name, s.flag)
+	s.annotate()
+	s.mu.Unlock()
+
+	addToRegistry(cmd, registryEntry{name: s.Name, persistent: s.Persistent, flag: self, base: s, site: site})
+
+	return s.bindEagerly()
+}
+
+// reuse attaches s to the existing flag f of the owner command instead of defining a new one,
+// see FlagBase.Reuse. The value type must match; the default, usage and other settings of
+// the existing flag stay in effect.
+func (s *FlagBase[T]) reuse(owner *cobra.Command, f *pflag.Flag, read readFunc[T], define func(flags *pflag.FlagSet)) error {
+	probe := pflag.NewFlagSet(s.Name, pflag.ContinueOnError)
+	define(probe)
+	if want := probe.Lookup(s.Name).Value.Type(); f.Value.Type() != want {
+		return fmt.Errorf("%w: %q on command %q has type %s, cannot reuse it as %s",
+			ErrDuplicateFlag, s.Name, owner.Name(), f.Value.Type(), want)
+	}
+
+	s.mu.Lock()
+	s.flag = f
+	track(f, s.changed)
+	s.cmd = owner
+	s.read = read
+	s.flagGetter, s.flagGetterE = mismatch(s.Name, f), mismatch(s.Name, f)
+	s.mu.Unlock()
+	return nil
+}
+
+// lookupShared returns the flag named name that a flag registered on cmd would share with
+// FlagBase.Reuse: a local or persistent flag of cmd, or a persistent flag of an ancestor,
+// together with the command that defines it.
+func lookupShared(cmd *cobra.Command, name string) (*pflag.Flag, *cobra.Command) {
+	if f := cmd.LocalNonPersistentFlags().Lookup(name); f != nil {
+		return f, cmd
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if f := c.PersistentFlags().Lookup(name); f != nil {
+			return f, c
+		}
+	}
+	return nil, nil
+}
+
+// registrationSite returns the source location of the first caller outside of cobraflags,
+// i.e. the call that registers a flag, as file:line.
+func registrationSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePath+".") {
+			return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// packagePath is the import path of cobraflags, see registrationSite.
+var packagePath = reflect.TypeFor[registryEntry]().PkgPath()
+
+// checkRegistration verifies that the flag name and shorthand can be registered
+// on both the local and the persistent flag sets of the command.
+func (s *FlagBase[T]) checkRegistration(cmd *cobra.Command, site string) error {
+	if s.Name == "" {
+		return errors.New("flag name must not be empty")
+	}
+	if len(s.Shorthand) > 1 {
+		return fmt.Errorf("%w: %q for flag %q is more than one ASCII character", ErrInvalidShorthand, s.Shorthand, s.Name)
+	}
+
+	for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+		if flags.Lookup(s.Name) != nil {
+			if first := siteOf(cmd, s.Name); first != "" && site != "" {
+				return fmt.Errorf("%w: %q on command %q (registered at %s, again at %s)", ErrDuplicateFlag, s.Name, cmd.Name(), first, site)
+			}
+			return fmt.Errorf("%w: %q on command %q", ErrDuplicateFlag, s.Name, cmd.Name())
+		}
+		if s.Shorthand == "" {
+			continue
+		}
+		if used := flags.ShorthandLookup(s.Shorthand); used != nil {
+			return fmt.Errorf("%w: %q for flag %q is already used by flag %q", ErrInvalidShorthand, s.Shorthand, s.Name, used.Name)
+		}
+	}
+
+	return nil
+}
+
+// annotate stores the binding metadata (Viper key and explicit environment variable)
+// on the underlying pflag.Flag so that CobraOnInitialize can find it later.
+func (s *FlagBase[T]) annotate() {
+	if s.flag.Annotations == nil {
+		s.flag.Annotations = make(map[string][]string)
+	}
+	s.flag.Annotations[viperKeyAnnotation] = []string{s.getViperKey()}
+	if s.origin != nil {
+		s.flag.Annotations[cloneAnnotation] = []string{"true"}
+	}
+	if s.EnvVar != "" {
+		s.flag.Annotations[envVarAnnotation] = []string{s.EnvVar}
+	}
+	if s.NoEnvUsage {
+		s.flag.Annotations[noEnvUsageAnnotation] = []string{"true"}
+	}
+	if s.AllowEmptyEnv {
+		s.flag.Annotations[allowEmptyEnvAnnotation] = []string{"true"}
+	}
+	if s.FileEnv {
+		s.flag.Annotations[fileEnvAnnotation] = []string{"true"}
+	}
+	if s.EnvSeparator != "" {
+		s.flag.Annotations[envSeparatorAnnotation] = []string{s.EnvSeparator}
+	}
+	if s.TrimSpace {
+		s.flag.Annotations[trimSpaceAnnotation] = []string{"true"}
+	}
+	if s.Group != "" {
+		s.flag.Annotations[groupAnnotation] = []string{s.Group}
+	}
+	if s.Advanced {
+		s.flag.Annotations[advancedAnnotation] = []string{"true"}
+		s.flag.Hidden = true
+	}
+	if s.Experimental {
+		s.flag.Annotations[experimentalAnnotation] = []string{"true"}
+	}
+	if s.Sensitive {
+		s.flag.Annotations[sensitiveAnnotation] = []string{"true"}
+	}
+	if len(s.DependsOn) > 0 {
+		s.flag.Annotations[dependsOnAnnotation] = slices.Clone(s.DependsOn)
+	}
+	if len(s.EnvAliases) > 0 {
+		s.flag.Annotations[envAliasesAnnotation] = slices.Clone(s.EnvAliases)
+	}
+	if len(s.RenamedFrom) > 0 {
+		s.flag.Annotations[renamedFromAnnotation] = slices.Clone(s.RenamedFrom)
+	}
+}
+
 // Register registers multiple flags with the given cobra command in a single call.
 // This is a convenience function that calls Register() on each flag individually.
 //
@@ -151,6 +852,24 @@ func Register(cmd *cobra.Command, flags ...Flag) {
 	}
 }
 
+// RegisterAllE registers multiple flags with the given cobra command in a single call.
+// Unlike Register, it does not panic: it stops at the first flag that cannot be
+// registered and returns its error.
+//
+// Example:
+//
+//	if err := RegisterAllE(cmd, countFlag, verboseFlag); err != nil {
+//		return err
+//	}
+func RegisterAllE(cmd *cobra.Command, flags ...Flag) error {
+	for _, flag := range flags {
+		if err := flag.RegisterE(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // RegisterMap registers flags from a map with the given cobra command.
 // The map keys are ignored; only the flag values are registered.
 // This is useful when you have flags organized in a map structure.
@@ -168,9 +887,132 @@ func RegisterMap(cmd *cobra.Command, flags map[string]Flag) {
 	}
 }
 
-func noError(err error) {
-	if err != nil {
-		slog.With("error", err).Error("unexpected error")
+// setupMutex serializes the changes that registration and CobraOnInitialize make to
+// command trees (pflag flag sets, command hooks) and to cobra's global initializers,
+// none of which are synchronized by cobra or pflag.
+var setupMutex sync.Mutex
+
+// errorHandler holds the function called for internal errors, see SetErrorHandler.
+var errorHandler atomic.Pointer[func(error)]
+
+// SetErrorHandler replaces the function that is called when cobraflags encounters
+// an internal error it cannot return to the caller, such as a failed registration
+// in Register or a failed Viper binding in a Get method. The Get...E methods return
+// binding errors instead, e.g. ErrNotRegistered, and a later call tries again.
+//
+// The default handler logs the error with slog and panics, unless disabled with
+// SetPanicOnInternalError. Libraries embedding cobraflags can install a handler that
+// logs and continues instead, or that records the error to be returned from the
+// command later. Passing nil restores the default.
+//
+// Example:
+//
+//	cobraflags.SetErrorHandler(func(err error) {
+//		slog.Warn("cobraflags error", "error", err)
+//	})
+func SetErrorHandler(handler func(error)) {
+	if handler == nil {
+		errorHandler.Store(nil)
+		return
+	}
+	errorHandler.Store(&handler)
+}
+
+// noPanicOnInternalError reports whether the default error handler only logs errors,
+// see SetPanicOnInternalError.
+var noPanicOnInternalError atomic.Bool
+
+// SetPanicOnInternalError controls whether the default error handler panics after
+// logging an internal error (the default) or only logs it. With panics disabled, a
+// flag that cannot be registered by Register is left unregistered and a Get method
+// whose flag cannot be bound returns the zero value, while RegisterE and the Get...E
+// methods keep returning these errors. This keeps a flag misconfiguration from
+// crashing a long-running process that embeds cobraflags, e.g. a server building
+// commands at run time.
+//
+// A handler installed with SetErrorHandler takes precedence. The Must methods panic
+// regardless, as documented.
+func SetPanicOnInternalError(enabled bool) {
+	noPanicOnInternalError.Store(!enabled)
+}
+
+// ResetState discards all package-level state kept by cobraflags: the Viper instances
+// of all command trees, the flag and argument registries (see Arg), the initialization
+// state recorded by CobraOnInitialize, the configuration watchers (see WatchConfig),
+// the deprecated environment variables and configuration keys already warned about (see
+// FlagBase.EnvAliases and FlagBase.RenamedFrom), the flags excluded with ExcludeFromEnv,
+// the error handler, the observer (see SetObserver) and the setting of
+// SetPanicOnInternalError.
+//
+// It is intended for tests that build many command trees in one process.
+func ResetState() {
+	vipersMutex.Lock()
+	vipers = make(map[*cobra.Command]*store)
+	scopedRoots = make(map[*cobra.Command]bool)
+	caseSensitiveRoots = make(map[*cobra.Command]bool)
+	assignments.Add(1)
+	vipersMutex.Unlock()
+
+	registryMutex.Lock()
+	registry = make(map[*cobra.Command][]registryEntry)
+	registryMutex.Unlock()
+
+	commandArgsMutex.Lock()
+	commandArgs = make(map[*cobra.Command][]registeredArg)
+	commandArgsMutex.Unlock()
+
+	initOnceMutex.Lock()
+	for command := range initOnceMap {
+		releaseInitState(command)
+	}
+	initOnceMutex.Unlock()
+
+	initConfigsMutex.Lock()
+	initConfigs = make(map[*cobra.Command]*initConfig)
+	initConfigsMutex.Unlock()
+
+	watchersMutex.Lock()
+	roots := slices.Collect(maps.Keys(watchers))
+	watchersMutex.Unlock()
+	for _, root := range roots {
+		stopWatching(root)
+	}
+
+	helpConfigsMutex.Lock()
+	helpConfigs = make(map[*cobra.Command]*helpConfig)
+	helpConfigsMutex.Unlock()
+
+	flagSetCommandsMutex.Lock()
+	flagSetCommands = make(map[*pflag.FlagSet]*cobra.Command)
+	flagSetCommandsMutex.Unlock()
+
+	warnedEnvAliases.Clear()
+	warnedRenamedKeys.Clear()
+
+	noEnvFlagsMutex.Lock()
+	noEnvFlags = defaultNoEnvFlags()
+	noEnvFlagsMutex.Unlock()
+
+	SetErrorHandler(nil)
+	SetPanicOnInternalError(true)
+	SetObserver(nil)
+}
+
+// defaultErrorHandler logs the error and panics, unless disabled with SetPanicOnInternalError.
+func defaultErrorHandler(err error) {
+	slog.With("error", err).Error("unexpected error")
+	if !noPanicOnInternalError.Load() {
 		panic(err)
 	}
 }
+
+func noError(err error) {
+	if err == nil {
+		return
+	}
+	if handler := errorHandler.Load(); handler != nil {
+		(*handler)(err)
+		return
+	}
+	defaultErrorHandler(err)
+}
@@ -5,7 +5,6 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/go-extras/cobraflags"
 )
@@ -251,9 +250,6 @@ func ExampleRegister() {
 
 // ExampleCobraOnInitialize demonstrates environment variable binding.
 func ExampleCobraOnInitialize() {
-	// Reset Viper state to avoid interference from other tests
-	viper.Reset()
-
 	// Set environment variable for demo
 	os.Setenv("MYAPP_MESSAGE", "from environment")
 	defer os.Unsetenv("MYAPP_MESSAGE")
@@ -285,11 +281,6 @@ func ExampleCobraOnInitialize() {
 // ExampleStringFlag_withViperKey demonstrates using a custom ViperKey with a StringFlag.
 // ViperKey allows using different configuration keys than flag names for Viper binding.
 func ExampleStringFlag_withViperKey() {
-	// Simulate setting a configuration value using Viper directly
-	// This demonstrates how ViperKey allows different config keys than flag names
-	viper.Set("app.config.file", "custom.yaml")
-	defer viper.Reset()
-
 	configFlag := &cobraflags.StringFlag{
 		Name:     "config-file",
 		ViperKey: "app.config.file", // Custom Viper key for configuration binding
@@ -307,6 +298,11 @@ func ExampleStringFlag_withViperKey() {
 	}
 
 	configFlag.Register(cmd)
+
+	// Simulate setting a configuration value using the command tree's Viper instance.
+	// This demonstrates how ViperKey allows different config keys than flag names
+	cobraflags.ViperFor(cmd).Set("app.config.file", "custom.yaml")
+
 	cmd.SetArgs(make([]string, 0))
 	_ = cmd.Execute()
 
@@ -1,6 +1,8 @@
 package cobraflags_test
 
 import (
+	"errors"
+	"regexp"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
@@ -51,6 +53,147 @@ func TestRegisterMap(t *testing.T) {
 	c.Assert(flags["name"].GetString(), qt.Equals, expectedValue)
 }
 
+func TestRegisterAllE(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	countFlag := &cobraflags.IntFlag{Name: "count", Value: 10}
+	verboseFlag := &cobraflags.BoolFlag{Name: "verbose", Shorthand: "v"}
+
+	err := cobraflags.RegisterAllE(cmd, countFlag, verboseFlag)
+	c.Assert(err, qt.IsNil)
+
+	cmd.SetArgs([]string{"--count", "3", "-v"})
+	err = cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(countFlag.GetInt(), qt.Equals, 3)
+	c.Assert(verboseFlag.GetBool(), qt.IsTrue)
+}
+
+func TestRegisterAllE_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []cobraflags.Flag
+		target  error
+		message string
+	}{
+		{
+			name: "duplicate_name",
+			flags: []cobraflags.Flag{
+				&cobraflags.StringFlag{Name: "name"},
+				&cobraflags.IntFlag{Name: "name"},
+			},
+			target:  cobraflags.ErrDuplicateFlag,
+			message: `flag already registered: "name" on command "myapp" \(registered at cobraflags_test\.go:\d+, again at cobraflags_test\.go:\d+\)`,
+		},
+		{
+			name: "duplicate_persistent_name",
+			flags: []cobraflags.Flag{
+				&cobraflags.StringFlag{Name: "name", Persistent: true},
+				&cobraflags.StringFlag{Name: "name"},
+			},
+			target:  cobraflags.ErrDuplicateFlag,
+			message: `flag already registered: "name" on command "myapp" \(registered at cobraflags_test\.go:\d+, again at cobraflags_test\.go:\d+\)`,
+		},
+		{
+			name: "long_shorthand",
+			flags: []cobraflags.Flag{
+				&cobraflags.BoolFlag{Name: "verbose", Shorthand: "vv"},
+			},
+			target:  cobraflags.ErrInvalidShorthand,
+			message: `invalid shorthand: "vv" for flag "verbose" is more than one ASCII character`,
+		},
+		{
+			name: "shorthand_in_use",
+			flags: []cobraflags.Flag{
+				&cobraflags.BoolFlag{Name: "verbose", Shorthand: "v"},
+				&cobraflags.StringFlag{Name: "version", Shorthand: "v"},
+			},
+			target:  cobraflags.ErrInvalidShorthand,
+			message: `invalid shorthand: "v" for flag "version" is already used by flag "verbose"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			err := cobraflags.RegisterAllE(newCobraCommand(), tt.flags...)
+
+			c.Assert(errors.Is(err, tt.target), qt.IsTrue)
+			c.Assert(err, qt.ErrorMatches, tt.message)
+		})
+	}
+}
+
+func TestRegisterE_DuplicateSites(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.StringFlag{Name: "name"}).Register(cmd)
+	err := (&cobraflags.StringFlag{Name: "name"}).RegisterE(cmd)
+
+	c.Assert(errors.Is(err, cobraflags.ErrDuplicateFlag), qt.IsTrue)
+	sites := regexp.MustCompile(`^flag already registered: "name" on command "myapp" \(registered at (cobraflags_test\.go:\d+), again at (cobraflags_test\.go:\d+)\)$`).FindStringSubmatch(err.Error())
+	c.Assert(sites, qt.HasLen, 3, qt.Commentf("%v", err))
+	c.Assert(sites[1], qt.Not(qt.Equals), sites[2])
+}
+
+func TestRegisterE_Reuse(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+
+	verboseFlag := &cobraflags.BoolFlag{Name: "verbose", Persistent: true}
+	verboseFlag.Register(root)
+	subVerboseFlag := &cobraflags.BoolFlag{Name: "verbose", Reuse: true}
+	c.Assert(subVerboseFlag.RegisterE(sub), qt.IsNil)
+
+	levelFlag := &cobraflags.IntFlag{Name: "level", Reuse: true} // nothing to reuse
+	c.Assert(levelFlag.RegisterE(sub), qt.IsNil)
+
+	root.SetArgs([]string{"sub", "--verbose", "--level", "2"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(verboseFlag.GetBool(), qt.IsTrue)
+	c.Assert(subVerboseFlag.GetBool(), qt.IsTrue)
+	c.Assert(levelFlag.GetInt(), qt.Equals, 2)
+	c.Assert(cobraflags.FlagsOf(sub), qt.HasLen, 1)
+}
+
+func TestRegisterE_ReuseTypeMismatch(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.StringFlag{Name: "verbose"}).Register(cmd)
+	err := (&cobraflags.BoolFlag{Name: "verbose", Reuse: true}).RegisterE(cmd)
+
+	c.Assert(errors.Is(err, cobraflags.ErrDuplicateFlag), qt.IsTrue)
+	c.Assert(err, qt.ErrorMatches, `flag already registered: "verbose" on command "myapp" has type string, cannot reuse it as bool`)
+}
+
+func TestRegisterE_PersistentRequired(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+
+	flag := &cobraflags.StringFlag{Name: "token", Persistent: true, Required: true}
+	c.Assert(flag.RegisterE(root), qt.IsNil)
+
+	root.SetArgs([]string{"sub"})
+	err := root.Execute()
+	c.Assert(err, qt.ErrorMatches, `required flag\(s\) "token" not set`)
+
+	root.SetArgs([]string{"sub", "--token", "secret"})
+	err = root.Execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(flag.GetString(), qt.Equals, "secret")
+}
+
 func newCobraCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "myapp",
@@ -63,3 +206,81 @@ func newCobraCommand() *cobra.Command {
 		SilenceErrors: true,
 	}
 }
+
+func TestClone(t *testing.T) {
+	c := qt.New(t)
+
+	original := &cobraflags.StringSliceFlag{
+		Name:      "items",
+		Shorthand: "i",
+		Usage:     "Items",
+		Value:     []string{"a"},
+		Required:  true,
+	}
+	clone := original.Clone()
+
+	c.Assert(clone, qt.Not(qt.Equals), original)
+	c.Assert(clone.Name, qt.Equals, original.Name)
+	c.Assert(clone.Shorthand, qt.Equals, original.Shorthand)
+	c.Assert(clone.Usage, qt.Equals, original.Usage)
+	c.Assert(clone.Value, qt.DeepEquals, original.Value)
+	c.Assert(clone.Required, qt.IsTrue)
+
+	clone.Value[0] = "changed"
+	c.Assert(original.Value, qt.DeepEquals, []string{"a"})
+}
+
+func TestRegisterOn(t *testing.T) {
+	c := qt.New(t)
+
+	root := &cobra.Command{Use: "app"}
+	list := &cobra.Command{Use: "list", Run: func(*cobra.Command, []string) {}}
+	show := &cobra.Command{Use: "show", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(list, show)
+
+	output := &cobraflags.StringFlag{Name: "output", Shorthand: "o", Value: "text"}
+	clones := output.RegisterOn(list, show)
+	c.Assert(clones, qt.HasLen, 2)
+	cobraflags.CobraOnInitialize("REGISTERON", root, cobraflags.WithCommandScopedViper())
+
+	root.SetArgs([]string{"list", "-o", "json"})
+	c.Assert(root.Execute(), qt.IsNil)
+	root.SetArgs([]string{"show", "-o", "yaml"})
+	c.Assert(root.Execute(), qt.IsNil)
+
+	c.Assert(clones[1].GetString(), qt.Equals, "yaml")
+	c.Assert(clones[0].GetString(), qt.Equals, "json")
+
+	// The original definition is left unregistered.
+	c.Assert(output.RegisterE(root), qt.IsNil)
+}
+
+func TestRegisterOn_SharedViper(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("REGISTERONSHARED_LEVEL", "debug")
+
+	newTree := func() (*cobra.Command, []*cobraflags.StringFlag, []*cobraflags.StringFlag) {
+		root := &cobra.Command{Use: "app"}
+		a := &cobra.Command{Use: "a", Run: func(*cobra.Command, []string) {}}
+		b := &cobra.Command{Use: "b", Run: func(*cobra.Command, []string) {}}
+		root.AddCommand(a, b)
+		outputs := (&cobraflags.StringFlag{Name: "output", Value: "text"}).RegisterOn(a, b)
+		levels := (&cobraflags.StringFlag{Name: "level", Value: "info"}).RegisterOn(a, b)
+		cobraflags.CobraOnInitialize("REGISTERONSHARED", root)
+		return root, outputs, levels
+	}
+
+	root, outputs, levels := newTree()
+	root.SetArgs([]string{"a", "--output", "json"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(outputs[0].GetString(), qt.Equals, "json")
+	c.Assert(outputs[1].GetString(), qt.Equals, "text")
+	c.Assert(levels[0].GetString(), qt.Equals, "debug")
+	c.Assert(levels[1].GetString(), qt.Equals, "debug")
+
+	root, outputs, _ = newTree()
+	root.SetArgs([]string{"b", "--output", "yaml"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(outputs[0].GetString(), qt.Equals, "text")
+	c.Assert(outputs[1].GetString(), qt.Equals, "yaml")
+}
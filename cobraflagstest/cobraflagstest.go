@@ -0,0 +1,116 @@
+// Package cobraflagstest provides helpers for testing commands built with cobraflags:
+// isolating package state between test cases, setting flag values directly,
+// executing a command with arguments and environment in one call, and asserting
+// on effective flag values and their sources.
+package cobraflagstest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// ResetGlobalState discards all cobraflags package state (see cobraflags.ResetState)
+// now and again when the test finishes, so that Viper instances, registries and
+// initialization state do not leak between test cases.
+func ResetGlobalState(tb testing.TB) {
+	tb.Helper()
+
+	cobraflags.ResetState()
+	tb.Cleanup(cobraflags.ResetState)
+}
+
+// SetFlag sets the value of the flag with the given name on cmd, looking it up in the
+// command's local, persistent and inherited flags. The flag is marked as changed, as if
+// it had been passed on the command line, and the values cached by cobraflags are
+// discarded (see cobraflags.InvalidateCache).
+func SetFlag(cmd *cobra.Command, name, value string) error {
+	flag := lookupFlag(cmd, name)
+	if flag == nil {
+		return fmt.Errorf("flag %q is not defined on command %q", name, cmd.CommandPath())
+	}
+	if err := flag.Value.Set(value); err != nil {
+		return fmt.Errorf("setting flag %q: %w", name, err)
+	}
+	flag.Changed = true
+	cobraflags.InvalidateCache(cmd.Root())
+	return nil
+}
+
+// Execute sets the given environment variables for the duration of the test,
+// executes cmd with args and returns everything the command wrote to its
+// output and error streams together with the execution error.
+func Execute(tb testing.TB, cmd *cobra.Command, args []string, env map[string]string) (string, error) {
+	tb.Helper()
+
+	for key, value := range env {
+		tb.Setenv(key, value)
+	}
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+
+	return out.String(), err
+}
+
+// AssertValue reports a test error if the effective value of the flag with the given
+// name, registered through cobraflags on cmd or one of its ancestors, differs from want.
+func AssertValue(tb testing.TB, cmd *cobra.Command, name string, want any) {
+	tb.Helper()
+
+	info, ok := findInfo(cmd, name)
+	if !ok {
+		tb.Errorf("flag %q is not registered on command %q", name, cmd.CommandPath())
+		return
+	}
+	if !reflect.DeepEqual(info.Value, want) {
+		tb.Errorf("flag %q: got value %#v (from %s), want %#v", name, info.Value, info.Source, want)
+	}
+}
+
+// AssertSource reports a test error if the effective value of the flag with the given
+// name, registered through cobraflags on cmd or one of its ancestors, does not come from want.
+func AssertSource(tb testing.TB, cmd *cobra.Command, name string, want cobraflags.Source) {
+	tb.Helper()
+
+	info, ok := findInfo(cmd, name)
+	if !ok {
+		tb.Errorf("flag %q is not registered on command %q", name, cmd.CommandPath())
+		return
+	}
+	if info.Source != want {
+		tb.Errorf("flag %q: got source %q (value %#v), want %q", name, info.Source, info.Value, want)
+	}
+}
+
+// findInfo looks up a flag registered on cmd or its ancestors by name.
+func findInfo(cmd *cobra.Command, name string) (cobraflags.FlagInfo, bool) {
+	for c := cmd; c != nil; c = c.Parent() {
+		for _, info := range cobraflags.FlagsOf(c) {
+			if info.Name == name {
+				return info, true
+			}
+		}
+	}
+	return cobraflags.FlagInfo{}, false
+}
+
+// lookupFlag finds a flag by name among the flags available to cmd.
+func lookupFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if flag := cmd.Flags().Lookup(name); flag != nil {
+		return flag
+	}
+	if flag := cmd.PersistentFlags().Lookup(name); flag != nil {
+		return flag
+	}
+	return cmd.InheritedFlags().Lookup(name)
+}
@@ -0,0 +1,113 @@
+package cobraflagstest_test
+
+import (
+	"fmt"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+	"github.com/go-extras/cobraflags/cobraflagstest"
+)
+
+func newCommand() (*cobra.Command, *cobra.Command, *cobraflags.IntFlag) {
+	root := &cobra.Command{
+		Use:           "testapp",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	sub := &cobra.Command{
+		Use: "serve",
+		Run: func(cmd *cobra.Command, _ []string) {
+			cmd.Println("serving")
+		},
+	}
+	root.AddCommand(sub)
+
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(sub)
+	(&cobraflags.StringFlag{Name: "host", Value: "localhost", Persistent: true}).Register(root)
+	cobraflags.CobraOnInitialize("TESTAPP", root)
+
+	return root, sub, portFlag
+}
+
+func TestExecute(t *testing.T) {
+	c := qt.New(t)
+	cobraflagstest.ResetGlobalState(t)
+
+	root, sub, portFlag := newCommand()
+
+	out, err := cobraflagstest.Execute(t, root, []string{"serve"}, map[string]string{
+		"TESTAPP_PORT": "8080",
+	})
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(out, qt.Equals, "serving\n")
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	cobraflagstest.AssertValue(t, sub, "port", 8080)
+	cobraflagstest.AssertSource(t, sub, "port", cobraflags.SourceEnv)
+	cobraflagstest.AssertValue(t, sub, "host", "localhost")
+	cobraflagstest.AssertSource(t, sub, "host", cobraflags.SourceDefault)
+}
+
+func TestSetFlag(t *testing.T) {
+	c := qt.New(t)
+	cobraflagstest.ResetGlobalState(t)
+
+	root, sub, portFlag := newCommand()
+	_, err := cobraflagstest.Execute(t, root, []string{"serve"}, nil)
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(cobraflagstest.SetFlag(sub, "port", "9090"), qt.IsNil)
+	c.Assert(cobraflagstest.SetFlag(sub, "host", "example.com"), qt.IsNil)
+
+	c.Assert(portFlag.GetInt(), qt.Equals, 9090)
+	cobraflagstest.AssertSource(t, sub, "port", cobraflags.SourceFlag)
+	cobraflagstest.AssertValue(t, sub, "host", "example.com")
+
+	c.Assert(cobraflagstest.SetFlag(sub, "missing", "x"), qt.ErrorMatches, `flag "missing" is not defined on command "testapp serve"`)
+	c.Assert(cobraflagstest.SetFlag(sub, "port", "abc"), qt.ErrorMatches, `setting flag "port": .*`)
+}
+
+func TestAssertValue_Failure(t *testing.T) {
+	c := qt.New(t)
+	cobraflagstest.ResetGlobalState(t)
+
+	root, sub, _ := newCommand()
+	_, err := cobraflagstest.Execute(t, root, []string{"serve"}, nil)
+	c.Assert(err, qt.IsNil)
+
+	rec := &recorder{TB: t}
+	cobraflagstest.AssertValue(rec, sub, "port", 1)
+	cobraflagstest.AssertSource(rec, sub, "port", cobraflags.SourceEnv)
+	cobraflagstest.AssertValue(rec, sub, "missing", 1)
+
+	c.Assert(rec.errors, qt.DeepEquals, []string{
+		`flag "port": got value 80 (from default), want 1`,
+		`flag "port": got source "default" (value 80), want "env"`,
+		`flag "missing" is not registered on command "testapp serve"`,
+	})
+}
+
+func TestResetGlobalState(t *testing.T) {
+	c := qt.New(t)
+	cobraflagstest.ResetGlobalState(t)
+
+	_, sub, _ := newCommand()
+	c.Assert(cobraflags.FlagsOf(sub), qt.HasLen, 1)
+
+	cobraflags.ResetState()
+	c.Assert(cobraflags.FlagsOf(sub), qt.HasLen, 0)
+}
+
+// recorder captures test errors instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
@@ -1,33 +1,442 @@
 package cobraflags
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/spf13/cast"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
-// initOnceMap stores sync.Once instances per command to prevent multiple initializations
-// of the same command while allowing different commands to be initialized independently
-var initOnceMap = make(map[*cobra.Command]*sync.Once)
+// initState records whether a command tree has been initialized. Unlike sync.Once,
+// it can be rewound (see Restore), so that the tree is initialized again.
+type initState struct {
+	mu    sync.Mutex
+	done  bool
+	hooks []*initHook // guarded by initOnceMutex
+}
+
+// do runs fn unless the state is already marked as done.
+func (s *initState) do(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done {
+		return
+	}
+	s.done = true
+	fn()
+}
+
+// initOnceMap stores initState instances per command to prevent multiple initializations
+// of the same command while allowing different commands to be initialized independently.
+// Entries are removed by UnregisterOnInitialize, ResetCommandState and ResetState.
+var initOnceMap = make(map[*cobra.Command]*initState)
 var initOnceMutex sync.Mutex
 
-var noEnvFlags = map[string]bool{
-	"help": true,
+// initHooks lists the hooks of the initialized command trees, in the order CobraOnInitialize
+// added them. They are run by runInitHooks, the only initializer cobraflags registers with
+// cobra.OnInitialize, since cobra keeps its initializers for the lifetime of the process.
+// Released hooks are removed, see releaseInitState. Guarded by initOnceMutex.
+var initHooks []*initHook
+var registerInitHooks sync.Once
+
+// runInitHooks runs the hooks of all command trees initialized with CobraOnInitialize.
+func runInitHooks() {
+	initOnceMutex.Lock()
+	hooks := slices.Clone(initHooks)
+	initOnceMutex.Unlock()
+	for _, hook := range hooks {
+		hook.run()
+	}
+}
+
+// initHook initializes a command tree as set up by CobraOnInitialize, see initHooks. The
+// help and usage functions of the command refer to it as well, so a released hook drops its
+// references to the command tree and its settings, and is left behind as a no-op.
+type initHook struct {
+	mu        sync.Mutex
+	envPrefix string
+	command   *cobra.Command
+	cfg       *initConfig
+	state     *initState
+}
+
+// run initializes the command tree of the hook, unless it has been initialized already
+// or the hook has been released.
+func (h *initHook) run() {
+	h.mu.Lock()
+	envPrefix, command, cfg, state := h.envPrefix, h.command, h.cfg, h.state
+	h.mu.Unlock()
+	if command == nil {
+		return // The command's state has been discarded, see UnregisterOnInitialize.
+	}
+	state.do(func() {
+		initialize(envPrefix, command, cfg)
+	})
+}
+
+// released reports whether the hook has been released.
+func (h *initHook) released() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.command == nil
+}
+
+// release turns the hook into a no-op.
+func (h *initHook) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.command, h.cfg, h.state = nil, nil, nil
+}
+
+// releaseInitState releases the hooks of the command's initState and removes it, so that
+// the command is no longer initialized. The caller must hold initOnceMutex.
+func releaseInitState(command *cobra.Command) {
+	state, ok := initOnceMap[command]
+	if !ok {
+		return
+	}
+	for _, hook := range state.hooks {
+		hook.release()
+	}
+	initHooks = slices.DeleteFunc(initHooks, func(hook *initHook) bool {
+		return slices.Contains(state.hooks, hook)
+	})
+	delete(initOnceMap, command)
+}
+
+// noEnvFlags lists the flags that are never bound to environment variables, see ExcludeFromEnv.
+var noEnvFlags = defaultNoEnvFlags()
+var noEnvFlagsMutex sync.RWMutex
+
+// defaultNoEnvFlags returns the flags excluded from environment binding by default.
+func defaultNoEnvFlags() map[string]bool {
+	return map[string]bool{
+		"help": true,
+	}
+}
+
+// ExcludeFromEnv excludes the flags with the given names, on any command, from being
+// preset from environment variables by CobraOnInitialize. Their usage text is left
+// unchanged as well. This is meant for flags that cobraflags does not manage, such as
+// those generated by cobra: the help flag is always excluded, others can be added:
+//
+//	cobraflags.ExcludeFromEnv("version")
+//
+// Flags registered through cobraflags are read through Viper, which still consults
+// the environment for them.
+func ExcludeFromEnv(names ...string) {
+	noEnvFlagsMutex.Lock()
+	defer noEnvFlagsMutex.Unlock()
+
+	for _, name := range names {
+		noEnvFlags[name] = true
+	}
+}
+
+// excludedFromEnv reports whether the named flag is excluded from environment binding.
+func excludedFromEnv(name string) bool {
+	noEnvFlagsMutex.RLock()
+	defer noEnvFlagsMutex.RUnlock()
+
+	return noEnvFlags[name]
+}
+
+// InitOption configures the behavior of CobraOnInitialize.
+type InitOption func(*initConfig)
+
+// initConfig holds the settings collected from InitOptions.
+type initConfig struct {
+	viper          *viper.Viper
+	commandScoped  bool
+	caseSensitive  bool
+	configFile     *configFile
+	remoteConfig   *remoteConfig
+	noEnvUsage     bool
+	envUsageFormat func(usage, envVar string) string
+	strictEnv      bool
+	allowEmptyEnv  bool
+	replacer       *strings.Replacer
+	noAutomaticEnv bool
+	fileEnv        bool
+	envSeparator   string
+	trimSpace      bool
+	secretsDir     string
+	valueStore     ValueStore
+	deprecationOut io.Writer
+	experimental   bool
+	expandEnv      bool
+	strictConfig   bool
+	warnConfigKeys bool
+	overlayFlag    string
+	configIncludes bool
+	setFlag        bool
+	commandPathEnv bool
+	envNameFunc    func(prefix, key string) string
+	translator     Translator
+	envPrefix      string // the prefix passed to CobraOnInitialize
+}
+
+// defaultKeyReplacer maps Viper keys to environment variable names, e.g. "server.max-conns"
+// to "SERVER_MAX_CONNS" (after upper-casing).
+var defaultKeyReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// keyReplacer returns the replacer for environment variable names, see WithKeyReplacer.
+func (c *initConfig) keyReplacer() *strings.Replacer {
+	if c.replacer != nil {
+		return c.replacer
+	}
+	return defaultKeyReplacer
+}
+
+// bindEnvExplicitly reports whether environment variables must be bound to the Viper keys
+// one by one, because Viper's AutomaticEnv would derive different names than cobraflags.
+func (c *initConfig) bindEnvExplicitly() bool {
+	return c.noAutomaticEnv || c.commandPathEnv || c.envNameFunc != nil || c.caseSensitive
+}
+
+// check returns an error if the options cannot be combined.
+func (c *initConfig) check() error {
+	if c.caseSensitive && c.viper != nil {
+		return errors.New("cobraflags: WithCaseSensitiveKeys cannot be combined with WithViper")
+	}
+	return nil
+}
+
+// initConfigs stores the settings passed to CobraOnInitialize, keyed by the command
+// it was called with, so that PresetRequiredFlags can find them.
+var initConfigs = make(map[*cobra.Command]*initConfig)
+var initConfigsMutex sync.Mutex
+
+// configFor returns the settings of the closest CobraOnInitialize call made for cmd
+// or one of its ancestors, or the defaults if there is none.
+func configFor(cmd *cobra.Command) *initConfig {
+	cfg, _ := lookupConfig(cmd)
+	return cfg
+}
+
+// lookupConfig returns the settings of cmd like configFor, and whether CobraOnInitialize
+// was called for it or one of its ancestors.
+func lookupConfig(cmd *cobra.Command) (*initConfig, bool) {
+	initConfigsMutex.Lock()
+	defer initConfigsMutex.Unlock()
+
+	for c := cmd; c != nil; c = c.Parent() {
+		if cfg, ok := initConfigs[c]; ok {
+			return cfg, true
+		}
+	}
+	return &initConfig{}, false
+}
+
+// WithViper makes the command tree bind into the given Viper instance instead of
+// a dedicated one created by cobraflags. This is useful for applications that already
+// manage their own instance (config files, remote providers), including the global one:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd, cobraflags.WithViper(viper.GetViper()))
+//
+// The instance is attached to the root command immediately, so it is also returned
+// by ViperFor from then on. Flags that were already read keep their previous binding.
+func WithViper(v *viper.Viper) InitOption {
+	return func(c *initConfig) {
+		c.viper = v
+	}
+}
+
+// WithoutEnvUsage leaves the usage text of flags unchanged. By default, the help and usage
+// output of commands initialized with CobraOnInitialize shows the environment variable of
+// each flag, e.g. "Server port [env: MYAPP_PORT]", which may be undesirable with custom help
+// templates. To opt out for individual flags, set FlagBase.NoEnvUsage instead.
+func WithoutEnvUsage() InitOption {
+	return func(c *initConfig) {
+		c.noEnvUsage = true
+	}
+}
+
+// WithEnvUsageFormat customizes how the environment variable of a flag is rendered in
+// its usage text. The function receives the usage text as registered and the name of
+// the environment variable, and returns the text to show, e.g. for "Server port (env $MYAPP_PORT)":
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd, cobraflags.WithEnvUsageFormat(
+//		func(usage, envVar string) string {
+//			return fmt.Sprintf("%s (env $%s)", usage, envVar)
+//		}))
+func WithEnvUsageFormat(format func(usage, envVar string) string) InitOption {
+	return func(c *initConfig) {
+		c.envUsageFormat = format
+	}
+}
+
+// WithStrictEnv makes the command execution fail if an environment variable (or a
+// configuration value) cannot be converted to the type of its flag, e.g. MYAPP_PORT=abc
+// for an IntFlag. The error names the variable. By default, such values are ignored
+// when presetting the flag.
+//
+// Flags of different definitions that resolve to the same Viper key or environment
+// variable make the execution fail as well, instead of only logging a warning.
+func WithStrictEnv() InitOption {
+	return func(c *initConfig) {
+		c.strictEnv = true
+	}
+}
+
+// WithAllowEmptyEnv makes environment variables that are set but empty override the
+// values of all flags, e.g. MYAPP_SUFFIX="" clears a non-empty default. By default,
+// empty variables are treated as unset. To opt in for individual flags, set
+// FlagBase.AllowEmptyEnv instead. An empty value that is not valid for the flag's type
+// (e.g. for an IntFlag) is ignored, unless WithStrictEnv is given.
+func WithAllowEmptyEnv() InitOption {
+	return func(c *initConfig) {
+		c.allowEmptyEnv = true
+	}
+}
+
+// WithKeyReplacer sets the replacer that derives environment variable names from the
+// upper-cased prefix and Viper key, joined with an underscore. The default replaces
+// "-" and "." with "_", so that the key "server.max-conns" with the prefix "MYAPP"
+// maps to MYAPP_SERVER_MAX_CONNS. To use double underscores for nesting instead:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd,
+//		cobraflags.WithKeyReplacer(strings.NewReplacer(".", "__", "-", "_")))
+func WithKeyReplacer(r *strings.Replacer) InitOption {
+	return func(c *initConfig) {
+		c.replacer = r
+	}
+}
+
+// WithCommandPathEnv includes the path of the command that defines a flag in the name of
+// its environment variable, so that flag port of the command "myapp server" maps to
+// MYAPP_SERVER_PORT, while flags of the root command keep their names. This prevents
+// collisions between subcommands that define flags with the same name but different
+// meanings. Persistent flags are named after the command that defines them. Combine
+// with WithCommandScopedViper to keep the values of such flags apart in Viper as well.
+//
+// Since Viper's AutomaticEnv would look up the names without the command path,
+// WithCommandPathEnv implies WithoutAutomaticEnv.
+func WithCommandPathEnv() InitOption {
+	return func(c *initConfig) {
+		c.commandPathEnv = true
+	}
+}
+
+// WithEnvNameFunc derives the environment variable names of flags with fn, which is
+// called with the prefix passed to CobraOnInitialize and the flag's Viper key, instead
+// of joining them with an underscore and applying the key replacer (see WithKeyReplacer).
+// This supports naming conventions a replacer cannot express, such as double underscores
+// between all parts of the name. Explicit EnvVar names are used as they are.
+//
+// Since Viper's AutomaticEnv would derive different names, WithEnvNameFunc implies
+// WithoutAutomaticEnv.
+//
+// Example:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd, cobraflags.WithEnvNameFunc(
+//		func(prefix, key string) string { // server.port → MYAPP__SERVER__PORT
+//			return prefix + "__" + strings.ToUpper(strings.NewReplacer(".", "__", "-", "_").Replace(key))
+//		}))
+func WithEnvNameFunc(fn func(prefix, key string) string) InitOption {
+	return func(c *initConfig) {
+		c.envNameFunc = fn
+	}
+}
+
+// WithoutAutomaticEnv binds only the environment variables of registered flags, instead
+// of enabling Viper's AutomaticEnv, which makes every lookup on the Viper instance (such
+// as ViperFor(cmd).Get("anything")) consult the environment.
+func WithoutAutomaticEnv() InitOption {
+	return func(c *initConfig) {
+		c.noAutomaticEnv = true
+	}
+}
+
+// WithFileEnv enables the convention of Docker and Kubernetes secrets for all flags:
+// if the environment variable of a flag is not set, but the same name with a "_FILE"
+// suffix is, e.g. MYAPP_DB_PASSWORD_FILE=/run/secrets/db, the flag's value is read
+// from the named file, with surrounding whitespace trimmed. This keeps secrets out of
+// environment listings. To opt in for individual flags, set FlagBase.FileEnv instead.
+//
+// The source of such values is reported as SourceFile. A file that cannot be read
+// is ignored, unless WithStrictEnv is given.
+func WithFileEnv() InitOption {
+	return func(c *initConfig) {
+		c.fileEnv = true
+	}
+}
+
+// WithEnvSeparator sets the separator of the items of slice flags given in environment
+// variables, e.g. ";" or "\n", so that items may contain commas. By default, the items
+// are comma-separated values, which need quoting to contain commas ("a,b",c). To set the
+// separator of individual flags, set FlagBase.EnvSeparator instead.
+//
+// The separator also applies to the files read for WithFileEnv, and to the defaults
+// written by the deployment file generators (see GenDockerfile).
+func WithEnvSeparator(sep string) InitOption {
+	return func(c *initConfig) {
+		c.envSeparator = sep
+	}
+}
+
+// envSeparatorOf returns the separator of slice items in the environment variable of f,
+// or "" for comma-separated values, see WithEnvSeparator.
+func envSeparatorOf(cfg *initConfig, f *pflag.Flag) string {
+	if annotations := f.Annotations[envSeparatorAnnotation]; len(annotations) > 0 {
+		return annotations[0]
+	}
+	return cfg.envSeparator
+}
+
+// WithTrimSpace removes leading and trailing whitespace, such as the trailing newline of
+// MYAPP_TOKEN="$(cat token.txt)", from the values of environment variables and configuration
+// files before they are parsed and validated. The items of slice flags are trimmed one by one.
+// A value consisting only of whitespace leaves the flag at its default. To trim the values of
+// individual flags, set FlagBase.TrimSpace instead.
+func WithTrimSpace() InitOption {
+	return func(c *initConfig) {
+		c.trimSpace = true
+	}
+}
+
+// trimSpaceOf reports whether the values of f from environment variables and configuration
+// files are trimmed, see WithTrimSpace.
+func trimSpaceOf(cfg *initConfig, f *pflag.Flag) bool {
+	return cfg.trimSpace || len(f.Annotations[trimSpaceAnnotation]) > 0
+}
+
+// keepDefault marks a flag whose environment variable or configuration value is blank after
+// trimming as set to its default, so that Viper does not read the blank value instead.
+func keepDefault(f *pflag.Flag) {
+	f.Changed = true
+	setAnnotation(f, sourceAnnotation, string(SourceDefault))
+}
+
+// keptDefault reports whether keepDefault has been applied to f.
+func keptDefault(f *pflag.Flag) bool {
+	annotations := f.Annotations[sourceAnnotation]
+	return f.Changed && len(annotations) > 0 && annotations[0] == string(SourceDefault)
 }
 
 // CobraOnInitialize initializes Cobra command(s) with automatic environment variable binding.
-// This function sets up Viper to automatically detect and bind environment variables
-// to command flags based on the provided prefix. It should be called after registering
+// This function sets up the command tree's Viper instance (see ViperFor) to automatically
+// detect and bind environment variables to command flags based on the provided prefix. It should be called after registering
 // flags but before executing the Cobra command.
 //
 // Environment Variable Mapping:
 // Flags are automatically mapped to environment variables using the pattern:
 // {envPrefix}_{FLAG_NAME} where FLAG_NAME is the flag name converted to uppercase
-// with hyphens replaced by underscores.
+// with hyphens and dots replaced by underscores (see WithKeyReplacer).
 //
 // Examples:
 //   - Flag "config-file" with prefix "MYAPP" → "MYAPP_CONFIG_FILE"
@@ -38,9 +447,21 @@ var noEnvFlags = map[string]bool{
 // If a flag has a custom ViperKey set, the environment variable will be based
 // on the ViperKey instead of the flag name, following the same transformation rules.
 //
+// Explicit Environment Variables:
+// If a flag has EnvVar set, that exact variable name is used instead and the
+// prefix is not applied.
+//
+// Precedence:
+// Flags given on the command line keep their value. The others are preset from the
+// environment, including deprecated aliases (see FlagBase.EnvAliases) and files named by
+// _FILE variables (see WithFileEnv), or else from the configuration files and other
+// values of the Viper instance.
+//
 // Parameters:
 //   - envPrefix: Environment variable prefix (without trailing underscore)
 //   - command: Root Cobra command to initialize (subcommands are processed recursively)
+//   - opts: Optional settings, e.g. WithViper, WithConfigFile, WithStrictEnv,
+//     WithKeyReplacer or WithoutAutomaticEnv
 //
 // Usage Example:
 //
@@ -51,35 +472,241 @@ var noEnvFlags = map[string]bool{
 //	cmd.Execute()
 //
 // Note: This function modifies the help function to ensure initialization occurs
-// before help is displayed, and uses sync.Once to prevent multiple initializations.
-func CobraOnInitialize(envPrefix string, command *cobra.Command) {
-	// Get or create a sync.Once for this specific command
+// before help is displayed, and ensures that each command tree is initialized only once.
+// It registers a single initializer with cobra.OnInitialize, on its first call, which
+// initializes all command trees set up with CobraOnInitialize. It may be called
+// concurrently with other calls and with flag registration, but the first call not while
+// a command is executed, since cobra reads its initializers unsynchronized.
+func CobraOnInitialize(envPrefix string, command *cobra.Command, opts ...InitOption) {
+	cfg := initConfig{envPrefix: envPrefix}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	setupMutex.Lock()
+	defer setupMutex.Unlock()
+
+	initConfigsMutex.Lock()
+	initConfigs[command] = &cfg
+	initConfigsMutex.Unlock()
+	if cfg.viper != nil {
+		setViper(command, cfg.viper)
+	}
+	if cfg.commandScoped {
+		setCommandScoped(command)
+	}
+	if cfg.caseSensitive {
+		setCaseSensitive(command)
+	}
+	if cfg.setFlag {
+		addSetFlag(command)
+	}
+
+	// Get or create an initState for this specific command
 	initOnceMutex.Lock()
 	initOnce, exists := initOnceMap[command]
 	if !exists {
-		initOnce = &sync.Once{}
+		initOnce = &initState{}
 		initOnceMap[command] = initOnce
 	}
+	hook := &initHook{envPrefix: envPrefix, command: command, cfg: &cfg, state: initOnce}
+	initOnce.hooks = append(initOnce.hooks, hook)
+	initHooks = append(initHooks, hook)
 	initOnceMutex.Unlock()
 
-	cobraInit := func() {
-		initOnce.Do(func() {
-			visited := make(map[*pflag.Flag]bool)
-			viper.AutomaticEnv()                          // Enable automatic detection of environment variables.
-			viper.SetEnvPrefix(envPrefix)                 // Set the prefix for environment variables.
-			replacer := strings.NewReplacer("-", "_")     // Create a replacer for environment variable names.
-			viper.SetEnvKeyReplacer(replacer)             // Set the replacer for Viper.
-			PostInitCommands(envPrefix, visited, command) // Initialize commands with environment variable values.
-		})
+	help := command.HelpFunc()
+	command.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		hook.run()
+		if hook.released() {
+			help(cmd, args)
+			return
+		}
+		defer decorateUsage(cmd)()
+		help(cmd, args)
+	})
+	usage := command.UsageFunc()
+	command.SetUsageFunc(func(cmd *cobra.Command) error {
+		if hook.released() {
+			return usage(cmd)
+		}
+		defer decorateUsage(cmd)()
+		return usage(cmd)
+	})
+
+	registerInitHooks.Do(func() {
+		cobra.OnInitialize(runInitHooks)
+	})
+}
+
+// initialize reads the configuration of the command tree and presets its flags.
+func initialize(envPrefix string, command *cobra.Command, cfg *initConfig) {
+	installRequiredCheck(command)
+	if err := initializeE(envPrefix, command, cfg); err != nil {
+		failExecution(command, err)
 	}
+}
 
-	fn := command.HelpFunc()
-	command.SetHelpFunc(func(cmd *cobra.Command, args []string) {
-		cobraInit()
-		fn(cmd, args)
+// initializeE is initialize, but returns the error that makes the execution fail instead.
+func initializeE(envPrefix string, command *cobra.Command, cfg *initConfig) error {
+	if err := cfg.check(); err != nil {
+		return err
+	}
+	if err := checkDependencyGraph(command); err != nil {
+		return err
+	}
+	if err := readConfig(envPrefix, command, cfg); err != nil {
+		return err
+	}
+	if err := checkConfigKeys(command, cfg); err != nil {
+		return err
+	}
+	if err := applySetFlag(command); err != nil {
+		return err
+	}
+	if err := readRemoteConfig(command, cfg.remoteConfig); err != nil {
+		return err
+	}
+	if err := readValueStore(command, cfg.valueStore); err != nil {
+		return err
+	}
+	if err := readSecretsDir(command, cfg.secretsDir); err != nil {
+		return err
+	}
+
+	visited := make(map[*pflag.Flag]bool)
+	// Initialize commands with environment variable values.
+	if err := postInitCommands(envPrefix, visited, command); err != nil &&
+		(cfg.strictEnv || errors.Is(err, ErrRenamedFlagConflict)) {
+		return err
+	}
+	return reportCollisions(command, cfg.strictEnv)
+}
+
+// UnregisterOnInitialize undoes CobraOnInitialize for command: the command tree is no
+// longer initialized on execution, and the settings passed to CobraOnInitialize are
+// discarded, so that the tree can be garbage collected. Values already preset on the
+// flags are kept.
+//
+// Processes that build many command trees, such as tests or REPL-style hosts, should
+// call it (or ResetCommandState) once a tree is no longer used.
+func UnregisterOnInitialize(command *cobra.Command) {
+	initOnceMutex.Lock()
+	releaseInitState(command)
+	initOnceMutex.Unlock()
+
+	initConfigsMutex.Lock()
+	delete(initConfigs, command)
+	initConfigsMutex.Unlock()
+}
+
+// ResetCommandState discards all state cobraflags keeps for cmd and its subcommands,
+// like ResetState does for all commands: their Viper instances (see ViperFor), their
+// flag and argument registrations, their initialization (see UnregisterOnInitialize) and
+// their configuration watcher (see WatchConfig). Flags read afterwards are bound to a new
+// Viper instance. cmd is usually a root command.
+//
+// Use it to tear down a command tree in a long-running process that builds many of them.
+func ResetCommandState(cmd *cobra.Command) {
+	walkCommands(cmd, func(c *cobra.Command) {
+		UnregisterOnInitialize(c)
+		stopWatching(c)
+
+		vipersMutex.Lock()
+		delete(vipers, c)
+		delete(scopedRoots, c)
+		delete(caseSensitiveRoots, c)
+		assignments.Add(1)
+		vipersMutex.Unlock()
+
+		registryMutex.Lock()
+		delete(registry, c)
+		registryMutex.Unlock()
+
+		commandArgsMutex.Lock()
+		delete(commandArgs, c)
+		commandArgsMutex.Unlock()
+
+		helpConfigsMutex.Lock()
+		delete(helpConfigs, c)
+		helpConfigsMutex.Unlock()
 	})
+}
 
-	cobra.OnInitialize(cobraInit)
+// CobraOnInitializeE is like CobraOnInitialize, but reports errors instead of ignoring them.
+// It returns an error right away, without initializing the command tree, if:
+//   - command is nil;
+//   - envPrefix is not a valid environment variable name;
+//   - the options cannot be combined, such as WithCaseSensitiveKeys and WithViper;
+//   - the dependencies between the flags registered so far are invalid (see ErrInvalidDependency).
+//
+// All other errors depend on the command line, e.g. on the configuration file given with
+// ConfigFileFlag, so they can only occur once a command of the tree is executed. Execute
+// then returns them after parsing the flags, before any PreRun or Run hook, but from the
+// positional argument check (cobra offers initializers no other way to fail). These are:
+//   - a configuration file that cannot be read or parsed, including overlays and includes
+//     (see WithConfigFile and ConfigFileFlag), and unknown keys with WithStrictConfig;
+//   - invalid --set assignments (see WithSetFlag);
+//   - failures to read the remote configuration, the value store or the secrets directory
+//     (see WithRemoteConfig, WithValueStore and WithSecretsDir);
+//   - environment, configuration or secret values that cannot be converted to the type of
+//     their flag (see WithStrictEnv, which is always enabled), and failures to bind flags
+//     to Viper;
+//   - a flag set under its current and its former name (see ErrRenamedFlagConflict);
+//   - distinct flags sharing a Viper key or an environment variable, as one error joining
+//     all collisions;
+//   - invalid dependencies of flags registered after the call (see ErrInvalidDependency);
+//   - defaults computed with FlagBase.DefaultFunc that fail, are invalid or depend on each
+//     other in a cycle (see ErrDefaultCycle).
+//
+// Example:
+//
+//	if err := cobraflags.CobraOnInitializeE("MYAPP", rootCmd); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := rootCmd.Execute(); err != nil { // e.g. "environment variable MYAPP_PORT: invalid argument ..."
+//		log.Fatal(err)
+//	}
+func CobraOnInitializeE(envPrefix string, command *cobra.Command, opts ...InitOption) error {
+	if command == nil {
+		return errors.New("cobraflags: command must not be nil")
+	}
+	if strings.IndexFunc(envPrefix, func(r rune) bool {
+		return r != '_' && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	}) >= 0 {
+		return fmt.Errorf("cobraflags: invalid environment variable prefix %q", envPrefix)
+	}
+	cfg := initConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.check(); err != nil {
+		return err
+	}
+	if err := checkDependencyGraph(command); err != nil {
+		return err
+	}
+
+	CobraOnInitialize(envPrefix, command, append(opts, WithStrictEnv())...)
+	return nil
+}
+
+// failExecution makes the next execution of any command in the tree rooted at root
+// fail with err. Initializers registered with cobra.OnInitialize cannot return errors,
+// but cobra validates the positional arguments right after running them, so err is
+// returned from there. Subsequent executions are not affected.
+func failExecution(root *cobra.Command, err error) {
+	original := make(map[*cobra.Command]cobra.PositionalArgs)
+	walkCommands(root, func(c *cobra.Command) {
+		original[c] = c.Args
+	})
+	for c := range original {
+		c.Args = func(*cobra.Command, []string) error {
+			for c, args := range original {
+				c.Args = args
+			}
+			return err
+		}
+	}
 }
 
 // PostInitCommands iterates through the given slice of Cobra commands
@@ -91,18 +718,36 @@ func CobraOnInitialize(envPrefix string, command *cobra.Command) {
 // - commands: A slice of Cobra commands to be initialized.
 //
 // This function is called recursively for each command that contains subcommands,
-// ensuring that the entire command tree is covered.
+// ensuring that the entire command tree is covered. The persistent flags of the given
+// commands' ancestors are visited as well, while those inherited by the subcommands are
+// visited once, with the command that defines them.
 func PostInitCommands(envPrefix string, flags map[*pflag.Flag]bool, commands ...*cobra.Command) {
+	_ = postInitCommands(envPrefix, flags, commands...)
+}
+
+// postInitCommands is PostInitCommands, but returns the errors of presetting values, see WithStrictEnv.
+func postInitCommands(envPrefix string, flags map[*pflag.Flag]bool, commands ...*cobra.Command) error {
+	var errs []error
 	for _, cmd := range commands {
-		PresetRequiredFlags(envPrefix, flags, cmd) // Bind environment variables to command flags.
-		if cmd.HasSubCommands() {
-			PostInitCommands(envPrefix, flags, cmd.Commands()...) // Recursively initialize subcommands.
-		}
+		errs = append(errs, presetTree(envPrefix, flags, cmd, true))
+	}
+	return errors.Join(errs...)
+}
+
+// presetTree presets the flags of cmd and its subcommands. The flags inherited by the
+// subcommands have been visited with their parents already, so that each flag set is
+// visited once, unless every command has a Viper instance to bind them into.
+func presetTree(envPrefix string, flags map[*pflag.Flag]bool, cmd *cobra.Command, inherited bool) error {
+	errs := []error{presetFlagSets(envPrefix, flags, cmd, inherited)} // Bind environment variables to command flags.
+	for _, sub := range cmd.Commands() {
+		errs = append(errs, presetTree(envPrefix, flags, sub, false)) // Recursively initialize subcommands.
 	}
+	return errors.Join(errs...)
 }
 
-// PresetRequiredFlags binds each flag of the given Cobra command
-// to a corresponding environment variable, if such a variable is set.
+// PresetRequiredFlags binds each flag of the given Cobra command, including the
+// persistent flags inherited from its ancestors, to a corresponding environment
+// variable, if such a variable is set.
 // This function uses Viper to read the environment variable that matches
 // the flag name and sets the flag's value accordingly.
 //
@@ -112,29 +757,527 @@ func PostInitCommands(envPrefix string, flags map[*pflag.Flag]bool, commands ...
 // This function iterates through all flags of the given command,
 // binding them to environment variables and setting their values if applicable.
 func PresetRequiredFlags(envPrefix string, flags map[*pflag.Flag]bool, cmd *cobra.Command) {
-	_ = viper.BindPFlags(cmd.Flags()) // Bind the command's flags to Viper.
-	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+	_ = presetFlags(envPrefix, flags, cmd)
+}
+
+// presetFlags is PresetRequiredFlags, but returns the errors of presetting values that
+// cannot be parsed, see WithStrictEnv.
+func presetFlags(envPrefix string, flags map[*pflag.Flag]bool, cmd *cobra.Command) error {
+	return presetFlagSets(envPrefix, flags, cmd, true)
+}
+
+// flagSet is a flag set of a command, together with the command that defines its flags.
+type flagSet struct {
+	owner *cobra.Command
+	flags *pflag.FlagSet
+}
+
+// flagSetsOf returns the flag sets of cmd: the persistent flags of its ancestors, root
+// first, if inherited is set, then its own persistent and local flags. The local flags
+// include the inherited ones once cobra has merged them, e.g. for the executed command.
+func flagSetsOf(cmd *cobra.Command, inherited bool) []flagSet {
+	var sets []flagSet
+	if inherited {
+		for c := cmd.Parent(); c != nil; c = c.Parent() {
+			sets = append(sets, flagSet{owner: c, flags: c.PersistentFlags()})
+		}
+		slices.Reverse(sets)
+	}
+	return append(sets, flagSet{owner: cmd, flags: cmd.PersistentFlags()}, flagSet{owner: cmd, flags: cmd.Flags()})
+}
+
+// presetFlagSets presets the flags of cmd like presetFlags, including the flags it inherits
+// only if inherited is set.
+func presetFlagSets(envPrefix string, flags map[*pflag.Flag]bool, cmd *cobra.Command, inherited bool) error {
+	var errs []error
+	cfg := configFor(cmd)
+	st := storeFor(cmd)
+	st.lock()
+	defer st.mu.Unlock()
+
+	st.configureEnv(envPrefix, cfg)
+	for _, entry := range registeredOn(cmd) {
+		if err := entry.base.bindInto(cmd, st); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// The inherited flags are bound into the Viper instance of every command that has one.
+	for _, set := range flagSetsOf(cmd, inherited || cfg.commandScoped) {
+		errs = append(errs, presetFlagSet(envPrefix, flags, cfg, st, set))
+	}
+	return errors.Join(errs...)
+}
+
+// presetFlagSet presets the flags of the set that have not been visited yet. The write
+// lock of the store must be held.
+func presetFlagSet(envPrefix string, flags map[*pflag.Flag]bool, cfg *initConfig, st *store, set flagSet) error {
+	var errs []error
+	b := st.values()
+	var path string // The command path of the environment variables, see WithCommandPathEnv.
+	if cfg.commandPathEnv {
+		path = commandEnvPath(set.owner)
+	}
+	set.flags.VisitAll(func(f *pflag.Flag) {
+		// Bind the command's flags to Viper by name, as well as by Viper key. Clones are
+		// bound under a key of their own only, see FlagBase.bindingKey.
+		if len(f.Annotations[cloneAnnotation]) == 0 {
+			if err := st.bindFlag(f.Name, f); err != nil {
+				errs = append(errs, fmt.Errorf("binding flag %q of command %q: %w", f.Name, set.owner.CommandPath(), err))
+			}
+		}
 		if flags[f] {
 			return
 		}
 
 		flags[f] = true
 
-		if noEnvFlags[f.Name] {
+		if err := presetRenamedFlag(set.flags, f); err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if excludedFromEnv(f.Name) || len(f.Annotations[noEnvAnnotation]) > 0 {
+			return
+		}
+
+		viperKey := viperKeyOf(f)
+		envVarName, explicit := pathEnvVar(envPrefix, cfg, path, f)
+		if explicit || cfg.bindEnvExplicitly() {
+			// Explicit and command path names bypass Viper's derivation from the prefix and key.
+			if err := b.BindEnv(viperKey, envVarName); err != nil {
+				errs = append(errs, fmt.Errorf("binding environment variable %s: %w", envVarName, err))
+			}
+		}
+		setAnnotation(f, resolvedEnvVarAnnotation, envVarName)
+
+		if f.Changed {
+			return // The command line takes precedence.
+		}
+
+		if preset, err := presetRenamedEnv(envPrefix, cfg, path, set.flags, f, envVarName); err != nil || preset {
+			if err != nil {
+				errs = append(errs, err)
+			}
+			return
+		}
+
+		if value, alias, ok := lookupEnvAlias(f, envVarName); ok {
+			warnEnvAlias(cfg, alias, envVarName)
+			if trimSpaceOf(cfg, f) && strings.TrimSpace(value) == "" {
+				keepDefault(f)
+				return
+			}
+			if err := presetEnvValue(cfg, set.flags, f, value); err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s: %w", alias, err))
+				return
+			}
+			setAnnotation(f, sourceAnnotation, string(SourceEnv))
 			return
 		}
 
-		viperKey := f.Name
-		if annotations := f.Annotations[viperKeyAnnotation]; len(annotations) > 0 {
-			viperKey = annotations[0]
+		if cfg.fileEnv || len(f.Annotations[fileEnvAnnotation]) > 0 {
+			value, fileVar, ok, err := lookupEnvFile(envVarName)
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			if ok {
+				if err := presetEnvValue(cfg, set.flags, f, value); err != nil {
+					errs = append(errs, fmt.Errorf("file named by environment variable %s: %w", fileVar, err))
+					return
+				}
+				setAnnotation(f, sourceAnnotation, string(SourceFile))
+				return
+			}
 		}
 
-		envVarName := strings.ToUpper(envPrefix + "_" + strings.ReplaceAll(strings.ReplaceAll(viperKey, ".", "_"), "-", "_"))
-		newUsage := fmt.Sprintf("%s [env: %s]", f.Usage, envVarName)
-		f.Usage = newUsage
+		if value, ok := os.LookupEnv(envVarName); ok && value == "" &&
+			(cfg.allowEmptyEnv || len(f.Annotations[allowEmptyEnvAnnotation]) > 0) {
+			if err := presetValue(set.flags, f, ""); err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s: %w", envVarName, err))
+				return
+			}
+			setAnnotation(f, sourceAnnotation, string(SourceEnv))
+			return
+		}
+
+		if value, ok := st.secrets[strings.ToLower(viperKey)]; ok {
+			if env, ok := os.LookupEnv(envVarName); !ok || env == "" { // Environment variables take precedence.
+				if err := presetValue(set.flags, f, value); err != nil {
+					errs = append(errs, fmt.Errorf("secret file %s: %w", viperKey, err))
+					return
+				}
+				setAnnotation(f, sourceAnnotation, string(SourceFile))
+				return
+			}
+		}
 
-		if viper.IsSet(viperKey) && viper.GetString(viperKey) != "" {
-			_ = cmd.Flags().Set(f.Name, viper.GetString(viperKey)) // Set flag value from environment variable.
+		old, err := renamedConfigKey(b, f, viperKey)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if !b.IsSet(viperKey) {
+			if old != "" {
+				errs = append(errs, presetRenamedKey(cfg, b, set.flags, f, old, viperKey))
+			}
+			return
+		}
+		source := sourceOf(b, f, viperKey)
+		if source == SourceEnv {
+			value := b.GetString(viperKey)
+			if trimSpaceOf(cfg, f) && strings.TrimSpace(value) == "" {
+				keepDefault(f)
+				return
+			}
+			if value != "" {
+				if err := presetEnvValue(cfg, set.flags, f, value); err != nil {
+					errs = append(errs, fmt.Errorf("environment variable %s: %w", envVarName, err))
+					return
+				}
+				setAnnotation(f, sourceAnnotation, string(source))
+			}
+			return
+		}
+		preset, err := presetStored(b, set.flags, f, viperKey, trimSpaceOf(cfg, f))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s value: %w", source, err))
+			return
+		}
+		if preset {
+			setAnnotation(f, sourceAnnotation, string(source))
+		} else if trimSpaceOf(cfg, f) && b.GetString(viperKey) != "" {
+			keepDefault(f) // A blank value.
 		}
 	})
+	return errors.Join(errs...)
+}
+
+// presetValue sets the value of a flag as if it was given on the command line.
+// If the value cannot be parsed, the flag keeps its previous value; pflag's
+// own values are left in an unspecified state. Booleans are parsed with parseBool,
+// which accepts more spellings than the command line.
+func presetValue(flags *pflag.FlagSet, f *pflag.Flag, value string) error {
+	if f.Value.Type() == "bool" {
+		b, err := parseBool(value)
+		if err != nil {
+			return redactSecret(f, err, value)
+		}
+		value = strconv.FormatBool(b)
+	}
+	previous := captureFlag(f)
+	if err := flags.Set(f.Name, value); err != nil {
+		restoreFlag(f, previous)
+		return redactSecret(f, err, value)
+	}
+	return nil
+}
+
+// presetStored sets the value of a flag from the value stored under key in b, e.g. read
+// from a configuration file, and reports whether there was a value to set. Lists are set
+// item by item for slice flags, since they have no string form; other empty values are
+// treated as unset. If trim is set, whitespace around the value or the items of a list
+// is removed first (see WithTrimSpace).
+func presetStored(b backend, flags *pflag.FlagSet, f *pflag.Flag, key string, trim bool) (bool, error) {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		if raw := b.Get(key); raw != nil && reflect.TypeOf(raw).Kind() == reflect.Slice {
+			items, err := cast.ToStringSliceE(raw)
+			if err != nil {
+				return false, redactSecret(f, err, fmt.Sprintf("%#v", raw))
+			}
+			if trim {
+				items = trimItems(items)
+			}
+			return true, presetItems(sv, f, items)
+		}
+	}
+
+	value := b.GetString(key)
+	if trim {
+		value = strings.TrimSpace(value)
+	}
+	if value == "" {
+		return false, nil
+	}
+	if sv, ok := f.Value.(pflag.SliceValue); ok && trim {
+		items, err := trimmedCSV(value)
+		if err != nil {
+			return false, err
+		}
+		return true, presetItems(sv, f, items)
+	}
+	return true, presetValue(flags, f, value)
+}
+
+// presetItems sets the items of a slice flag like presetValue.
+func presetItems(sv pflag.SliceValue, f *pflag.Flag, items []string) error {
+	previous := captureFlag(f)
+	if err := sv.Replace(items); err != nil {
+		restoreFlag(f, previous)
+		return err
+	}
+	f.Changed = true
+	return nil
+}
+
+// presetEnvValue sets the value of a flag from an environment variable like presetValue.
+// The items of slice flags are taken from a JSON array, if the value is one, or split at
+// the separator set with WithEnvSeparator, if any, instead of being parsed as comma-separated
+// values. With WithTrimSpace, the value and the items are trimmed.
+func presetEnvValue(cfg *initConfig, flags *pflag.FlagSet, f *pflag.Flag, value string) error {
+	trim := trimSpaceOf(cfg, f)
+	if trim {
+		value = strings.TrimSpace(value)
+	}
+	sv, ok := f.Value.(pflag.SliceValue)
+	if !ok {
+		return presetValue(flags, f, value)
+	}
+
+	items, ok := jsonArray(value)
+	if !ok {
+		sep := envSeparatorOf(cfg, f)
+		switch {
+		case sep == "" && !trim:
+			return presetValue(flags, f, value)
+		case value == "":
+			items = []string{}
+		case sep == "":
+			var err error
+			if items, err = trimmedCSV(value); err != nil {
+				return err
+			}
+		default:
+			items = strings.Split(value, sep)
+		}
+	}
+	if trim {
+		items = trimItems(items)
+	}
+	return presetItems(sv, f, items)
+}
+
+// trimmedCSV returns the trimmed items of comma-separated values, see WithTrimSpace.
+func trimmedCSV(value string) ([]string, error) {
+	items, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return nil, err
+	}
+	return trimItems(items), nil
+}
+
+// trimItems removes leading and trailing whitespace from each item.
+func trimItems(items []string) []string {
+	trimmed := make([]string, len(items))
+	for i, item := range items {
+		trimmed[i] = strings.TrimSpace(item)
+	}
+	return trimmed
+}
+
+// jsonArray returns the elements of value if it is a JSON array: strings unquoted, other
+// elements (numbers, booleans, ...) as written.
+func jsonArray(value string) ([]string, bool) {
+	if !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		return nil, false
+	}
+	var elements []json.RawMessage
+	if err := json.Unmarshal([]byte(value), &elements); err != nil {
+		return nil, false
+	}
+
+	items := make([]string, len(elements))
+	for i, element := range elements {
+		if err := json.Unmarshal(element, &items[i]); err != nil {
+			items[i] = string(element)
+		}
+	}
+	return items, true
+}
+
+// viperKeyOf returns the Viper key a flag is bound to.
+func viperKeyOf(f *pflag.Flag) string {
+	if annotations := f.Annotations[viperKeyAnnotation]; len(annotations) > 0 {
+		return annotations[0]
+	}
+	return f.Name
+}
+
+// flagEnvVar returns the name of the environment variable the flag f of cmd is bound to,
+// and whether it was set explicitly, taking WithCommandPathEnv into account.
+func flagEnvVar(envPrefix string, cfg *initConfig, cmd *cobra.Command, f *pflag.Flag) (string, bool) {
+	var path string
+	if cfg.commandPathEnv {
+		path = commandEnvPath(flagOwner(cmd, f))
+	}
+	return pathEnvVar(envPrefix, cfg, path, f)
+}
+
+// pathEnvVar is flagEnvVar with the command path of the command that defines f, as
+// returned by commandEnvPath, or "" unless WithCommandPathEnv is given.
+func pathEnvVar(envPrefix string, cfg *initConfig, path string, f *pflag.Flag) (string, bool) {
+	key := viperKeyOf(f)
+	if path != "" {
+		key = path + "_" + key
+	}
+	return envVarFor(envPrefix, cfg, f, key)
+}
+
+// commandEnvPath returns the names of the commands from the root (exclusive) down to
+// cmd, joined by underscores.
+func commandEnvPath(cmd *cobra.Command) string {
+	var names []string
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		names = append(names, c.Name())
+	}
+	slices.Reverse(names)
+	return strings.Join(names, "_")
+}
+
+// flagOwner returns the command that defines the flag f of cmd: the ancestor whose
+// persistent flag it is, or cmd itself.
+func flagOwner(cmd *cobra.Command, f *pflag.Flag) *cobra.Command {
+	owner := cmd
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.PersistentFlags().Lookup(f.Name) == f {
+			owner = c
+		}
+	}
+	return owner
+}
+
+// envVarFor returns the name of the environment variable a flag is bound to,
+// and whether it was set explicitly through the EnvVar field. Derived names are
+// built by the function given with WithEnvNameFunc, or else the way Viper looks
+// them up: the prefix and key are joined and upper-cased, then the replacer is applied.
+func envVarFor(envPrefix string, cfg *initConfig, f *pflag.Flag, viperKey string) (string, bool) {
+	if annotations := f.Annotations[envVarAnnotation]; len(annotations) > 0 {
+		return annotations[0], true
+	}
+	if cfg.envNameFunc != nil {
+		return cfg.envNameFunc(envPrefix, viperKey), false
+	}
+	replacer := cfg.keyReplacer()
+	name := viperKey
+	if envPrefix != "" {
+		name = envPrefix + "_" + viperKey
+	}
+	return replacer.Replace(strings.ToUpper(name)), false
+}
+
+// decorateUsage translates the usage text of each flag of cmd (see WithTranslator),
+// appends its environment variable, using the format given with WithEnvUsageFormat, and
+// redacts the defaults of secrets (see FlagBase.Sensitive). It returns a function that
+// restores the original texts, so that they are only formatted while help is shown.
+// Flags decorated already, e.g. when the help output includes the usage output, are
+// skipped.
+func decorateUsage(cmd *cobra.Command) (restore func()) {
+	cfg := configFor(cmd)
+	if helpInstalled(cmd) {
+		return func() {}
+	}
+	format := cfg.envUsageFormat
+	if format == nil {
+		format = func(usage, envVar string) string {
+			return usage + " " + fmt.Sprintf(translate(cmd, MessageEnvUsage), envVar)
+		}
+	}
+
+	var decorated []*pflag.Flag
+	_ = cmd.InheritedFlags()
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if len(f.Annotations[usageAnnotation]) > 0 {
+			return
+		}
+		usage := translate(cmd, f.Usage)
+		if !cfg.noEnvUsage {
+			if envVarName := usageEnvVar(cfg, cmd, f); envVarName != "" {
+				usage = format(usage, envVarName)
+			}
+		}
+		secret := secretFlag(f) && showsDefault(f)
+		if usage == f.Usage && !secret {
+			return
+		}
+		setAnnotation(f, usageAnnotation, f.Usage)
+		f.Usage = usage
+		if secret {
+			setAnnotation(f, defValueAnnotation, f.DefValue)
+			f.DefValue = redacted
+		}
+		decorated = append(decorated, f)
+	})
+
+	return func() {
+		for _, f := range decorated {
+			f.Usage = f.Annotations[usageAnnotation][0]
+			delete(f.Annotations, usageAnnotation)
+			if original := f.Annotations[defValueAnnotation]; len(original) > 0 {
+				f.DefValue = original[0]
+				delete(f.Annotations, defValueAnnotation)
+			}
+		}
+	}
+}
+
+// usageEnvVar returns the environment variable shown in the help output for the flag f of
+// cmd, or "" if none is shown: the flag is excluded from environment binding or sets
+// NoEnvUsage. Flags that have not been preset yet get the name they would be bound to.
+func usageEnvVar(cfg *initConfig, cmd *cobra.Command, f *pflag.Flag) string {
+	if len(f.Annotations[noEnvUsageAnnotation]) > 0 || excludedFromEnv(f.Name) || len(f.Annotations[noEnvAnnotation]) > 0 {
+		return ""
+	}
+	if envVarName := envVarOf(f); envVarName != "" {
+		return envVarName
+	}
+	envVarName, _ := flagEnvVar(cfg.envPrefix, cfg, cmd, f)
+	return envVarName
+}
+
+// lookupEnvFile returns the trimmed content of the file named by the environment variable
+// envVarName with the suffix "_FILE", unless envVarName itself is set, see WithFileEnv.
+func lookupEnvFile(envVarName string) (value, fileVar string, ok bool, err error) {
+	if _, set := os.LookupEnv(envVarName); set {
+		return "", "", false, nil
+	}
+	fileVar = envVarName + "_FILE"
+	path, set := os.LookupEnv(fileVar)
+	if !set || path == "" {
+		return "", "", false, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false, fmt.Errorf("environment variable %s: %w", fileVar, err)
+	}
+	return strings.TrimSpace(string(content)), fileVar, true, nil
+}
+
+// warnedEnvAliases records the deprecated environment variables that have been warned about.
+var warnedEnvAliases sync.Map
+
+// lookupEnvAlias returns the value of the first deprecated alias of a flag's environment
+// variable that is set (see FlagBase.EnvAliases), unless the variable itself is set.
+func lookupEnvAlias(f *pflag.Flag, envVarName string) (value, alias string, ok bool) {
+	if _, set := os.LookupEnv(envVarName); set {
+		return "", "", false
+	}
+	for _, alias := range f.Annotations[envAliasesAnnotation] {
+		if value, set := os.LookupEnv(alias); set && value != "" {
+			return value, alias, true
+		}
+	}
+	return "", "", false
+}
+
+// warnEnvAlias logs a warning about the use of a deprecated environment variable,
+// once per variable, or writes it to the writer set with WithDeprecationWarnings.
+func warnEnvAlias(cfg *initConfig, alias, envVarName string) {
+	if _, warned := warnedEnvAliases.LoadOrStore(alias, true); warned {
+		return
+	}
+	if cfg.deprecationOut != nil {
+		_, _ = fmt.Fprintf(cfg.deprecationOut, "Environment variable %s has been deprecated, use %s instead\n", alias, envVarName)
+		return
+	}
+	slog.Warn("deprecated environment variable, use the new name instead", "name", alias, "replacement", envVarName)
 }
@@ -0,0 +1,631 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// captureLogs redirects the default slog logger to a buffer for the duration of the test.
+func captureLogs(c *qt.C) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	c.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestEnvAliases(t *testing.T) {
+	c := qt.New(t)
+	cobraflags.ResetState() // Aliases are warned about once per process.
+	logs := captureLogs(c)
+	c.Setenv("OLDAPP_PORT", "8080")
+	c.Setenv("LEGACY_HOST", "legacy.example.com")
+	c.Setenv("NEWAPP_HOST", "new.example.com")
+
+	execute := func() (*cobraflags.IntFlag, *cobraflags.StringFlag) {
+		cmd := newCobraCommand()
+		portFlag := &cobraflags.IntFlag{Name: "port", Value: 80, EnvAliases: []string{"MISSING_PORT", "OLDAPP_PORT"}}
+		hostFlag := &cobraflags.StringFlag{Name: "host", Value: "localhost", EnvAliases: []string{"LEGACY_HOST"}}
+		cobraflags.Register(cmd, portFlag, hostFlag)
+		cobraflags.CobraOnInitialize("NEWAPP", cmd)
+		c.Assert(cmd.Execute(), qt.IsNil)
+
+		sources := make(map[string]cobraflags.Source)
+		for _, info := range cobraflags.FlagsOf(cmd) {
+			sources[info.Name] = info.Source
+		}
+		c.Assert(sources, qt.DeepEquals, map[string]cobraflags.Source{
+			"port": cobraflags.SourceEnv,
+			"host": cobraflags.SourceEnv,
+		})
+		return portFlag, hostFlag
+	}
+
+	portFlag, hostFlag := execute()
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(hostFlag.GetString(), qt.Equals, "new.example.com")
+
+	execute()
+	c.Assert(strings.Count(logs.String(), "deprecated environment variable"), qt.Equals, 1)
+	c.Assert(logs.String(), qt.Contains, "name=OLDAPP_PORT replacement=NEWAPP_PORT")
+	c.Assert(logs.String(), qt.Not(qt.Contains), "LEGACY_HOST")
+}
+
+func TestCommandLineOverEnv(t *testing.T) {
+	c := qt.New(t)
+	dir := c.TempDir()
+	writeConfig(c, dir, "token", "file-token")
+	c.Setenv("CLIOVERENVAPP_HOST", "env.example.com")
+	c.Setenv("OLD_PORT", "9000")
+	c.Setenv("CLIOVERENVAPP_TOKEN_FILE", filepath.Join(dir, "token"))
+	c.Setenv("CLIOVERENVAPP_SUFFIX", "")
+
+	cmd := newCobraCommand()
+	hostFlag := &cobraflags.StringFlag{Name: "host"}
+	portFlag := &cobraflags.IntFlag{Name: "port", EnvAliases: []string{"OLD_PORT"}}
+	tokenFlag := &cobraflags.StringFlag{Name: "token", FileEnv: true}
+	suffixFlag := &cobraflags.StringFlag{Name: "suffix", AllowEmptyEnv: true}
+	cobraflags.Register(cmd, hostFlag, portFlag, tokenFlag, suffixFlag)
+	cobraflags.CobraOnInitialize("CLIOVERENVAPP", cmd)
+
+	cmd.SetArgs([]string{"--host", "cli.example.com", "--port", "8080", "--token", "cli-token", "--suffix", "cli"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(hostFlag.GetString(), qt.Equals, "cli.example.com")
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(tokenFlag.GetString(), qt.Equals, "cli-token")
+	c.Assert(suffixFlag.GetString(), qt.Equals, "cli")
+	for _, info := range cobraflags.FlagsOf(cmd) {
+		c.Assert(info.Source, qt.Equals, cobraflags.SourceFlag, qt.Commentf("flag %s", info.Name))
+	}
+}
+
+func TestEnvUsage(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "sub", RunE: func(*cobra.Command, []string) error { return nil }}
+	root.AddCommand(sub)
+	(&cobraflags.IntFlag{Name: "port", Usage: "Server port"}).Register(sub)
+	(&cobraflags.StringFlag{Name: "token", Usage: "API token", NoEnvUsage: true}).Register(sub)
+	cobraflags.CobraOnInitialize("USAGEAPP", root)
+	cobraflags.CobraOnInitialize("USAGEAPP", sub)
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"sub", "--help"})
+	c.Assert(root.Execute(), qt.IsNil)
+
+	c.Assert(out.String(), qt.Matches, `(?s).*--port int\s+Server port \[env: USAGEAPP_PORT\]\n.*`)
+	c.Assert(out.String(), qt.Matches, `(?s).*--token string\s+API token\n.*`)
+	c.Assert(strings.Count(out.String(), "[env:"), qt.Equals, 1)
+
+	// The usage text is only decorated while help is shown.
+	c.Assert(sub.Flags().Lookup("port").Usage, qt.Equals, "Server port")
+
+	// So is the usage output shown on errors.
+	c.Assert(sub.UsageString(), qt.Contains, "Server port [env: USAGEAPP_PORT]")
+}
+
+func TestWithoutEnvUsage(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("NOUSAGE_PORT", "8080")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Usage: "Server port"}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("NOUSAGE", cmd, cobraflags.WithoutEnvUsage())
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(cmd.Flags().Lookup("port").Usage, qt.Equals, "Server port")
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(cobraflags.FlagsOf(cmd)[0].EnvVar, qt.Equals, "NOUSAGE_PORT")
+}
+
+func TestWithEnvUsageFormat(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Usage: "Server port"}).Register(cmd)
+	cobraflags.CobraOnInitialize("FMTAPP", cmd, cobraflags.WithEnvUsageFormat(func(usage, envVar string) string {
+		return fmt.Sprintf("%s (env $%s)", usage, envVar)
+	}))
+
+	c.Assert(cmd.UsageString(), qt.Contains, "Server port (env $FMTAPP_PORT)")
+}
+
+func TestWithStrictEnv(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("STRICTAPP_PORT", "abc")
+	c.Setenv("STRICTAPP_LEVEL", "300")
+	c.Setenv("STRICTAPP_HOST", "example.com")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	levelFlag := &cobraflags.Uint8Flag{Name: "level", Value: 1}
+	hostFlag := &cobraflags.StringFlag{Name: "host"}
+	cobraflags.Register(cmd, portFlag, levelFlag, hostFlag)
+	cobraflags.CobraOnInitialize("STRICTAPP", cmd, cobraflags.WithStrictEnv())
+
+	err := cmd.Execute()
+	c.Assert(err, qt.ErrorMatches, `(?s)environment variable STRICTAPP_LEVEL: invalid argument "300" for "--level" flag: .*`+
+		`\nenvironment variable STRICTAPP_PORT: invalid argument "abc" for "--port" flag: .*`)
+	c.Assert(hostFlag.GetString(), qt.Equals, "example.com")
+}
+
+func TestWithStrictEnv_Config(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "port: abc\n")
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	cobraflags.CobraOnInitialize("STRICTAPP", cmd, cobraflags.WithStrictEnv(), cobraflags.WithConfigFile("config", "yaml", dir))
+
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `config value: invalid argument "abc" for "--port" flag: .*`)
+}
+
+func TestStrictEnv_Disabled(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("LAXAPP_PORT", "abc")
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	cobraflags.CobraOnInitialize("LAXAPP", cmd)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+}
+
+func TestAllowEmptyEnv(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("EMPTYAPP_SUFFIX", "")
+	c.Setenv("EMPTYAPP_NAME", "")
+	c.Setenv("EMPTYAPP_TAGS", "")
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "name: from-config\n")
+
+	cmd := newCobraCommand()
+	suffixFlag := &cobraflags.StringFlag{Name: "suffix", Value: "-dev", AllowEmptyEnv: true}
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags", Value: []string{"a"}, AllowEmptyEnv: true}
+	cobraflags.Register(cmd, suffixFlag, nameFlag, tagsFlag)
+	cobraflags.CobraOnInitialize("EMPTYAPP", cmd, cobraflags.WithConfigFile("config", "yaml", dir))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(suffixFlag.GetString(), qt.Equals, "")
+	c.Assert(nameFlag.GetString(), qt.Equals, "from-config")
+	c.Assert(tagsFlag.GetStringSlice(), qt.HasLen, 0)
+}
+
+func TestWithAllowEmptyEnv(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("EMPTYAPP_NAME", "")
+	c.Setenv("EMPTYAPP_PORT", "")
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "name: from-config\n")
+
+	cmd := newCobraCommand()
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	cobraflags.Register(cmd, nameFlag, portFlag)
+	cobraflags.CobraOnInitialize("EMPTYAPP", cmd, cobraflags.WithAllowEmptyEnv(), cobraflags.WithConfigFile("config", "yaml", dir))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(nameFlag.GetString(), qt.Equals, "")
+	c.Assert(portFlag.GetInt(), qt.Equals, 80)
+}
+
+func TestWithEnvSeparator(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("SEPAPP_HOSTS", "a,1;b,2")
+	c.Setenv("SEPAPP_LINES", "first, line\nsecond")
+	c.Setenv("SEPAPP_TAGS", "x,y")
+
+	cmd := newCobraCommand()
+	hostsFlag := &cobraflags.StringSliceFlag{Name: "hosts"}
+	linesFlag := &cobraflags.StringSliceFlag{Name: "lines", EnvSeparator: "\n"}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags"}
+	cobraflags.Register(cmd, hostsFlag, linesFlag, tagsFlag)
+	cobraflags.CobraOnInitialize("SEPAPP", cmd, cobraflags.WithEnvSeparator(";"))
+
+	cmd.SetArgs([]string{"--tags", "p,q"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(hostsFlag.GetStringSlice(), qt.DeepEquals, []string{"a,1", "b,2"})
+	c.Assert(linesFlag.GetStringSlice(), qt.DeepEquals, []string{"first, line", "second"})
+	c.Assert(tagsFlag.GetStringSlice(), qt.DeepEquals, []string{"p", "q"}) // the command line is not affected
+}
+
+func TestSliceEnv_JSONArray(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("JSONAPP_ITEMS", `["a,b", "c"]`)
+	c.Setenv("JSONAPP_MIXED", ` [1, true, "x;y"]`)
+	c.Setenv("JSONAPP_EMPTY", `[]`)
+	c.Setenv("JSONAPP_INVALID", `[a,b`)
+
+	cmd := newCobraCommand()
+	itemsFlag := &cobraflags.StringSliceFlag{Name: "items"}
+	mixedFlag := &cobraflags.StringSliceFlag{Name: "mixed", EnvSeparator: ";"}
+	emptyFlag := &cobraflags.StringSliceFlag{Name: "empty", Value: []string{"default"}}
+	invalidFlag := &cobraflags.StringSliceFlag{Name: "invalid"}
+	cobraflags.Register(cmd, itemsFlag, mixedFlag, emptyFlag, invalidFlag)
+	cobraflags.CobraOnInitialize("JSONAPP", cmd)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(itemsFlag.GetStringSlice(), qt.DeepEquals, []string{"a,b", "c"})
+	c.Assert(mixedFlag.GetStringSlice(), qt.DeepEquals, []string{"1", "true", "x;y"})
+	c.Assert(emptyFlag.GetStringSlice(), qt.HasLen, 0)
+	c.Assert(invalidFlag.GetStringSlice(), qt.DeepEquals, []string{"[a", "b"}) // not JSON, split as usual
+}
+
+func TestWithTrimSpace(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("TRIMAPP_TOKEN", "secret\n")
+	c.Setenv("TRIMAPP_PORT", " 8080 ")
+	c.Setenv("TRIMAPP_TAGS", " a , b ")
+	c.Setenv("TRIMAPP_LEVEL", " \n")
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "name: '  app  '\nhosts: [' x ', 'y ']\n")
+
+	cmd := newCobraCommand()
+	tokenFlag := &cobraflags.StringFlag{Name: "token"}
+	portFlag := &cobraflags.IntFlag{Name: "port"}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags"}
+	levelFlag := &cobraflags.IntFlag{Name: "level", Value: 3}
+	nameFlag := &cobraflags.StringFlag{Name: "name"}
+	hostsFlag := &cobraflags.StringSliceFlag{Name: "hosts"}
+	cobraflags.Register(cmd, tokenFlag, portFlag, tagsFlag, levelFlag, nameFlag, hostsFlag)
+	cobraflags.CobraOnInitialize("TRIMAPP", cmd, cobraflags.WithTrimSpace(),
+		cobraflags.WithConfigFile("config", "yaml", dir))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(tokenFlag.GetString(), qt.Equals, "secret")
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(tagsFlag.GetStringSlice(), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(levelFlag.GetInt(), qt.Equals, 3)
+	for _, info := range cobraflags.FlagsOf(cmd) {
+		if info.Name == "level" {
+			c.Assert(info.Source, qt.Equals, cobraflags.SourceDefault)
+		}
+	}
+	c.Assert(nameFlag.GetString(), qt.Equals, "app")
+	c.Assert(hostsFlag.GetStringSlice(), qt.DeepEquals, []string{"x", "y"})
+}
+
+func TestTrimSpace_PerFlag(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("TRIMFLAGAPP_TOKEN", "secret\n")
+	c.Setenv("TRIMFLAGAPP_NAME", " app ")
+	c.Setenv("TRIMFLAGAPP_USER", "  ")
+
+	cmd := newCobraCommand()
+	tokenFlag := &cobraflags.StringFlag{Name: "token", TrimSpace: true}
+	nameFlag := &cobraflags.StringFlag{Name: "name"}
+	userFlag := &cobraflags.StringFlag{Name: "user", TrimSpace: true, Required: true}
+	cobraflags.Register(cmd, tokenFlag, nameFlag, userFlag)
+	cobraflags.CobraOnInitialize("TRIMFLAGAPP", cmd)
+
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `required flag "user" not set, use --user, the environment variable TRIMFLAGAPP_USER or the config key "user"`)
+	c.Assert(tokenFlag.GetString(), qt.Equals, "secret")
+	c.Assert(nameFlag.GetString(), qt.Equals, " app ")
+}
+
+func TestExcludeFromEnv(t *testing.T) {
+	c := qt.New(t)
+	c.Cleanup(cobraflags.ResetState)
+	c.Setenv("EXCLAPP_VERSION", "true")
+	c.Setenv("EXCLAPP_WORKERS", "8")
+
+	cmd := newCobraCommand()
+	cmd.Version = "1.0.0"
+	workers := cmd.Flags().Int("workers", 1, "Number of workers")
+	cobraflags.ExcludeFromEnv("version", "workers")
+	cobraflags.CobraOnInitialize("EXCLAPP", cmd)
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	c.Assert(out.String(), qt.Equals, "")
+	c.Assert(*workers, qt.Equals, 1)
+	c.Assert(cmd.Flags().Lookup("workers").Usage, qt.Equals, "Number of workers")
+	c.Assert(cmd.Flags().Lookup("version").Usage, qt.Not(qt.Contains), "env")
+	c.Assert(cmd.Flags().Lookup("help").Usage, qt.Not(qt.Contains), "env")
+}
+
+func TestPostInitCommands_InheritedFlags(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("INHERITAPP_VERBOSE", "true")
+	c.Setenv("INHERITAPP_PORT", "8080")
+
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "sub"}
+	root.AddCommand(sub)
+	verboseFlag := &cobraflags.BoolFlag{Name: "verbose", Usage: "Verbose output", Persistent: true}
+	verboseFlag.Register(root)
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(sub)
+
+	// Initialize the subcommand alone, without executing the tree.
+	cobraflags.PostInitCommands("INHERITAPP", make(map[*pflag.Flag]bool), sub)
+
+	c.Assert(verboseFlag.GetBool(), qt.IsTrue)
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(cobraflags.FlagsOf(root)[0].EnvVar, qt.Equals, "INHERITAPP_VERBOSE")
+	c.Assert(cobraflags.FlagsOf(root)[0].Source, qt.Equals, cobraflags.SourceEnv)
+}
+
+func TestCobraOnInitializeE(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("INITEAPP_PORT", "abc")
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	c.Assert(cobraflags.CobraOnInitializeE("INITEAPP", cmd), qt.IsNil)
+
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `environment variable INITEAPP_PORT: invalid argument "abc" for "--port" flag: .*`)
+
+	// The error is reported once; the tree is initialized by then.
+	c.Assert(cmd.Execute(), qt.IsNil)
+}
+
+func TestCobraOnInitializeE_InvalidArguments(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(cobraflags.CobraOnInitializeE("APP", nil), qt.ErrorMatches, "cobraflags: command must not be nil")
+	c.Assert(cobraflags.CobraOnInitializeE("MY-APP", newCobraCommand()), qt.ErrorMatches, `cobraflags: invalid environment variable prefix "MY-APP"`)
+	c.Assert(cobraflags.CobraOnInitializeE("", newCobraCommand()), qt.IsNil)
+}
+
+func TestUnregisterOnInitialize(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("UNREGAPP_PORT", "9000")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("UNREGAPP", cmd)
+	cobraflags.UnregisterOnInitialize(cmd)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 80)
+
+	// The command can be initialized again.
+	cobraflags.CobraOnInitialize("UNREGAPP", cmd)
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9000)
+}
+
+func TestUnregisterOnInitialize_OtherTrees(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("UNREGA_PORT", "9000")
+	c.Setenv("UNREGB_PORT", "9001")
+
+	newTree := func(prefix string) (*cobra.Command, *cobraflags.IntFlag) {
+		cmd := newCobraCommand()
+		portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+		portFlag.Register(cmd)
+		cobraflags.CobraOnInitialize(prefix, cmd)
+		return cmd, portFlag
+	}
+	a, portA := newTree("UNREGA")
+	for range 100 {
+		cmd, _ := newTree("UNREGC")
+		cobraflags.UnregisterOnInitialize(cmd)
+	}
+	b, portB := newTree("UNREGB")
+	cobraflags.UnregisterOnInitialize(a)
+
+	c.Assert(b.Execute(), qt.IsNil)
+	c.Assert(portB.GetInt(), qt.Equals, 9001)
+	c.Assert(a.Execute(), qt.IsNil)
+	c.Assert(portA.GetInt(), qt.Equals, 80)
+}
+
+func TestResetCommandState(t *testing.T) {
+	c := qt.New(t)
+
+	collected := make(chan struct{})
+	func() {
+		root := newCobraCommand()
+		sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+		root.AddCommand(sub)
+		cobraflags.Register(sub, &cobraflags.StringFlag{Name: "name"})
+		cobraflags.CobraOnInitialize("TEARDOWNAPP", root, cobraflags.WithCommandScopedViper())
+		root.SetArgs([]string{"sub", "--name", "x"})
+		c.Assert(root.Execute(), qt.IsNil)
+		c.Assert(cobraflags.FlagsOf(sub), qt.HasLen, 1)
+
+		cobraflags.ResetCommandState(root)
+		c.Assert(cobraflags.FlagsOf(sub), qt.HasLen, 0)
+		runtime.AddCleanup(root, func(ch chan struct{}) { close(ch) }, collected)
+	}()
+
+	// Neither cobraflags nor the initializer registered with cobra refer to the tree anymore.
+	for range 10 {
+		runtime.GC()
+		select {
+		case <-collected:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	c.Fatal("the command tree has not been garbage collected")
+}
+
+func TestEnvVarNames_DottedViperKey(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("DOTAPP_SERVER_MAX_CONNS", "100")
+
+	cmd := newCobraCommand()
+	connsFlag := &cobraflags.IntFlag{Name: "max-conns", ViperKey: "server.max-conns", Value: 10}
+	connsFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("DOTAPP", cmd)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(connsFlag.GetInt(), qt.Equals, 100)
+	c.Assert(cobraflags.FlagsOf(cmd)[0].Source, qt.Equals, cobraflags.SourceEnv)
+}
+
+func TestWithKeyReplacer(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("NESTAPP__SERVER__PORT", "8080")
+	c.Setenv("NESTAPP_SERVER_PORT", "9090")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("NESTAPP_", cmd, cobraflags.WithKeyReplacer(strings.NewReplacer(".", "__", "-", "_")))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(cobraflags.FlagsOf(cmd)[0].EnvVar, qt.Equals, "NESTAPP__SERVER__PORT")
+}
+
+func TestWithoutAutomaticEnv(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("NOAUTOAPP_PORT", "8080")
+	c.Setenv("NOAUTOAPP_UNRELATED", "value")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("NOAUTOAPP", cmd, cobraflags.WithoutAutomaticEnv())
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(cobraflags.ViperFor(cmd).IsSet("unrelated"), qt.IsFalse)
+}
+
+func TestFileEnv(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "db-password", "s3cret\n")
+	writeConfig(c, dir, "api-token", "ignored")
+	c.Setenv("FILEAPP_DB_PASSWORD_FILE", filepath.Join(dir, "db-password"))
+	c.Setenv("FILEAPP_API_TOKEN", "from-env")
+	c.Setenv("FILEAPP_API_TOKEN_FILE", filepath.Join(dir, "api-token"))
+	c.Setenv("FILEAPP_USER_FILE", filepath.Join(dir, "db-password"))
+
+	cmd := newCobraCommand()
+	passwordFlag := &cobraflags.StringFlag{Name: "db-password", FileEnv: true}
+	tokenFlag := &cobraflags.StringFlag{Name: "api-token", FileEnv: true}
+	userFlag := &cobraflags.StringFlag{Name: "user", Value: "admin"}
+	cobraflags.Register(cmd, passwordFlag, tokenFlag, userFlag)
+	cobraflags.CobraOnInitialize("FILEAPP", cmd)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(passwordFlag.GetString(), qt.Equals, "s3cret")
+	c.Assert(tokenFlag.GetString(), qt.Equals, "from-env")
+	c.Assert(userFlag.GetString(), qt.Equals, "admin")
+
+	sources := make(map[string]cobraflags.Source)
+	for _, info := range cobraflags.FlagsOf(cmd) {
+		sources[info.Name] = info.Source
+	}
+	c.Assert(sources["db-password"], qt.Equals, cobraflags.SourceFile)
+	c.Assert(sources["api-token"], qt.Equals, cobraflags.SourceEnv)
+}
+
+func TestWithFileEnv(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "port", "8080\n")
+	c.Setenv("FILEAPP_PORT_FILE", filepath.Join(dir, "port"))
+	c.Setenv("FILEAPP_HOST_FILE", filepath.Join(dir, "missing"))
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	hostFlag := &cobraflags.StringFlag{Name: "host", Value: "localhost"}
+	cobraflags.Register(cmd, portFlag, hostFlag)
+	cobraflags.CobraOnInitialize("FILEAPP", cmd, cobraflags.WithFileEnv(), cobraflags.WithStrictEnv())
+
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `environment variable FILEAPP_HOST_FILE: open .*missing: no such file or directory`)
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(hostFlag.GetString(), qt.Equals, "localhost")
+}
+
+func TestWithCommandPathEnv(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("PATHAPP_PORT", "1000")
+	c.Setenv("PATHAPP_SERVER_PORT", "8080")
+	c.Setenv("PATHAPP_CLIENT_PORT", "9090")
+	c.Setenv("PATHAPP_LEVEL", "debug")
+	c.Setenv("PATHAPP_SERVER_ADMIN_USER", "root")
+
+	root := &cobra.Command{Use: "pathapp"}
+	server := &cobra.Command{Use: "server", Run: func(*cobra.Command, []string) {}}
+	admin := &cobra.Command{Use: "admin", Run: func(*cobra.Command, []string) {}}
+	client := &cobra.Command{Use: "client", Run: func(*cobra.Command, []string) {}}
+	server.AddCommand(admin)
+	root.AddCommand(server, client)
+
+	levelFlag := &cobraflags.StringFlag{Name: "level", Value: "info", Persistent: true}
+	levelFlag.Register(root)
+	serverPort := &cobraflags.IntFlag{Name: "port", Value: 80, Persistent: true}
+	serverPort.Register(server)
+	clientPort := &cobraflags.IntFlag{Name: "port", Value: 80}
+	clientPort.Register(client)
+	userFlag := &cobraflags.StringFlag{Name: "user"}
+	userFlag.Register(admin)
+	cobraflags.CobraOnInitialize("PATHAPP", root, cobraflags.WithCommandPathEnv(), cobraflags.WithCommandScopedViper())
+
+	root.SetArgs([]string{"server", "admin"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(levelFlag.GetString(), qt.Equals, "debug")
+	c.Assert(serverPort.GetInt(), qt.Equals, 8080)
+	c.Assert(clientPort.GetInt(), qt.Equals, 9090)
+	c.Assert(userFlag.GetString(), qt.Equals, "root")
+
+	envVars := make(map[string]string)
+	for _, cf := range cobraflags.CollectFlags(root) {
+		for _, info := range cf.Flags {
+			envVars[cf.Command+" --"+info.Name] = info.EnvVar
+		}
+	}
+	c.Assert(envVars, qt.DeepEquals, map[string]string{
+		"pathapp --level":             "PATHAPP_LEVEL",
+		"pathapp server --port":       "PATHAPP_SERVER_PORT",
+		"pathapp server admin --user": "PATHAPP_SERVER_ADMIN_USER",
+		"pathapp client --port":       "PATHAPP_CLIENT_PORT",
+	})
+}
+
+func TestWithEnvNameFunc(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("NAMEAPP__SERVER__PORT", "8080")
+	c.Setenv("NAMEAPP_SERVER_PORT", "9090")
+	c.Setenv("NAMEAPP__LOG_LEVEL", "debug")
+	c.Setenv("CUSTOM_HOST", "example.com")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80}
+	levelFlag := &cobraflags.StringFlag{Name: "log-level", Value: "info"}
+	hostFlag := &cobraflags.StringFlag{Name: "host", EnvVar: "CUSTOM_HOST"}
+	cobraflags.Register(cmd, portFlag, levelFlag, hostFlag)
+	cobraflags.CobraOnInitialize("NAMEAPP", cmd, cobraflags.WithEnvNameFunc(func(prefix, key string) string {
+		return prefix + "__" + strings.ToUpper(strings.NewReplacer(".", "__", "-", "_").Replace(key))
+	}))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(levelFlag.GetString(), qt.Equals, "debug")
+	c.Assert(hostFlag.GetString(), qt.Equals, "example.com")
+	c.Assert(cobraflags.FlagsOf(cmd)[0].EnvVar, qt.Equals, "NAMEAPP__SERVER__PORT")
+}
@@ -0,0 +1,155 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// binding is a flag registered through cobraflags, as seen by collision detection.
+type binding struct {
+	cmd    *cobra.Command
+	flag   *pflag.Flag
+	origin any
+}
+
+// String describes the flag for collision reports.
+func (b binding) String() string {
+	return fmt.Sprintf("--%s of %q", b.flag.Name, b.cmd.CommandPath())
+}
+
+// identity returns the pflag.Flag of the flag and the flag it was cloned from
+// (or the flag itself), see clone.
+func (s *FlagBase[T]) identity() (*pflag.Flag, any) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.origin != nil {
+		return s.flag, s.origin
+	}
+	return s.flag, s
+}
+
+// checkViperKey returns an error if a flag already registered on cmd has the same Viper key
+// as s, since both would read the same value on the same command. Collisions across commands,
+// and of keys that differ only in case, are only reported by CobraOnInitialize, when the
+// command tree is complete and it is known whether its commands share a Viper instance and
+// whether its keys are case-sensitive (see WithCaseSensitiveKeys), see findCollisions.
+func (s *FlagBase[T]) checkViperKey(cmd *cobra.Command) error {
+	_, origin := s.identity()
+	key := s.getViperKey()
+	for _, entry := range registeredOn(cmd) {
+		f, o := entry.base.identity()
+		if f == nil || o == origin || viperKeyOf(f) != key {
+			continue
+		}
+		return fmt.Errorf("%w: flags --%s and --%s of command %q share the Viper key %q",
+			ErrViperKeyCollision, f.Name, s.Name, cmd.Name(), key)
+	}
+	return nil
+}
+
+// findCollisions reports flags registered through cobraflags on cmd's command tree that
+// resolve to the same Viper key (of the same Viper instance) or to the same environment
+// variable, and would thus silently share their values. Clones of a flag (see RegisterOn)
+// are not reported: they share the environment variable and configuration key of their
+// definition on purpose, but each is bound under a key scoped to its own command (see
+// FlagBase.bindingKey), so that the values given on the command line never overwrite
+// each other. It must be called after the flags have been preset, so that their
+// environment variables are resolved.
+func findCollisions(cmd *cobra.Command) []error {
+	type keyOf struct {
+		st  *store
+		key string
+	}
+	byKey := make(map[keyOf][]binding)
+	byEnv := make(map[string][]binding)
+	var keys []keyOf
+	var envVars []string
+
+	seen := make(map[*pflag.Flag]bool)
+	walkCommands(cmd, func(c *cobra.Command) {
+		st := storeFor(c)
+		for _, entry := range registeredOn(c) {
+			f, origin := entry.base.identity()
+			if f == nil || seen[f] {
+				continue
+			}
+			seen[f] = true
+			b := binding{cmd: c, flag: f, origin: origin}
+
+			k := keyOf{st: st, key: st.foldKey(viperKeyOf(f))}
+			if _, ok := byKey[k]; !ok {
+				keys = append(keys, k)
+			}
+			byKey[k] = append(byKey[k], b)
+
+			if envVar := envVarOf(f); envVar != "" && len(f.Annotations[resolvedEnvVarAnnotation]) > 0 {
+				if _, ok := byEnv[envVar]; !ok {
+					envVars = append(envVars, envVar)
+				}
+				byEnv[envVar] = append(byEnv[envVar], b)
+			}
+		}
+	})
+
+	var errs []error
+	for _, k := range keys {
+		if !collides(byKey[k]) {
+			continue
+		}
+		if differInCase(byKey[k]) {
+			errs = append(errs, fmt.Errorf("flags %s share the Viper key %q (Viper keys are case-insensitive)",
+				describeBindings(byKey[k]), k.key))
+		} else {
+			errs = append(errs, fmt.Errorf("flags %s share the Viper key %q", describeBindings(byKey[k]), k.key))
+		}
+	}
+	for _, envVar := range envVars {
+		if collides(byEnv[envVar]) {
+			errs = append(errs, fmt.Errorf("flags %s share the environment variable %s", describeBindings(byEnv[envVar]), envVar))
+		}
+	}
+	return errs
+}
+
+// collides reports whether the bindings stem from more than one flag definition.
+func collides(bindings []binding) bool {
+	return slices.ContainsFunc(bindings, func(b binding) bool {
+		return b.origin != bindings[0].origin
+	})
+}
+
+// differInCase reports whether the Viper keys of the bindings are spelled differently.
+func differInCase(bindings []binding) bool {
+	return slices.ContainsFunc(bindings, func(b binding) bool {
+		return viperKeyOf(b.flag) != viperKeyOf(bindings[0].flag)
+	})
+}
+
+// describeBindings lists the bindings for collision reports.
+func describeBindings(bindings []binding) string {
+	names := make([]string, len(bindings))
+	for i, b := range bindings {
+		names[i] = b.String()
+	}
+	return strings.Join(names, " and ")
+}
+
+// reportCollisions logs a warning for every collision found on cmd's command tree
+// (see findCollisions), or returns them as an error if strict.
+func reportCollisions(cmd *cobra.Command, strict bool) error {
+	errs := findCollisions(cmd)
+	if strict {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		slog.Warn("flag collision", "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cobraflags_test
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func newCollidingCommand(opts ...cobraflags.InitOption) *cobra.Command {
+	root := &cobra.Command{Use: "collideapp", SilenceUsage: true, SilenceErrors: true}
+	fetch := &cobra.Command{Use: "fetch", Run: func(*cobra.Command, []string) {}}
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(fetch, serve)
+
+	(&cobraflags.IntFlag{Name: "timeout", Usage: "Fetch timeout in seconds"}).Register(fetch)
+	(&cobraflags.IntFlag{Name: "timeout", Usage: "Idle timeout in seconds"}).Register(serve)
+	(&cobraflags.StringFlag{Name: "level", Persistent: true}).RegisterOn(fetch, serve)
+	cobraflags.CobraOnInitialize("COLLIDEAPP", root, opts...)
+	root.SetArgs([]string{"fetch"})
+	return root
+}
+
+func TestFlagCollisions(t *testing.T) {
+	c := qt.New(t)
+
+	logs := captureLogs(c)
+	c.Assert(newCollidingCommand().Execute(), qt.IsNil)
+	c.Assert(logs.String(), qt.Contains, `flags --timeout of \"collideapp fetch\" and --timeout of \"collideapp serve\" share the Viper key \"timeout\"`)
+	c.Assert(logs.String(), qt.Contains, `flags --timeout of \"collideapp fetch\" and --timeout of \"collideapp serve\" share the environment variable COLLIDEAPP_TIMEOUT`)
+	c.Assert(logs.String(), qt.Not(qt.Contains), "--level")
+}
+
+func TestFlagCollisions_Strict(t *testing.T) {
+	c := qt.New(t)
+
+	err := newCollidingCommand(cobraflags.WithStrictEnv()).Execute()
+	c.Assert(err, qt.ErrorMatches, `flags --timeout of "collideapp fetch" and --timeout of "collideapp serve" share the Viper key "timeout"
+flags --timeout of "collideapp fetch" and --timeout of "collideapp serve" share the environment variable COLLIDEAPP_TIMEOUT`)
+
+	err = newCollidingCommand(cobraflags.WithStrictEnv(), cobraflags.WithCommandScopedViper(), cobraflags.WithCommandPathEnv()).Execute()
+	c.Assert(err, qt.IsNil)
+}
+
+func TestRegisterE_ViperKeyCollision(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", ViperKey: "server.port"}).Register(cmd)
+	err := (&cobraflags.IntFlag{Name: "listen-port", ViperKey: "server.port"}).RegisterE(cmd)
+
+	c.Assert(errors.Is(err, cobraflags.ErrViperKeyCollision), qt.IsTrue)
+	c.Assert(err, qt.ErrorMatches, `viper key collision: flags --port and --listen-port of command "myapp" share the Viper key "server.port"`)
+
+	// A flag named like another flag's Viper key collides as well.
+	err = (&cobraflags.StringFlag{Name: "server.port"}).RegisterE(cmd)
+	c.Assert(errors.Is(err, cobraflags.ErrViperKeyCollision), qt.IsTrue)
+}
+
+func TestFlagCollisions_Clones(t *testing.T) {
+	c := qt.New(t)
+
+	root := &cobra.Command{Use: "cloneapp", SilenceUsage: true, SilenceErrors: true}
+	fetch := &cobra.Command{Use: "fetch", Run: func(*cobra.Command, []string) {}}
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(fetch, serve)
+	levels := (&cobraflags.StringFlag{Name: "level", Value: "info"}).RegisterOn(fetch, serve)
+	cobraflags.CobraOnInitialize("CLONEAPP", root, cobraflags.WithStrictEnv())
+
+	// Clones share the key and environment variable, but not their values.
+	root.SetArgs([]string{"fetch", "--level", "debug"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(levels[0].GetString(), qt.Equals, "debug")
+	c.Assert(levels[1].GetString(), qt.Equals, "info")
+}
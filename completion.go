@@ -0,0 +1,58 @@
+package cobraflags
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// registerCompletion sets up the shell completion of the values of the flag f that s
+// defines on cmd, if they are known: the values allowed by a OneOf validator, or else the
+// files or directories requested with FlagBase.FileExtensions and FlagBase.DirCompletion.
+// It leaves the completion alone if FlagBase.NoCompletion is set or cmd has one for f
+// already, since cobra accepts a single completion function per flag.
+func (s *FlagBase[T]) registerCompletion(cmd *cobra.Command, f *pflag.Flag) error {
+	if s.NoCompletion {
+		return nil
+	}
+	if _, ok := cmd.GetFlagCompletionFunc(f.Name); ok {
+		return nil
+	}
+	values := s.enum()
+	if len(values) == 0 {
+		s.registerPathCompletion(f)
+		return nil
+	}
+
+	completions := make([]cobra.Completion, 0, len(values))
+	for _, value := range values {
+		completions = append(completions, fmt.Sprint(value))
+	}
+	err := cmd.RegisterFlagCompletionFunc(f.Name, func(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		return fmt.Errorf("registering completion of flag %q: %w", f.Name, err)
+	}
+	return nil
+}
+
+// registerPathCompletion annotates f for cobra to complete its value with files of the
+// extensions in FlagBase.FileExtensions, or with directories if FlagBase.DirCompletion
+// is set, like cobra.MarkFlagFilename and cobra.MarkFlagDirname do.
+func (s *FlagBase[T]) registerPathCompletion(f *pflag.Flag) {
+	if len(s.FileExtensions) == 0 && !s.DirCompletion {
+		return
+	}
+	if f.Annotations == nil {
+		f.Annotations = make(map[string][]string)
+	}
+	if len(s.FileExtensions) > 0 {
+		f.Annotations[cobra.BashCompFilenameExt] = slices.Clone(s.FileExtensions)
+	}
+	if s.DirCompletion {
+		f.Annotations[cobra.BashCompSubdirsInDir] = []string{}
+	}
+}
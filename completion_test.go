@@ -0,0 +1,76 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// complete returns the completions cobra offers for args on root.
+func complete(c *qt.C, root *cobra.Command, args ...string) string {
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+	c.Assert(root.Execute(), qt.IsNil)
+	return out.String()
+}
+
+func TestCompletion_OneOf(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+	(&cobraflags.StringFlag{Name: "log-level", Persistent: true, Validator: cobraflags.OneOf("debug", "info", "error")}).Register(root)
+	(&cobraflags.IntFlag{Name: "workers", Validator: cobraflags.OneOf(1, 2, 4)}).Register(serve)
+	(&cobraflags.StringFlag{Name: "host"}).Register(serve)
+
+	c.Assert(complete(c, root, "serve", "--log-level", ""), qt.Equals, "debug\ninfo\nerror\n:4\n")
+	c.Assert(complete(c, root, "serve", "--workers", ""), qt.Equals, "1\n2\n4\n:4\n")
+	c.Assert(complete(c, root, "serve", "--host", ""), qt.Equals, ":0\n")
+}
+
+func TestCompletion_ConfigFileFlag(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	cobraflags.ConfigFileFlag(cmd)
+
+	c.Assert(cmd.PersistentFlags().Lookup("config").Annotations[cobra.BashCompFilenameExt], qt.Contains, "yaml")
+	c.Assert(complete(c, cmd, "--config", ""), qt.Matches, `(?s)json\n.*yaml\n.*:8\n`)
+}
+
+func TestCompletion_NoCompletion(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.StringFlag{Name: "mode", Validator: cobraflags.OneOf("dev", "prod"), NoCompletion: true}).Register(cmd)
+	err := cmd.RegisterFlagCompletionFunc("mode", func(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return []cobra.Completion{"prod"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(complete(c, cmd, "--mode", ""), qt.Equals, "prod\n:4\n")
+}
+
+func TestCompletion_Paths(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	cobraflags.Register(cmd,
+		cobraflags.NewStringFlag("cert", cobraflags.WithFileCompletion[string]("pem", "crt")),
+		cobraflags.NewStringSliceFlag("include", cobraflags.WithDirCompletion[[]string]()),
+		&cobraflags.StringFlag{Name: "key", FileExtensions: []string{"pem"}, NoCompletion: true},
+	)
+
+	c.Assert(cmd.Flags().Lookup("cert").Annotations[cobra.BashCompFilenameExt], qt.DeepEquals, []string{"pem", "crt"})
+	c.Assert(complete(c, cmd, "--cert", ""), qt.Equals, "pem\ncrt\n:8\n")
+	c.Assert(complete(c, cmd, "--include", ""), qt.Equals, ":16\n")
+	_, ok := cmd.Flags().Lookup("key").Annotations[cobra.BashCompFilenameExt]
+	c.Assert(ok, qt.IsFalse)
+}
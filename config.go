@@ -0,0 +1,517 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// configFile describes a configuration file to search for (see WithConfigFile),
+// or the configuration files to merge (see WithConfigFiles).
+type configFile struct {
+	name       string
+	configType string
+	paths      []string
+	files      []string
+}
+
+// WithConfigFile makes CobraOnInitialize read a configuration file into the command
+// tree's Viper instance before flags are preset, so that values are resolved with the
+// precedence default < config file < environment variable < command line.
+//
+// The file is searched for by name (without extension) in the given paths, in order;
+// "$HOME" and other environment variables in the paths are expanded. If configType
+// is empty, it is derived from the file extension. A missing file is not an error;
+// a file that cannot be read or parsed makes the command execution fail.
+//
+// Config keys are the Viper keys of the flags, so a flag with ViperKey "server.port"
+// is set by the nested YAML key port under server.
+//
+// Example:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd,
+//		cobraflags.WithConfigFile("config", "yaml", "$HOME/.myapp", "."))
+func WithConfigFile(name, configType string, paths ...string) InitOption {
+	return func(c *initConfig) {
+		c.configFile = &configFile{name: name, configType: configType, paths: paths}
+	}
+}
+
+// WithConfigFiles makes CobraOnInitialize read the given configuration files and merge them
+// key by key, later files overriding earlier ones, before flags are preset. This enables layered
+// configurations, e.g. a base file shared by all deployments and an override per deployment.
+// The merged configuration takes the place of a single file: values are resolved with the
+// precedence default < config files < environment variable < command line.
+//
+// The format of each file is derived from its extension. Unlike with WithConfigFile, the files
+// are named explicitly, so a missing file makes the command execution fail, as does a file that
+// cannot be read or parsed. WatchConfig watches all of the files.
+//
+// Example:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd,
+//		cobraflags.WithConfigFiles("/etc/myapp/base.yaml", "/etc/myapp/override.yaml"))
+func WithConfigFiles(paths ...string) InitOption {
+	return func(c *initConfig) {
+		c.configFile = &configFile{files: paths}
+	}
+}
+
+// WithConfigOverlay makes CobraOnInitialize merge an overlay for the current environment on
+// top of the configuration file that was read, e.g. config.prod.yaml on top of config.yaml.
+// The environment is named by the value of the flag with the given name, e.g. --env prod,
+// which is resolved from the command line, its environment variable or its default before
+// any other flag is preset. A missing overlay file is not an error. The overlay is merged
+// key by key, and reported by ConfigFilesUsed after the base file.
+//
+// Example:
+//
+//	cobraflags.Register(rootCmd, &cobraflags.StringFlag{Name: "env", Usage: "Deployment environment", Persistent: true})
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd,
+//		cobraflags.WithConfigFile("config", "yaml", "/etc/myapp"),
+//		cobraflags.WithConfigOverlay("env"))
+func WithConfigOverlay(flagName string) InitOption {
+	return func(c *initConfig) {
+		c.overlayFlag = flagName
+	}
+}
+
+// WithStandardConfigPaths is like WithConfigFile, but searches the standard locations
+// of the application's configuration, in this order:
+//
+//  1. the working directory,
+//  2. the user's configuration directory, $XDG_CONFIG_HOME/<app>, or $HOME/.config/<app>
+//     if XDG_CONFIG_HOME is not set,
+//  3. the system configuration directory, /etc/<app>.
+//
+// The first file found is read; use ConfigFileUsed to find out which one it was.
+//
+// Example:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd,
+//		cobraflags.WithStandardConfigPaths("myapp", "config", "yaml"))
+func WithStandardConfigPaths(app, name, configType string) InitOption {
+	return WithConfigFile(name, configType, standardConfigPaths(app)...)
+}
+
+// standardConfigPaths returns the configuration search paths of app, see WithStandardConfigPaths.
+func standardConfigPaths(app string) []string {
+	paths := append([]string{"."}, userConfigPath(app)...)
+	return append(paths, filepath.Join("/etc", app))
+}
+
+// userConfigPath returns the XDG user configuration directory of app, if it can be determined.
+func userConfigPath(app string) []string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return []string{filepath.Join(dir, app)}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return []string{filepath.Join(home, ".config", app)}
+	}
+	return nil
+}
+
+// WithPlatformConfigPaths is like WithConfigFile, but searches the conventional locations
+// of the application's configuration on the current operating system, see PlatformConfigPaths.
+// The first file found is read; use ConfigFileUsed to find out which one it was.
+//
+// Example:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd,
+//		cobraflags.WithPlatformConfigPaths("myapp", "config", "yaml"))
+func WithPlatformConfigPaths(app, name, configType string) InitOption {
+	return WithConfigFile(name, configType, PlatformConfigPaths(app)...)
+}
+
+// PlatformConfigPaths returns the conventional locations of the configuration of app on
+// the current operating system, in search order. All lists start with the working directory.
+//
+//   - Windows: %APPDATA%\<app>, %LOCALAPPDATA%\<app>, %ProgramData%\<app>
+//   - macOS: ~/Library/Application Support/<app>, the XDG user directory (see
+//     WithStandardConfigPaths), /Library/Application Support/<app>, /etc/<app>
+//   - other systems: the locations searched by WithStandardConfigPaths
+//
+// Locations whose base directory cannot be determined are left out.
+func PlatformConfigPaths(app string) []string {
+	paths := []string{"."}
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"APPDATA", "LOCALAPPDATA", "ProgramData"} {
+			if dir := os.Getenv(env); dir != "" {
+				paths = append(paths, filepath.Join(dir, app))
+			}
+		}
+		return paths
+	case "darwin", "ios":
+		if home, err := os.UserHomeDir(); err == nil {
+			paths = append(paths, filepath.Join(home, "Library", "Application Support", app))
+		}
+		paths = append(paths, userConfigPath(app)...)
+		return append(paths, filepath.Join("/Library", "Application Support", app), filepath.Join("/etc", app))
+	default:
+		return standardConfigPaths(app)
+	}
+}
+
+// ConfigFileUsed returns the path of the configuration file that CobraOnInitialize
+// read into the Viper instance of cmd's command tree, see WithConfigFile and
+// ConfigFileFlag. If several files were merged, it returns the last one; see
+// ConfigFilesUsed for all of them. It returns an empty string if no file was read (yet).
+func ConfigFileUsed(cmd *cobra.Command) string {
+	files := ConfigFilesUsed(cmd)
+	if len(files) == 0 {
+		return ""
+	}
+	return files[len(files)-1]
+}
+
+// ConfigFilesUsed returns the paths of the configuration files that CobraOnInitialize
+// read into the Viper instance of cmd's command tree, in merge order, see WithConfigFiles.
+func ConfigFilesUsed(cmd *cobra.Command) []string {
+	st := storeFor(cmd.Root())
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	return slices.Clone(st.configFiles)
+}
+
+// configFileAnnotation marks the flag registered by ConfigFileFlag.
+const configFileAnnotation = "cobraflags-config-file"
+
+// ConfigFileFlag registers a persistent --config flag on cmd, typically the root command,
+// and returns it. When CobraOnInitialize is set up for the tree, the file given by the
+// flag (or by its environment variable) is read into the Viper instance before other
+// flags are preset, so its values take part in the precedence
+// default < config file < environment variable < command line.
+//
+// The format is derived from the file extension. If the user names a file that is
+// missing or cannot be parsed, the command execution fails with an error naming the file.
+// The file given as default value is read only if it exists. A file given through the
+// flag takes precedence over the one searched for with WithConfigFile, which is only
+// searched for if there is none.
+//
+// Shells complete the flag with the files whose extension Viper supports.
+//
+// The usual options apply, e.g. to change the shorthand or set a default:
+//
+//	cobraflags.ConfigFileFlag(rootCmd, cobraflags.WithShorthand[string]("c"),
+//		cobraflags.WithDefault("/etc/myapp/config.yaml"))
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd)
+func ConfigFileFlag(cmd *cobra.Command, opts ...Option[string]) *StringFlag {
+	opts = append([]Option[string]{
+		WithUsage[string]("Path to the configuration file"),
+		Persistent[string](),
+		WithFileCompletion[string](viper.SupportedExts...), // The files Viper can read.
+	}, opts...)
+	flag := NewStringFlag("config", opts...)
+	flag.Register(cmd)
+	pStringFlag(flag).setAnnotation(configFileAnnotation, "true")
+	return flag
+}
+
+// readConfig reads the configuration file into the Viper instance of cmd's command tree.
+// The file given by a ConfigFileFlag takes precedence over the one described by the
+// configFile of cfg. It returns nil if there is no file to read. The overlay for the
+// current environment (see WithConfigOverlay) and included files (see WithConfigIncludes)
+// are merged as well, if enabled.
+func readConfig(envPrefix string, cmd *cobra.Command, cfg *initConfig) error {
+	path, explicit := configFlagValue(envPrefix, cmd)
+	if !explicit && path != "" {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			path = "" // A missing default is not an error.
+		}
+	}
+	if path == "" && cfg.configFile == nil {
+		return nil
+	}
+
+	st := storeFor(cmd)
+	st.lock()
+	defer st.mu.Unlock()
+
+	if err := readBaseConfig(st, path, cfg.configFile); err != nil {
+		return err
+	}
+	if len(st.configFiles) == 0 {
+		return nil
+	}
+
+	files := st.configFiles
+	overlay, err := findOverlay(envPrefix, cmd, cfg.overlayFlag, files[len(files)-1])
+	if err != nil {
+		return err
+	}
+	if overlay != "" {
+		files = append(slices.Clone(files), overlay)
+	}
+	if cfg.configIncludes {
+		if files, err = resolveIncludes(files); err != nil {
+			return err
+		}
+	}
+	if slices.Equal(files, st.configFiles) {
+		return nil
+	}
+
+	if err := mergeConfigFiles(st.v, files); err != nil {
+		return err
+	}
+	st.configFiles = files
+	return nil
+}
+
+// findOverlay returns the path of the overlay of the config file at path for the environment
+// named by the flag overlayFlag, see WithConfigOverlay. It returns an empty string if overlays
+// are not enabled, no environment is named, or the overlay does not exist.
+func findOverlay(envPrefix string, cmd *cobra.Command, overlayFlag, path string) (string, error) {
+	if overlayFlag == "" {
+		return "", nil
+	}
+
+	env, _ := earlyFlagValue(envPrefix, cmd, func(f *pflag.Flag) bool { return f.Name == overlayFlag })
+	if env == "" {
+		return "", nil
+	}
+	if strings.ContainsAny(env, `/\`) {
+		return "", fmt.Errorf("invalid environment name %q", env)
+	}
+	overlay := overlayPath(path, env)
+	if _, err := os.Stat(overlay); errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return overlay, nil
+}
+
+// readBaseConfig reads the file at path, or else the file(s) described by cf, into the
+// Viper instance of st. The caller must hold the write lock of st.
+func readBaseConfig(st *store, path string, cf *configFile) error {
+	v := st.v
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("reading config file %q: %w", path, err)
+		}
+		st.configFiles = []string{v.ConfigFileUsed()}
+		return nil
+	}
+
+	if len(cf.files) > 0 {
+		if err := mergeConfigFiles(v, cf.files); err != nil {
+			return err
+		}
+		st.configFiles = slices.Clone(cf.files)
+		return nil
+	}
+
+	v.SetConfigName(cf.name)
+	if cf.configType != "" {
+		v.SetConfigType(cf.configType)
+	}
+	for _, path := range cf.paths {
+		v.AddConfigPath(path)
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	st.configFiles = []string{v.ConfigFileUsed()}
+	return nil
+}
+
+// overlayPath returns the path of the overlay of the config file at path for
+// the environment env, e.g. "config.prod.yaml" for "config.yaml" and "prod".
+func overlayPath(path, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// mergeConfigFiles replaces the configuration of v with the given files, merged in order.
+func mergeConfigFiles(v *viper.Viper, files []string) error {
+	for i, path := range files {
+		v.SetConfigFile(path)
+		read := v.MergeInConfig
+		if i == 0 {
+			read = v.ReadInConfig
+		}
+		if err := read(); err != nil {
+			return fmt.Errorf("reading config file %q: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// configFlagValue returns the path given by the ConfigFileFlag of cmd's command tree,
+// if any, and whether it was specified by the user rather than being the default.
+func configFlagValue(envPrefix string, cmd *cobra.Command) (string, bool) {
+	return earlyFlagValue(envPrefix, cmd, func(f *pflag.Flag) bool {
+		return len(f.Annotations[configFileAnnotation]) > 0
+	})
+}
+
+// earlyFlagValue returns the value of the flag of cmd's command tree selected by match,
+// before flags are preset, and whether it was specified by the user rather than being
+// the default. The command line takes precedence over the environment variable.
+func earlyFlagValue(envPrefix string, cmd *cobra.Command, match func(*pflag.Flag) bool) (string, bool) {
+	var flag *pflag.Flag
+	var owner *cobra.Command
+	walkCommands(cmd, func(c *cobra.Command) {
+		visitFlags(c, func(f *pflag.Flag) {
+			if match(f) && (flag == nil || f.Changed && !flag.Changed) {
+				flag, owner = f, c
+			}
+		})
+	})
+	if flag == nil {
+		return "", false
+	}
+
+	if flag.Changed {
+		return flag.Value.String(), true
+	}
+	envVarName, _ := flagEnvVar(envPrefix, configFor(cmd), owner, flag)
+	if value, ok := os.LookupEnv(envVarName); ok && value != "" {
+		return value, true
+	}
+	return flag.Value.String(), false
+}
+
+// WithStrictConfig makes the command execution fail if the configuration file contains keys
+// that do not correspond to the Viper key of any flag in the command tree. This catches typos
+// like "sever.port", which would otherwise silently have no effect. Use WithConfigKeyWarnings
+// to only log them.
+func WithStrictConfig() InitOption {
+	return func(c *initConfig) {
+		c.strictConfig = true
+	}
+}
+
+// WithConfigKeyWarnings makes CobraOnInitialize log a warning for every key of the configuration
+// file that does not correspond to the Viper key of any flag in the command tree, see WithStrictConfig.
+func WithConfigKeyWarnings() InitOption {
+	return func(c *initConfig) {
+		c.warnConfigKeys = true
+	}
+}
+
+// checkConfigKeys reports the keys of the configuration file read for cmd's command tree that
+// no flag is bound to: as an error with WithStrictConfig, or as warnings with WithConfigKeyWarnings.
+func checkConfigKeys(cmd *cobra.Command, cfg *initConfig) error {
+	if !cfg.strictConfig && !cfg.warnConfigKeys {
+		return nil
+	}
+
+	st := storeFor(cmd)
+	known := map[string]bool{includeKey: cfg.configIncludes}
+	walkCommands(cmd, func(c *cobra.Command) {
+		visitFlags(c, func(f *pflag.Flag) {
+			known[st.foldKey(viperKeyOf(f))] = true
+		})
+	})
+
+	st.mu.RLock()
+	files := st.configFiles
+	var unknown []string
+	if len(files) > 0 {
+		b := st.values()
+		for _, key := range b.AllKeys() {
+			if !known[key] && b.InConfig(key) {
+				unknown = append(unknown, key)
+			}
+		}
+	}
+	st.mu.RUnlock()
+
+	if len(unknown) == 0 {
+		return nil
+	}
+	slices.Sort(unknown)
+	if cfg.strictConfig {
+		return fmt.Errorf("unknown keys in config file %s: %s", quoteAll(files), strings.Join(unknown, ", "))
+	}
+	for _, key := range unknown {
+		slog.Warn("unknown key in config file", "key", key, "file", strings.Join(files, ","))
+	}
+	return nil
+}
+
+// WithSecretsDir makes CobraOnInitialize read the files in dir, as mounted by Docker and
+// Kubernetes secrets (e.g. /run/secrets), and use their contents as values of the flags
+// whose Viper keys match the file names, case-insensitively. Contents are trimmed of
+// surrounding whitespace.
+//
+// Secrets rank above configuration files and below environment variables, so they are
+// resolved with the precedence default < config file < secret < environment variable <
+// command line. Their source is reported as SourceFile. A missing directory is ignored;
+// hidden files, such as the ..data links of Kubernetes, and subdirectories are skipped.
+//
+// Example:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd, cobraflags.WithSecretsDir("/run/secrets"))
+func WithSecretsDir(dir string) InitOption {
+	return func(c *initConfig) {
+		c.secretsDir = dir
+	}
+}
+
+// readSecretsDir reads the files in dir into the store of cmd's command tree, see WithSecretsDir.
+// It returns nil if dir is empty or does not exist.
+func readSecretsDir(cmd *cobra.Command, dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading secrets directory: %w", err)
+	}
+
+	secrets := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue // Secrets are often symlinks, so the entry's own type is not conclusive.
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading secret: %w", err)
+		}
+		secrets[strings.ToLower(entry.Name())] = strings.TrimSpace(string(content))
+	}
+
+	st := storeFor(cmd)
+	st.lock()
+	defer st.mu.Unlock()
+
+	st.secrets = secrets
+	return nil
+}
+
+// quoteAll returns the quoted strings, separated by commas.
+func quoteAll(s []string) string {
+	quoted := make([]string, len(s))
+	for i, v := range s {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,400 @@
+package cobraflags_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func writeConfig(c *qt.C, dir, name, content string) {
+	c.Assert(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600), qt.IsNil)
+}
+
+func TestWithConfigFile(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("CFGAPP_HOST", "env.example.com")
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "host: config.example.com\nserver:\n  port: 9000\nname: from-config\ntags: [a, b]\n")
+
+	cmd := newCobraCommand()
+	hostFlag := &cobraflags.StringFlag{Name: "host", Value: "localhost"}
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80}
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags"}
+	debugFlag := &cobraflags.BoolFlag{Name: "debug"}
+	cobraflags.Register(cmd, hostFlag, portFlag, nameFlag, tagsFlag, debugFlag)
+	cobraflags.CobraOnInitialize("CFGAPP", cmd, cobraflags.WithConfigFile("config", "yaml", filepath.Join(c.TempDir(), "missing"), dir))
+
+	cmd.SetArgs([]string{"--name", "from-cli"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	c.Assert(hostFlag.GetString(), qt.Equals, "env.example.com")
+	c.Assert(portFlag.GetInt(), qt.Equals, 9000)
+	c.Assert(nameFlag.GetString(), qt.Equals, "from-cli")
+	c.Assert(tagsFlag.GetStringSlice(), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(debugFlag.GetBool(), qt.IsFalse)
+
+	sources := make(map[string]cobraflags.Source)
+	for _, info := range cobraflags.FlagsOf(cmd) {
+		sources[info.Name] = info.Source
+	}
+	c.Assert(sources, qt.DeepEquals, map[string]cobraflags.Source{
+		"host":  cobraflags.SourceEnv,
+		"port":  cobraflags.SourceConfig,
+		"name":  cobraflags.SourceFlag,
+		"tags":  cobraflags.SourceConfig,
+		"debug": cobraflags.SourceDefault,
+	})
+}
+
+func TestWithConfigFile_Missing(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("CFGAPP", cmd, cobraflags.WithConfigFile("config", "yaml", c.TempDir()))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 80)
+}
+
+func TestWithConfigFile_Malformed(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "port: [unterminated\n")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("CFGAPP", cmd, cobraflags.WithConfigFile("config", "yaml", dir))
+
+	c.Assert(cmd.Execute(), qt.ErrorMatches, "reading config file: .*")
+	c.Assert(portFlag.GetInt(), qt.Equals, 80)
+}
+
+func TestConfigFileFlag(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "custom.yaml", "port: 9000\n")
+	writeConfig(c, dir, "config.yaml", "port: 7000\n")
+
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "serve", RunE: func(*cobra.Command, []string) error { return nil }}
+	root.AddCommand(sub)
+	configFlag := cobraflags.ConfigFileFlag(root, cobraflags.WithShorthand[string]("c"))
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(sub)
+	cobraflags.CobraOnInitialize("CFGFLAG", root, cobraflags.WithConfigFile("config", "yaml", dir))
+
+	root.SetArgs([]string{"serve", "-c", filepath.Join(dir, "custom.yaml")})
+	c.Assert(root.Execute(), qt.IsNil)
+
+	c.Assert(configFlag.GetString(), qt.Equals, filepath.Join(dir, "custom.yaml"))
+	c.Assert(portFlag.GetInt(), qt.Equals, 9000)
+}
+
+func TestConfigFileFlag_Env(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "app.json", `{"port": 9000}`)
+	c.Setenv("CFGFLAG_CONFIG", filepath.Join(dir, "app.json"))
+
+	cmd := newCobraCommand()
+	cobraflags.ConfigFileFlag(cmd)
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("CFGFLAG", cmd)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9000)
+}
+
+func TestConfigFileFlag_Default(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	cobraflags.ConfigFileFlag(cmd, cobraflags.WithDefault(filepath.Join(c.TempDir(), "missing.yaml")))
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("CFGFLAG", cmd)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 80)
+}
+
+func TestConfigFileFlag_Errors(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "broken.yaml", "port: [unterminated\n")
+
+	tests := []struct {
+		name string
+		path string
+		err  string
+	}{
+		{"missing", filepath.Join(dir, "missing.yaml"), `reading config file ".*missing.yaml": .*no such file or directory`},
+		{"malformed", filepath.Join(dir, "broken.yaml"), `reading config file ".*broken.yaml": .*`},
+	}
+
+	for _, tt := range tests {
+		c.Run(tt.name, func(c *qt.C) {
+			cmd := newCobraCommand()
+			cobraflags.ConfigFileFlag(cmd)
+			portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+			portFlag.Register(cmd)
+			cobraflags.CobraOnInitialize("CFGFLAG", cmd)
+
+			cmd.SetArgs([]string{"--config", tt.path})
+			c.Assert(cmd.Execute(), qt.ErrorMatches, tt.err)
+			c.Assert(portFlag.GetInt(), qt.Equals, 80)
+		})
+	}
+}
+
+func TestWithStandardConfigPaths(t *testing.T) {
+	c := qt.New(t)
+
+	xdg := c.TempDir()
+	c.Setenv("XDG_CONFIG_HOME", xdg)
+	c.Assert(os.Mkdir(filepath.Join(xdg, "stdapp"), 0o700), qt.IsNil)
+	writeConfig(c, filepath.Join(xdg, "stdapp"), "cobraflags-std.yaml", "port: 9000\n")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.ConfigFileFlag(cmd, cobraflags.WithDefault(filepath.Join(xdg, "missing.yaml")))
+	cobraflags.CobraOnInitialize("STDAPP", cmd, cobraflags.WithStandardConfigPaths("stdapp", "cobraflags-std", "yaml"))
+
+	c.Assert(cobraflags.ConfigFileUsed(cmd), qt.Equals, "")
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9000)
+	c.Assert(cobraflags.ConfigFileUsed(cmd), qt.Equals, filepath.Join(xdg, "stdapp", "cobraflags-std.yaml"))
+}
+
+func TestPlatformConfigPaths(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("XDG_CONFIG_HOME", "/xdg")
+	c.Setenv("APPDATA", `C:\Users\gopher\AppData\Roaming`)
+	c.Setenv("LOCALAPPDATA", `C:\Users\gopher\AppData\Local`)
+	c.Setenv("ProgramData", `C:\ProgramData`)
+	home, err := os.UserHomeDir()
+	c.Assert(err, qt.IsNil)
+
+	var want []string
+	switch runtime.GOOS {
+	case "windows":
+		want = []string{".", `C:\Users\gopher\AppData\Roaming\platapp`, `C:\Users\gopher\AppData\Local\platapp`, `C:\ProgramData\platapp`}
+	case "darwin", "ios":
+		want = []string{".", filepath.Join(home, "Library", "Application Support", "platapp"),
+			filepath.Join("/xdg", "platapp"), "/Library/Application Support/platapp", "/etc/platapp"}
+	default:
+		want = []string{".", filepath.Join("/xdg", "platapp"), "/etc/platapp"}
+	}
+	c.Assert(cobraflags.PlatformConfigPaths("platapp"), qt.DeepEquals, want)
+}
+
+func TestWithPlatformConfigPaths(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	for _, env := range []string{"XDG_CONFIG_HOME", "APPDATA"} {
+		c.Setenv(env, dir)
+	}
+	c.Setenv("HOME", dir) // Library/Application Support on macOS
+	appDir := cobraflags.PlatformConfigPaths("platapp")[1]
+	c.Assert(os.MkdirAll(appDir, 0o700), qt.IsNil)
+	writeConfig(c, appDir, "cobraflags-platform.yaml", "port: 9000\n")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("PLATAPP", cmd, cobraflags.WithPlatformConfigPaths("platapp", "cobraflags-platform", "yaml"))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9000)
+	c.Assert(cobraflags.ConfigFileUsed(cmd), qt.Equals, filepath.Join(appDir, "cobraflags-platform.yaml"))
+}
+
+func TestConfigFileUsed_NotFound(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("XDG_CONFIG_HOME", c.TempDir())
+
+	cmd := newCobraCommand()
+	cobraflags.ConfigFileFlag(cmd, cobraflags.WithDefault(filepath.Join(c.TempDir(), "missing.yaml")))
+	cobraflags.CobraOnInitialize("STDAPP", cmd, cobraflags.WithStandardConfigPaths("stdapp", "cobraflags-std", "yaml"))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(cobraflags.ConfigFileUsed(cmd), qt.Equals, "")
+	c.Assert(cobraflags.ViperFor(cmd).ConfigFileUsed(), qt.Equals, "")
+}
+
+func TestWithSecretsDir(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("SECRETAPP_API_TOKEN", "from-env")
+
+	secrets := c.TempDir()
+	writeConfig(c, secrets, "db-password", "s3cret\n")
+	writeConfig(c, secrets, "api-token", "from-secret")
+	writeConfig(c, secrets, "Server.Port", "8443")
+	writeConfig(c, secrets, ".hidden", "ignored")
+	c.Assert(os.Mkdir(filepath.Join(secrets, "..data"), 0o700), qt.IsNil)
+	c.Assert(os.Symlink(filepath.Join(secrets, "db-password"), filepath.Join(secrets, "linked")), qt.IsNil)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "db-password: from-config\nhost: config.example.com\n")
+
+	cmd := newCobraCommand()
+	passwordFlag := &cobraflags.StringFlag{Name: "db-password"}
+	tokenFlag := &cobraflags.StringFlag{Name: "api-token"}
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80}
+	hostFlag := &cobraflags.StringFlag{Name: "host"}
+	linkedFlag := &cobraflags.StringFlag{Name: "linked"}
+	cobraflags.Register(cmd, passwordFlag, tokenFlag, portFlag, hostFlag, linkedFlag)
+	cobraflags.CobraOnInitialize("SECRETAPP", cmd,
+		cobraflags.WithConfigFile("config", "yaml", dir),
+		cobraflags.WithSecretsDir(secrets))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(passwordFlag.GetString(), qt.Equals, "s3cret")
+	c.Assert(tokenFlag.GetString(), qt.Equals, "from-env")
+	c.Assert(portFlag.GetInt(), qt.Equals, 8443)
+	c.Assert(hostFlag.GetString(), qt.Equals, "config.example.com")
+	c.Assert(linkedFlag.GetString(), qt.Equals, "s3cret")
+
+	sources := make(map[string]cobraflags.Source)
+	for _, info := range cobraflags.FlagsOf(cmd) {
+		sources[info.Name] = info.Source
+	}
+	c.Assert(sources, qt.DeepEquals, map[string]cobraflags.Source{
+		"db-password": cobraflags.SourceFile,
+		"api-token":   cobraflags.SourceEnv,
+		"port":        cobraflags.SourceFile,
+		"host":        cobraflags.SourceConfig,
+		"linked":      cobraflags.SourceFile,
+	})
+}
+
+func TestWithSecretsDir_Missing(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("SECRETAPP", cmd, cobraflags.WithSecretsDir(filepath.Join(c.TempDir(), "missing")))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 80)
+}
+
+func TestWithStrictConfig(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "server:\n  port: 8080\nsever:\n  port: 9090\nverbose: true\n")
+
+	newCommand := func(opts ...cobraflags.InitOption) (*cobra.Command, *cobraflags.IntFlag) {
+		root := &cobra.Command{Use: "strictapp", SilenceUsage: true, SilenceErrors: true}
+		serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+		root.AddCommand(serve)
+		portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "Server.Port", Value: 80}
+		portFlag.Register(serve)
+		root.PersistentFlags().Bool("verbose", false, "Verbose output")
+		cobraflags.CobraOnInitialize("STRICTAPP", root,
+			append(opts, cobraflags.WithConfigFile("config", "yaml", dir))...)
+		root.SetArgs([]string{"serve"})
+		return root, portFlag
+	}
+
+	cmd, _ := newCommand(cobraflags.WithStrictConfig())
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `unknown keys in config file ".*config.yaml": sever.port`)
+
+	logs := captureLogs(c)
+	cmd, portFlag := newCommand(cobraflags.WithConfigKeyWarnings())
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(logs.String(), qt.Matches, `(?s).*msg="unknown key in config file" key=sever.port file=.*config.yaml\n`)
+}
+
+func TestWithConfigFiles(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "base.yaml", "server:\n  host: base.example.com\n  port: 8080\nlevel: info\n")
+	writeConfig(c, dir, "override.json", `{"server": {"port": 9090}}`)
+	c.Setenv("MERGEAPP_LEVEL", "debug")
+
+	cmd := newCobraCommand()
+	hostFlag := &cobraflags.StringFlag{Name: "host", ViperKey: "server.host"}
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80}
+	levelFlag := &cobraflags.StringFlag{Name: "level", Value: "warn"}
+	cobraflags.Register(cmd, hostFlag, portFlag, levelFlag)
+	files := []string{filepath.Join(dir, "base.yaml"), filepath.Join(dir, "override.json")}
+	cobraflags.CobraOnInitialize("MERGEAPP", cmd, cobraflags.WithConfigFiles(files...))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(hostFlag.GetString(), qt.Equals, "base.example.com")
+	c.Assert(portFlag.GetInt(), qt.Equals, 9090)
+	c.Assert(levelFlag.GetString(), qt.Equals, "debug")
+	c.Assert(cobraflags.ConfigFilesUsed(cmd), qt.DeepEquals, files)
+	c.Assert(cobraflags.ConfigFileUsed(cmd), qt.Equals, files[1])
+}
+
+func TestWithConfigFiles_Missing(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "base.yaml", "port: 8080\n")
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	cobraflags.CobraOnInitialize("MERGEAPP", cmd,
+		cobraflags.WithConfigFiles(filepath.Join(dir, "base.yaml"), filepath.Join(dir, "missing.yaml")))
+
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `reading config file ".*missing.yaml": open .*: no such file or directory`)
+}
+
+func TestWithConfigOverlay(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "host: base.example.com\nport: 8080\n")
+	writeConfig(c, dir, "config.prod.yaml", "host: prod.example.com\n")
+
+	execute := func(args ...string) (*cobra.Command, *cobraflags.StringFlag, *cobraflags.IntFlag, error) {
+		cmd := newCobraCommand()
+		hostFlag := &cobraflags.StringFlag{Name: "host", Value: "localhost"}
+		portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+		cobraflags.Register(cmd, &cobraflags.StringFlag{Name: "env"}, hostFlag, portFlag)
+		cobraflags.CobraOnInitialize("OVERLAYAPP", cmd,
+			cobraflags.WithConfigFile("config", "yaml", dir), cobraflags.WithConfigOverlay("env"))
+		cmd.SetArgs(args)
+		return cmd, hostFlag, portFlag, cmd.Execute()
+	}
+
+	cmd, hostFlag, portFlag, err := execute("--env", "prod")
+	c.Assert(err, qt.IsNil)
+	c.Assert(hostFlag.GetString(), qt.Equals, "prod.example.com")
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(cobraflags.ConfigFilesUsed(cmd), qt.DeepEquals,
+		[]string{filepath.Join(dir, "config.yaml"), filepath.Join(dir, "config.prod.yaml")})
+
+	c.Setenv("OVERLAYAPP_ENV", "staging")
+	_, hostFlag, _, err = execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(hostFlag.GetString(), qt.Equals, "base.example.com")
+
+	_, _, _, err = execute("--env", "../prod")
+	c.Assert(err, qt.ErrorMatches, `invalid environment name "../prod"`)
+}
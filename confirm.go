@@ -0,0 +1,99 @@
+package cobraflags
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrConfirmationRequired is returned by ConfirmFlag.ConfirmE when the action is not
+// confirmed with the flag and the input is not interactive, e.g. in scripts and CI jobs.
+var ErrConfirmationRequired = errors.New("confirmation required")
+
+// confirmAnnotation marks the flags registered by ConfirmFlag, which are left out of the
+// effective configuration, so that a saved configuration does not confirm every action.
+const confirmAnnotation = "cobraflags-confirm"
+
+// ConfirmFlag is a boolean flag, --yes/-y by default, that confirms destructive actions
+// upfront. Commands call ConfirmE before acting, which asks the user interactively unless
+// the flag is set, so that all commands confirm the same way.
+//
+// Like any other flag, it can be set through the environment (e.g. MYAPP_YES=true) or a
+// configuration file, which unattended runs may use to approve every action. It is left out
+// of print-config and WriteConfig, though, so that saving a configuration does not do so.
+//
+// Example:
+//
+//	yesFlag := &cobraflags.ConfirmFlag{}
+//	yesFlag.Register(deleteCmd)
+//
+//	deleteCmd.RunE = func(cmd *cobra.Command, args []string) error {
+//		ok, err := yesFlag.ConfirmE(fmt.Sprintf("Delete %d files?", len(args)))
+//		if err != nil || !ok {
+//			return err
+//		}
+//		...
+//	}
+type ConfirmFlag struct {
+	BoolFlag
+}
+
+// Register registers the flag with the given cobra command, as --yes/-y unless Name is set.
+// It panics if the flag cannot be registered; use RegisterE to handle the error instead.
+func (s *ConfirmFlag) Register(cmd *cobra.Command) {
+	noError(s.RegisterE(cmd))
+}
+
+// RegisterE registers the flag with the given cobra command, as --yes/-y unless Name is set.
+// It returns an error if the flag cannot be registered, see BoolFlag.RegisterE.
+func (s *ConfirmFlag) RegisterE(cmd *cobra.Command) error {
+	if s.Name == "" {
+		s.Name = "yes"
+		if s.Shorthand == "" {
+			s.Shorthand = "y"
+		}
+	}
+	if s.Usage == "" {
+		s.Usage = "Confirm without prompting"
+	}
+	if err := s.BoolFlag.RegisterE(cmd); err != nil {
+		return err
+	}
+	pBoolFlag(&s.BoolFlag).setAnnotation(confirmAnnotation, "true")
+	return nil
+}
+
+// ConfirmE reports whether the action described by prompt is confirmed: right away if the
+// flag is set, or else by asking the user, who confirms with "y" or "yes" in any case.
+// The prompt is written to the error output of the command the flag is registered on,
+// followed by " [y/N]: ", and the answer is read from its input.
+//
+// If the flag is not set and the input is not interactive (see EnablePrompting), ConfirmE
+// returns false and an error wrapping ErrConfirmationRequired, which names the flag.
+func (s *ConfirmFlag) ConfirmE(prompt string) (bool, error) {
+	confirmed, err := s.GetBoolE()
+	if err != nil || confirmed {
+		return confirmed, err
+	}
+
+	cmd := pBoolFlag(&s.BoolFlag).command()
+	in, ok := interactiveInput(cmd)
+	if !ok {
+		return false, fmt.Errorf("%w: %s, use --%s", ErrConfirmationRequired, prompt, s.Name)
+	}
+
+	_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("reading confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,101 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// newConfirmCommand returns a command that runs confirm with its confirmation flag.
+func newConfirmCommand(confirm func(*cobraflags.ConfirmFlag) error) *cobra.Command {
+	cmd := newCobraCommand()
+	yesFlag := &cobraflags.ConfirmFlag{}
+	yesFlag.Register(cmd)
+	cmd.RunE = func(*cobra.Command, []string) error {
+		return confirm(yesFlag)
+	}
+	cobraflags.CobraOnInitialize("CONFIRMAPP", cmd)
+	return cmd
+}
+
+func TestConfirmFlag(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		env       string
+		input     string
+		confirmed bool
+		prompt    string
+	}{
+		{name: "flag", args: []string{"--yes"}, confirmed: true},
+		{name: "shorthand", args: []string{"-y"}, confirmed: true},
+		{name: "env", env: "true", confirmed: true},
+		{name: "yes", input: "yes\n", confirmed: true, prompt: "Delete? [y/N]: "},
+		{name: "y", input: "Y\n", confirmed: true, prompt: "Delete? [y/N]: "},
+		{name: "no", input: "n\n", prompt: "Delete? [y/N]: "},
+		{name: "empty", input: "\n", prompt: "Delete? [y/N]: "},
+		{name: "eof", prompt: "Delete? [y/N]: "},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := qt.New(t)
+			if test.env != "" {
+				c.Setenv("CONFIRMAPP_YES", test.env)
+			}
+
+			var confirmed bool
+			cmd := newConfirmCommand(func(yesFlag *cobraflags.ConfirmFlag) error {
+				var err error
+				confirmed, err = yesFlag.ConfirmE("Delete?")
+				return err
+			})
+			var prompts bytes.Buffer
+			cmd.SetErr(&prompts)
+			cmd.SetIn(strings.NewReader(test.input))
+			cmd.SetArgs(test.args)
+			c.Assert(cmd.Execute(), qt.IsNil)
+			c.Assert(confirmed, qt.Equals, test.confirmed)
+			c.Assert(prompts.String(), qt.Equals, test.prompt)
+		})
+	}
+}
+
+func TestConfirmFlag_NotInteractive(t *testing.T) {
+	c := qt.New(t)
+
+	path := filepath.Join(c.TempDir(), "input")
+	c.Assert(os.WriteFile(path, []byte("yes\n"), 0o600), qt.IsNil)
+	in, err := os.Open(path)
+	c.Assert(err, qt.IsNil)
+	defer in.Close()
+
+	cmd := newConfirmCommand(func(yesFlag *cobraflags.ConfirmFlag) error {
+		confirmed, err := yesFlag.ConfirmE("Delete?")
+		c.Check(confirmed, qt.IsFalse)
+		return err
+	})
+	cmd.SetIn(in)
+	err = cmd.Execute()
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrConfirmationRequired)
+	c.Assert(err, qt.ErrorMatches, `confirmation required: Delete\?, use --yes`)
+}
+
+func TestConfirmFlag_Name(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	forceFlag := &cobraflags.ConfirmFlag{BoolFlag: cobraflags.BoolFlag{Name: "force", Usage: "Skip the confirmation"}}
+	forceFlag.Register(cmd)
+
+	f := cmd.Flags().Lookup("force")
+	c.Assert(f, qt.IsNotNil)
+	c.Assert(f.Shorthand, qt.Equals, "")
+	c.Assert(f.Usage, qt.Equals, "Skip the confirmation")
+}
@@ -0,0 +1,59 @@
+package cobraflags
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// contextKey is the key under which flag values are stored in a context.
+type contextKey struct{}
+
+// WithValues returns a copy of ctx carrying the current values of the flags registered
+// through cobraflags that are available to cmd, like cmd.Flags() and cmd.InheritedFlags():
+// the flags of cmd itself and the persistent flags of its ancestors. When a flag name is
+// registered on several of them, the value of the command closest to cmd wins.
+//
+// The values are captured when WithValues is called, so it is typically called from
+// the command's Run function, after all sources have been applied. Deeper application
+// layers can then read the values with FromContext without importing the flag variables.
+//
+// Example:
+//
+//	RunE: func(cmd *cobra.Command, args []string) error {
+//		return serve(cobraflags.WithValues(cmd.Context(), cmd))
+//	}
+func WithValues(ctx context.Context, cmd *cobra.Command) context.Context {
+	values := make(map[string]any)
+	for c := cmd; c != nil; c = c.Parent() {
+		for _, entry := range registeredOn(c) {
+			if c != cmd && !entry.persistent {
+				continue // Local to an ancestor.
+			}
+			if _, ok := values[entry.name]; !ok {
+				values[entry.name] = entry.base.info().Value
+			}
+		}
+	}
+	return context.WithValue(ctx, contextKey{}, values)
+}
+
+// FromContext returns the value of the named flag stored in ctx by WithValues.
+// The second result is false if ctx carries no such flag or its value is not of type T.
+//
+// Example:
+//
+//	port, ok := cobraflags.FromContext[int](ctx, "port")
+func FromContext[T any](ctx context.Context, name string) (T, bool) {
+	var zero T
+
+	values, ok := ctx.Value(contextKey{}).(map[string]any)
+	if !ok {
+		return zero, false
+	}
+	v, ok := values[name].(T)
+	if !ok {
+		return zero, false
+	}
+	return v, true
+}
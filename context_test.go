@@ -0,0 +1,73 @@
+package cobraflags_test
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestWithValues(t *testing.T) {
+	c := qt.New(t)
+
+	var ctx context.Context
+	root := &cobra.Command{Use: "ctxapp"}
+	sub := &cobra.Command{
+		Use: "run",
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx = cobraflags.WithValues(context.Background(), cmd)
+		},
+	}
+	root.AddCommand(sub)
+
+	(&cobraflags.StringFlag{Name: "name", Value: "root", Persistent: true}).Register(root)
+	(&cobraflags.BoolFlag{Name: "verbose", Persistent: true}).Register(root)
+	(&cobraflags.StringFlag{Name: "local", Value: "root"}).Register(root)
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(sub)
+	(&cobraflags.StringSliceFlag{Name: "tags", Value: []string{"a"}}).Register(sub)
+
+	root.SetArgs([]string{"run", "--port", "8080", "--verbose"})
+	c.Assert(root.Execute(), qt.IsNil)
+
+	port, ok := cobraflags.FromContext[int](ctx, "port")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(port, qt.Equals, 8080)
+
+	verbose, ok := cobraflags.FromContext[bool](ctx, "verbose")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(verbose, qt.IsTrue)
+
+	tags, ok := cobraflags.FromContext[[]string](ctx, "tags")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(tags, qt.DeepEquals, []string{"a"})
+
+	name, ok := cobraflags.FromContext[string](ctx, "name")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(name, qt.Equals, "root")
+
+	// Local flags of ancestors are not available to the command.
+	_, ok = cobraflags.FromContext[string](ctx, "local")
+	c.Assert(ok, qt.IsFalse)
+}
+
+func TestFromContext_Missing(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	ctx := cobraflags.WithValues(context.Background(), cmd)
+
+	_, ok := cobraflags.FromContext[int](ctx, "missing")
+	c.Assert(ok, qt.IsFalse)
+
+	_, ok = cobraflags.FromContext[string](ctx, "port")
+	c.Assert(ok, qt.IsFalse)
+
+	_, ok = cobraflags.FromContext[int](context.Background(), "port")
+	c.Assert(ok, qt.IsFalse)
+}
@@ -0,0 +1,162 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ErrDefaultCycle is returned when the defaults of flags computed with FlagBase.DefaultFunc
+// depend on each other in a cycle. The error names the flags of the cycle.
+var ErrDefaultCycle = errors.New("default value cycle")
+
+// DefaultFunc computes the default value of a flag from the effective values of other
+// flags, see FlagBase.DefaultFunc.
+type DefaultFunc[T any] func(d *Defaults) (T, error)
+
+// Defaults gives FlagBase.DefaultFunc access to the effective values of the other flags
+// available to the executed command.
+type Defaults struct {
+	flags     map[string]registeredFlag
+	order     []string
+	resolving map[string]bool
+	done      map[string]bool
+	path      []string // the flags being resolved, for the error of a cycle
+}
+
+// Value returns the effective value of the named flag, e.g. a string for a StringFlag.
+// If the default of that flag is computed with a DefaultFunc as well and the flag is not
+// set, its default is computed first. It returns an error wrapping ErrNotRegistered if no
+// such flag is available to the command, and one wrapping ErrDefaultCycle if the flag
+// depends on the default being computed.
+func (d *Defaults) Value(name string) (any, error) {
+	f, ok := d.flags[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotRegistered, name)
+	}
+	if err := d.resolve(name); err != nil {
+		return nil, err
+	}
+	return f.current()
+}
+
+// DefaultOf returns the effective value of the named flag like Defaults.Value, as a T.
+// It returns an error if the flag has a different value type.
+func DefaultOf[T any](d *Defaults, name string) (T, error) {
+	var zero T
+	v, err := d.Value(name)
+	if err != nil {
+		return zero, err
+	}
+	t, ok := v.(T)
+	if !ok {
+		return zero, fmt.Errorf("flag %q has a value of type %T, not %T", name, v, zero)
+	}
+	return t, nil
+}
+
+// resolve computes the default of the named flag, unless it has been computed already.
+func (d *Defaults) resolve(name string) error {
+	if d.done[name] {
+		return nil
+	}
+	if d.resolving[name] {
+		return fmt.Errorf("%w: %s -> %s", ErrDefaultCycle, strings.Join(d.path, " -> "), name)
+	}
+	d.resolving[name] = true
+	d.path = append(d.path, name)
+	err := d.flags[name].resolveDefault(d)
+	d.path = d.path[:len(d.path)-1]
+	delete(d.resolving, name)
+	d.done[name] = true
+	return err
+}
+
+// resolveDefaults computes the defaults of the flags available to cmd that have a
+// DefaultFunc and are not set by any source. It is called from the Args function of cmd,
+// see installRequiredCheck.
+func resolveDefaults(cmd *cobra.Command) error {
+	d := &Defaults{
+		flags:     make(map[string]registeredFlag),
+		resolving: make(map[string]bool),
+		done:      make(map[string]bool),
+	}
+	add := func(entry registryEntry) {
+		if _, ok := d.flags[entry.name]; ok {
+			return // Shadowed by a flag of a nearer command.
+		}
+		d.flags[entry.name] = entry.base
+		d.order = append(d.order, entry.name)
+	}
+	for _, entry := range registeredOn(cmd) {
+		add(entry)
+	}
+	for c := cmd.Parent(); c != nil; c = c.Parent() {
+		for _, entry := range registeredOn(c) {
+			if entry.persistent {
+				add(entry)
+			}
+		}
+	}
+
+	var errs []error
+	for _, name := range d.order {
+		if err := d.resolve(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveDefault computes the default of the flag with its DefaultFunc, if it has one and
+// is not set by any source, and makes it the value of the flag. The flag is not marked as
+// changed, so that any source takes precedence, and its source becomes SourceDerived.
+func (s *FlagBase[T]) resolveDefault(d *Defaults) error {
+	if s.DefaultFunc == nil {
+		return nil
+	}
+	if source := s.source(); source != SourceDefault && source != SourceDerived {
+		return nil
+	}
+	v, err := s.DefaultFunc(d)
+	if err != nil {
+		return fmt.Errorf("default of flag %q: %w", s.Name, err)
+	}
+	if _, err := s.validate(v); err != nil {
+		return fmt.Errorf("invalid default %v for flag %q: %w", v, s.Name, err)
+	}
+
+	st, _, err := s.bind()
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	flag := s.flag
+	s.mu.RUnlock()
+
+	st.lock()
+	defer st.mu.Unlock()
+	if sv, ok := flag.Value.(pflag.SliceValue); ok {
+		if items, ok := any(v).([]string); ok {
+			err = sv.Replace(items)
+		}
+	} else {
+		err = flag.Value.Set(fmt.Sprint(v))
+	}
+	if err != nil {
+		return fmt.Errorf("default of flag %q: %w", s.Name, err)
+	}
+	setAnnotation(flag, sourceAnnotation, string(SourceDerived))
+	return nil
+}
+
+// current returns the current effective value of the flag.
+func (s *FlagBase[T]) current() (any, error) {
+	s.mu.RLock()
+	read := s.read
+	s.mu.RUnlock()
+	return s.value(read)
+}
@@ -0,0 +1,122 @@
+package cobraflags_test
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestDefaultFunc(t *testing.T) {
+	c := qt.New(t)
+
+	newFlags := func() (*cobraflags.StringFlag, *cobraflags.StringFlag) {
+		listenFlag := &cobraflags.StringFlag{Name: "listen-addr", Value: "localhost:8080", Persistent: true}
+		metricsFlag := &cobraflags.StringFlag{Name: "metrics-addr", DefaultFunc: func(d *cobraflags.Defaults) (string, error) {
+			listen, err := cobraflags.DefaultOf[string](d, "listen-addr")
+			if err != nil {
+				return "", err
+			}
+			host, port, err := net.SplitHostPort(listen)
+			if err != nil {
+				return "", err
+			}
+			n, err := strconv.Atoi(port)
+			if err != nil {
+				return "", err
+			}
+			return net.JoinHostPort(host, strconv.Itoa(n+1)), nil
+		}}
+		return listenFlag, metricsFlag
+	}
+	execute := func(args ...string) (*cobraflags.StringFlag, error) {
+		root := newCobraCommand()
+		serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+		root.AddCommand(serve)
+		listenFlag, metricsFlag := newFlags()
+		listenFlag.Register(root)
+		metricsFlag.Register(serve)
+		cobraflags.CobraOnInitialize("DEFAPP", root)
+		root.SetArgs(append([]string{"serve"}, args...))
+		return metricsFlag, root.Execute()
+	}
+
+	metricsFlag, err := execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(metricsFlag.GetString(), qt.Equals, "localhost:8081")
+
+	metricsFlag, err = execute("--listen-addr", "0.0.0.0:9000")
+	c.Assert(err, qt.IsNil)
+	c.Assert(metricsFlag.GetString(), qt.Equals, "0.0.0.0:9001")
+
+	_, err = execute("--listen-addr", "invalid")
+	c.Assert(err, qt.ErrorMatches, `default of flag "metrics-addr": address invalid: missing port in address`)
+
+	c.Setenv("DEFAPP_LISTEN_ADDR", "example.com:80")
+	metricsFlag, err = execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(metricsFlag.GetString(), qt.Equals, "example.com:81")
+
+	// Values given by any source take precedence.
+	c.Setenv("DEFAPP_METRICS_ADDR", "example.com:9100")
+	metricsFlag, err = execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(metricsFlag.GetString(), qt.Equals, "example.com:9100")
+}
+
+func TestDefaultFunc_Cycle(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	other := func(name string) cobraflags.DefaultFunc[int] {
+		return func(d *cobraflags.Defaults) (int, error) {
+			v, err := cobraflags.DefaultOf[int](d, name)
+			return v + 1, err
+		}
+	}
+	aFlag := &cobraflags.IntFlag{Name: "a", DefaultFunc: other("b")}
+	bFlag := &cobraflags.IntFlag{Name: "b", DefaultFunc: other("c")}
+	cFlag := &cobraflags.IntFlag{Name: "c", DefaultFunc: other("a")}
+	cobraflags.Register(cmd, aFlag, bFlag, cFlag)
+	cobraflags.CobraOnInitialize("CYCLEAPP", cmd)
+
+	err := cmd.Execute()
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrDefaultCycle)
+	c.Assert(err, qt.ErrorMatches, `default of flag "a": default of flag "b": default of flag "c": default value cycle: a -> b -> c -> a`)
+
+	// Setting one of them breaks the cycle.
+	cmd.SetArgs([]string{"--c", "10"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(aFlag.GetInt(), qt.Equals, 12)
+	c.Assert(bFlag.GetInt(), qt.Equals, 11)
+
+	_, err = cobraflags.DefaultOf[string](&cobraflags.Defaults{}, "a")
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrNotRegistered)
+}
+
+func TestDefaultFunc_PersistentPreRun(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 8080, Persistent: true}
+	adminFlag := &cobraflags.IntFlag{Name: "admin-port", Persistent: true, DefaultFunc: func(d *cobraflags.Defaults) (int, error) {
+		port, err := cobraflags.DefaultOf[int](d, "port")
+		return port + 1, err
+	}}
+	cobraflags.Register(root, portFlag, adminFlag)
+	var seen int
+	root.PersistentPreRun = func(*cobra.Command, []string) {
+		seen = adminFlag.GetInt()
+	}
+	cobraflags.CobraOnInitialize("DEFAPP", root)
+
+	root.SetArgs([]string{"serve", "--port", "9000"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(seen, qt.Equals, 9001)
+}
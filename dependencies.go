@@ -0,0 +1,137 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// dependsOnAnnotation lists the flags a flag depends on, see FlagBase.DependsOn.
+const dependsOnAnnotation = "cobraflags-depends-on"
+
+var (
+	// ErrInvalidDependency is returned by CobraOnInitializeE, and by the execution of a
+	// command initialized with CobraOnInitialize, when a flag depends on a flag that is not
+	// available to its command, or when flags depend on each other in a cycle (see
+	// FlagBase.DependsOn).
+	ErrInvalidDependency = errors.New("invalid flag dependency")
+
+	// ErrMissingDependency is returned when a flag is set while a flag it depends on is not
+	// (see FlagBase.DependsOn).
+	ErrMissingDependency = errors.New("missing flag dependency")
+)
+
+// lookupAvailable returns the flag named name that is available to cmd: one of its own
+// flags or a persistent flag of one of its ancestors.
+func lookupAvailable(cmd *cobra.Command, name string) *pflag.Flag {
+	if f := cmd.Flags().Lookup(name); f != nil {
+		return f
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if f := c.PersistentFlags().Lookup(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// checkDependencyGraph returns an error for each flag of the command tree rooted at root
+// that depends on a flag not available to its command, and for each cycle of dependencies.
+func checkDependencyGraph(root *cobra.Command) error {
+	var errs []error
+	reported := make(map[string]bool)
+	report := func(err error) {
+		if !reported[err.Error()] {
+			reported[err.Error()] = true
+			errs = append(errs, err)
+		}
+	}
+
+	walkCommands(root, func(c *cobra.Command) {
+		const (
+			visiting = 1
+			visited  = 2
+		)
+		state := make(map[*pflag.Flag]int)
+		var path []string
+		var visit func(f *pflag.Flag)
+		visit = func(f *pflag.Flag) {
+			switch state[f] {
+			case visiting:
+				start := 0
+				for path[start] != f.Name {
+					start++
+				}
+				cycle := append(path[start:len(path):len(path)], f.Name)
+				report(fmt.Errorf("%w: cycle --%s on command %q", ErrInvalidDependency, strings.Join(cycle, " -> --"), c.CommandPath()))
+				return
+			case visited:
+				return
+			}
+			state[f] = visiting
+			path = append(path, f.Name)
+			for _, name := range f.Annotations[dependsOnAnnotation] {
+				dep := lookupAvailable(c, name)
+				if dep == nil {
+					report(fmt.Errorf("%w: --%s of command %q depends on --%s, which is not defined",
+						ErrInvalidDependency, f.Name, c.CommandPath(), name))
+					continue
+				}
+				visit(dep)
+			}
+			path = path[:len(path)-1]
+			state[f] = visited
+		}
+		visitFlags(c, func(f *pflag.Flag) {
+			if len(f.Annotations[dependsOnAnnotation]) > 0 {
+				visit(f)
+			}
+		})
+	})
+	return errors.Join(errs...)
+}
+
+// checkDependencies returns an error for each flag available to cmd that is set, from any
+// source, while a flag it depends on is not.
+func checkDependencies(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	dependent := false
+	flags.VisitAll(func(f *pflag.Flag) {
+		dependent = dependent || len(f.Annotations[dependsOnAnnotation]) > 0
+	})
+	if !dependent {
+		return nil
+	}
+
+	sources := make(map[string]Source)
+	for _, p := range Explain(cmd) {
+		sources[p.Name] = p.Source
+	}
+	isSet := func(f *pflag.Flag) bool {
+		if source, ok := sources[f.Name]; ok {
+			return source != SourceDefault && source != SourceDerived
+		}
+		return f.Changed // Not registered through cobraflags.
+	}
+
+	var errs []error
+	flags.VisitAll(func(f *pflag.Flag) {
+		deps := f.Annotations[dependsOnAnnotation]
+		if len(deps) == 0 || !isSet(f) {
+			return
+		}
+		var missing []string
+		for _, name := range deps {
+			if dep := flags.Lookup(name); dep == nil || !isSet(dep) {
+				missing = append(missing, "--"+name)
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("%w: --%s requires %s", ErrMissingDependency, f.Name, strings.Join(missing, ", ")))
+		}
+	})
+	return errors.Join(errs...)
+}
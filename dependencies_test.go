@@ -0,0 +1,65 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestDependsOn(t *testing.T) {
+	c := qt.New(t)
+
+	execute := func(args ...string) error {
+		root := newCobraCommand()
+		serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+		root.AddCommand(serve)
+		(&cobraflags.StringFlag{Name: "ca-file", Persistent: true}).Register(root)
+		cobraflags.Register(serve,
+			&cobraflags.StringFlag{Name: "tls-cert"},
+			&cobraflags.StringFlag{Name: "tls-key", DependsOn: []string{"tls-cert", "ca-file"}},
+		)
+		cobraflags.CobraOnInitialize("DEPONAPP", root)
+		root.SetArgs(append([]string{"serve"}, args...))
+		return root.Execute()
+	}
+
+	c.Assert(execute(), qt.IsNil)
+	c.Assert(execute("--tls-cert", "cert.pem"), qt.IsNil)
+
+	err := execute("--tls-key", "key.pem")
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrMissingDependency)
+	c.Assert(err, qt.ErrorMatches, `missing flag dependency: --tls-key requires --tls-cert, --ca-file`)
+
+	c.Setenv("DEPONAPP_CA_FILE", "ca.pem")
+	err = execute("--tls-key", "key.pem")
+	c.Assert(err, qt.ErrorMatches, `missing flag dependency: --tls-key requires --tls-cert`)
+
+	c.Setenv("DEPONAPP_TLS_KEY", "key.pem")
+	c.Assert(execute("--tls-cert", "cert.pem"), qt.IsNil)
+}
+
+func TestDependsOn_Graph(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	cobraflags.Register(cmd,
+		&cobraflags.StringFlag{Name: "a", DependsOn: []string{"b"}},
+		&cobraflags.StringFlag{Name: "b", DependsOn: []string{"c"}},
+		&cobraflags.StringFlag{Name: "c", DependsOn: []string{"a", "missing"}},
+	)
+	const message = `invalid flag dependency: cycle --a -> --b -> --c -> --a on command "myapp"\n` +
+		`invalid flag dependency: --c of command "myapp" depends on --missing, which is not defined`
+
+	err := cobraflags.CobraOnInitializeE("GRAPHAPP", cmd)
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrInvalidDependency)
+	c.Assert(err, qt.ErrorMatches, message)
+
+	// CobraOnInitialize cannot return it, so the execution fails instead.
+	cobraflags.CobraOnInitialize("GRAPHAPP", cmd)
+	err = cmd.Execute()
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrInvalidDependency)
+	c.Assert(err, qt.ErrorMatches, message)
+}
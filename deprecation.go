@@ -0,0 +1,64 @@
+package cobraflags
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// WithDeprecationWarnings makes the warnings about deprecated flags and environment
+// variables that supply values (see FlagBase.Deprecated and FlagBase.EnvAliases) go to w,
+// worded like pflag's own notices, instead of being logged with slog. Use os.Stderr to
+// show them to the user.
+func WithDeprecationWarnings(w io.Writer) InitOption {
+	return func(c *initConfig) {
+		c.deprecationOut = w
+	}
+}
+
+// warnDeprecated warns about the deprecated flags available to cmd whose effective value
+// comes from the environment, a configuration file or another source set up by
+// CobraOnInitialize. Their use on the command line is already reported by pflag.
+func warnDeprecated(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	deprecated := false
+	flags.VisitAll(func(f *pflag.Flag) {
+		deprecated = deprecated || f.Deprecated != ""
+	})
+	if !deprecated {
+		return
+	}
+
+	cfg := configFor(cmd)
+	for _, p := range Explain(cmd) {
+		f := flags.Lookup(p.Name)
+		if f == nil || f.Deprecated == "" || p.Source == SourceDefault || p.Source == SourceDerived || p.Source == SourceFlag {
+			continue
+		}
+		origin := deprecatedOrigin(p)
+		if cfg.deprecationOut != nil {
+			_, _ = fmt.Fprintf(cfg.deprecationOut, "Flag --%s has been deprecated, %s (set by %s)\n", p.Name, f.Deprecated, origin)
+			continue
+		}
+		slog.Warn("deprecated flag supplied the value", "flag", p.Name, "from", origin, "hint", f.Deprecated)
+	}
+}
+
+// deprecatedOrigin describes where the value of a deprecated flag comes from.
+func deprecatedOrigin(p Provenance) string {
+	switch p.Source {
+	case SourceEnv:
+		return "environment variable " + p.EnvVar
+	case SourceFile:
+		if p.EnvVar != "" {
+			return "the secret file of " + p.EnvVar
+		}
+		return "a secret file"
+	case SourceConfig, SourceViper:
+		return fmt.Sprintf("configuration key %q", p.ConfigKey)
+	}
+	return string(p.Source)
+}
@@ -0,0 +1,64 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestDeprecated(t *testing.T) {
+	c := qt.New(t)
+	logs := captureLogs(c)
+	c.Setenv("DEPAPP_TIMEOUT", "30")
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "legacy-mode: fast\n")
+
+	cmd := newCobraCommand()
+	timeoutFlag := &cobraflags.IntFlag{Name: "timeout", Deprecated: "use --deadline instead"}
+	modeFlag := &cobraflags.StringFlag{Name: "legacy-mode", Deprecated: "use --mode instead"}
+	verboseFlag := &cobraflags.BoolFlag{Name: "verbose", Deprecated: "use --log-level instead"}
+	cobraflags.Register(cmd, timeoutFlag, modeFlag, verboseFlag)
+	cobraflags.CobraOnInitialize("DEPAPP", cmd, cobraflags.WithConfigFiles(filepath.Join(dir, "config.yaml")))
+
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+	cmd.SetOut(&stderr)
+	cmd.SetArgs([]string{"--verbose"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(timeoutFlag.GetInt(), qt.Equals, 30)
+	c.Assert(modeFlag.GetString(), qt.Equals, "fast")
+
+	c.Assert(logs.String(), qt.Contains, `msg="deprecated flag supplied the value" flag=timeout from="environment variable DEPAPP_TIMEOUT" hint="use --deadline instead"`)
+	c.Assert(logs.String(), qt.Contains, `flag=legacy-mode from="configuration key \"legacy-mode\"" hint="use --mode instead"`)
+	c.Assert(logs.String(), qt.Not(qt.Contains), "flag=verbose") // pflag reports the command line.
+	c.Assert(stderr.String(), qt.Contains, "Flag --verbose has been deprecated, use --log-level instead")
+
+	var help bytes.Buffer
+	cmd.SetOut(&help)
+	c.Assert(cmd.Help(), qt.IsNil)
+	c.Assert(help.String(), qt.Not(qt.Contains), "--timeout")
+}
+
+func TestWithDeprecationWarnings(t *testing.T) {
+	c := qt.New(t)
+	cobraflags.ResetState() // Aliases are warned about once per process.
+	logs := captureLogs(c)
+	c.Setenv("DEPWAPP_TIMEOUT", "30")
+	c.Setenv("OLDDEPWAPP_HOST", "old.example.com")
+
+	cmd := newCobraCommand()
+	timeoutFlag := &cobraflags.IntFlag{Name: "timeout", Deprecated: "use --deadline instead"}
+	hostFlag := &cobraflags.StringFlag{Name: "host", EnvAliases: []string{"OLDDEPWAPP_HOST"}}
+	cobraflags.Register(cmd, timeoutFlag, hostFlag)
+	var warnings bytes.Buffer
+	cobraflags.CobraOnInitialize("DEPWAPP", cmd, cobraflags.WithDeprecationWarnings(&warnings))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(warnings.String(), qt.Equals, "Environment variable OLDDEPWAPP_HOST has been deprecated, use DEPWAPP_HOST instead\n"+
+		"Flag --timeout has been deprecated, use --deadline instead (set by environment variable DEPWAPP_TIMEOUT)\n")
+	c.Assert(logs.String(), qt.Equals, "")
+}
@@ -0,0 +1,110 @@
+package cobraflags
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var _ Flag = (*DerivedFlag[string])(nil)
+
+// DerivedFlag represents a flag whose value is computed from other flags by its DefaultFunc,
+// e.g. a base URL assembled from the scheme, host and port, so that commands share the
+// assembly instead of repeating it. T is the value type of one of the other flag kinds:
+// string, int, bool, uint8 or []string.
+//
+// The value is computed once the command is about to run, see FlagBase.DefaultFunc. It is
+// still a regular flag, so it can be overridden on the command line, in the environment or
+// in a configuration file, and it is listed by FlagsOf, Explain and the dumps, with the
+// source SourceDerived while computed.
+//
+// Example usage:
+//
+//	baseURLFlag := &cobraflags.DerivedFlag[string]{
+//		Name:  "base-url",
+//		Usage: "Base URL of the API (default: from --scheme, --host and --port)",
+//		DefaultFunc: func(d *cobraflags.Defaults) (string, error) {
+//			scheme, _ := cobraflags.DefaultOf[string](d, "scheme")
+//			host, _ := cobraflags.DefaultOf[string](d, "host")
+//			port, err := cobraflags.DefaultOf[int](d, "port")
+//			return fmt.Sprintf("%s://%s:%d", scheme, host, port), err
+//		},
+//	}
+//	baseURLFlag.Register(cmd)
+type DerivedFlag[T any] FlagBase[T]
+
+// Register registers the flag with the given cobra command.
+// It panics if the flag cannot be registered; use RegisterE to handle the error instead.
+func (s *DerivedFlag[T]) Register(cmd *cobra.Command) {
+	noError(s.RegisterE(cmd))
+}
+
+// RegisterE registers the flag with the given cobra command.
+// It returns an error if the flag has no DefaultFunc, if T is not a supported value type,
+// or for the reasons given by StringFlag.RegisterE.
+func (s *DerivedFlag[T]) RegisterE(cmd *cobra.Command) error {
+	base := (*FlagBase[T])(s)
+	if base.DefaultFunc == nil {
+		return fmt.Errorf("derived flag %q has no DefaultFunc", s.Name)
+	}
+	read, define, err := derivedAccess(base)
+	if err != nil {
+		return err
+	}
+	return base.register(cmd, s, read, define)
+}
+
+// Get retrieves the current value of the flag, see StringFlag.GetString.
+func (s *DerivedFlag[T]) Get() T {
+	base := (*FlagBase[T])(s)
+	return base.get(base.reader())
+}
+
+// GetE retrieves the current value of the flag with validation, see StringFlag.GetStringE.
+func (s *DerivedFlag[T]) GetE() (T, error) {
+	base := (*FlagBase[T])(s)
+	return base.getE(base.reader())
+}
+
+// Must retrieves the current value of the flag like GetE, but panics if validation fails,
+// see StringFlag.MustString.
+func (s *DerivedFlag[T]) Must() T {
+	base := (*FlagBase[T])(s)
+	return base.must(base.reader())
+}
+
+// reader returns the read function the flag was registered with.
+func (s *FlagBase[T]) reader() readFunc[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.read
+}
+
+// derivedAccess returns the read function and the definition of the pflag.Flag of a
+// DerivedFlag, by its value type.
+func derivedAccess[T any](s *FlagBase[T]) (readFunc[T], func(flags *pflag.FlagSet), error) {
+	var read any
+	var define func(flags *pflag.FlagSet)
+	switch p := any(s).(type) {
+	case *FlagBase[string]:
+		read = readFunc[string](backend.GetString)
+		define = func(flags *pflag.FlagSet) { flags.StringP(p.Name, p.Shorthand, p.Value, p.Usage) }
+	case *FlagBase[int]:
+		read = readFunc[int](backend.GetInt)
+		define = func(flags *pflag.FlagSet) { flags.IntP(p.Name, p.Shorthand, p.Value, p.Usage) }
+	case *FlagBase[bool]:
+		read = readFunc[bool](getBool)
+		define = func(flags *pflag.FlagSet) { flags.BoolP(p.Name, p.Shorthand, p.Value, p.Usage) }
+	case *FlagBase[uint8]:
+		read = readFunc[uint8](getUint8)
+		define = func(flags *pflag.FlagSet) { flags.Uint8P(p.Name, p.Shorthand, p.Value, p.Usage) }
+	case *FlagBase[[]string]:
+		read = readFunc[[]string](backend.GetStringSlice)
+		define = func(flags *pflag.FlagSet) { flags.StringSliceP(p.Name, p.Shorthand, p.Value, p.Usage) }
+	default:
+		var zero T
+		return nil, nil, fmt.Errorf("derived flag %q: unsupported value type %T", s.Name, zero)
+	}
+	return read.(readFunc[T]), define, nil
+}
@@ -0,0 +1,83 @@
+package cobraflags_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestDerivedFlag(t *testing.T) {
+	c := qt.New(t)
+
+	execute := func(args ...string) (*cobraflags.DerivedFlag[string], *cobraflags.DerivedFlag[[]string], []cobraflags.Provenance) {
+		cmd := newCobraCommand()
+		baseURLFlag := &cobraflags.DerivedFlag[string]{
+			Name: "base-url",
+			DefaultFunc: func(d *cobraflags.Defaults) (string, error) {
+				scheme, _ := cobraflags.DefaultOf[string](d, "scheme")
+				host, _ := cobraflags.DefaultOf[string](d, "host")
+				port, err := cobraflags.DefaultOf[int](d, "port")
+				return fmt.Sprintf("%s://%s:%d", scheme, host, port), err
+			},
+		}
+		endpointsFlag := &cobraflags.DerivedFlag[[]string]{
+			Name: "endpoints",
+			DefaultFunc: func(d *cobraflags.Defaults) ([]string, error) {
+				baseURL, err := cobraflags.DefaultOf[string](d, "base-url")
+				return []string{baseURL + "/v1", baseURL + "/v2"}, err
+			},
+		}
+		cobraflags.Register(cmd,
+			&cobraflags.StringFlag{Name: "scheme", Value: "https"},
+			&cobraflags.StringFlag{Name: "host", Value: "localhost"},
+			&cobraflags.IntFlag{Name: "port", Value: 8443},
+			endpointsFlag,
+			baseURLFlag,
+		)
+		cobraflags.CobraOnInitialize("DERIVEDAPP", cmd)
+		cmd.SetArgs(args)
+		c.Assert(cmd.Execute(), qt.IsNil)
+		return baseURLFlag, endpointsFlag, cobraflags.Explain(cmd)
+	}
+
+	baseURLFlag, endpointsFlag, provenance := execute("--host", "example.com")
+	c.Assert(baseURLFlag.Get(), qt.Equals, "https://example.com:8443")
+	c.Assert(baseURLFlag.Must(), qt.Equals, "https://example.com:8443")
+	c.Assert(endpointsFlag.Get(), qt.DeepEquals, []string{"https://example.com:8443/v1", "https://example.com:8443/v2"})
+	c.Assert(provenance[3], qt.DeepEquals, cobraflags.Provenance{
+		Command:   "myapp",
+		Name:      "endpoints",
+		Value:     []string{"https://example.com:8443/v1", "https://example.com:8443/v2"},
+		Source:    cobraflags.SourceDerived,
+		EnvVar:    "DERIVEDAPP_ENDPOINTS",
+		ConfigKey: "endpoints",
+	})
+	c.Assert(provenance[4].Source, qt.Equals, cobraflags.SourceDerived)
+
+	// An explicit value overrides the computed one.
+	c.Setenv("DERIVEDAPP_BASE_URL", "http://proxy:3128")
+	baseURLFlag, endpointsFlag, provenance = execute()
+	value, err := baseURLFlag.GetE()
+	c.Assert(err, qt.IsNil)
+	c.Assert(value, qt.Equals, "http://proxy:3128")
+	c.Assert(endpointsFlag.Get(), qt.DeepEquals, []string{"http://proxy:3128/v1", "http://proxy:3128/v2"})
+	c.Assert(provenance[4].Source, qt.Equals, cobraflags.SourceEnv)
+}
+
+func TestDerivedFlag_RegisterErrors(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	err := (&cobraflags.DerivedFlag[string]{Name: "base-url"}).RegisterE(cmd)
+	c.Assert(err, qt.ErrorMatches, `derived flag "base-url" has no DefaultFunc`)
+
+	err = (&cobraflags.DerivedFlag[time.Duration]{
+		Name:        "timeout",
+		DefaultFunc: func(*cobraflags.Defaults) (time.Duration, error) { return time.Second, nil },
+	}).RegisterE(cmd)
+	c.Assert(err, qt.ErrorMatches, `derived flag "timeout": unsupported value type time.Duration`)
+}
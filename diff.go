@@ -0,0 +1,59 @@
+package cobraflags
+
+import (
+	"reflect"
+
+	"github.com/spf13/cobra"
+)
+
+// Change describes a flag whose effective value differs from its default.
+type Change struct {
+	Command string `json:"command" yaml:"command"` // Path of the command the flag is registered on
+	Name    string `json:"name" yaml:"name"`       // Flag name
+	Default any    `json:"default" yaml:"default"` // Default value
+	Value   any    `json:"value" yaml:"value"`     // Current effective value
+	Source  Source `json:"source" yaml:"source"`   // Where the effective value comes from
+}
+
+// DiffFromDefaults returns the flags registered on cmd and its subcommands whose effective
+// value differs from their default, in depth-first order, together with the source of each
+// change. This is a compact summary of how a program was configured, e.g. for support bundles
+// and bug reports. The values and defaults of secrets are redacted (see FlagBase.Sensitive).
+// Call DiffFromDefaults after the command has been executed.
+//
+// Example:
+//
+//	for _, change := range cobraflags.DiffFromDefaults(rootCmd) {
+//		log.Printf("%s=%v (default %v, from %s)", change.Name, change.Value, change.Default, change.Source)
+//	}
+func DiffFromDefaults(cmd *cobra.Command) []Change {
+	var changes []Change
+	for _, cf := range CollectFlags(cmd) {
+		for _, info := range cf.Flags {
+			if equalValues(info.Default, info.Value) {
+				continue
+			}
+			if isSecret(info) {
+				info.Default, info.Value = redacted, redacted
+			}
+			changes = append(changes, Change{
+				Command: cf.Command,
+				Name:    info.Name,
+				Default: info.Default,
+				Value:   info.Value,
+				Source:  info.Source,
+			})
+		}
+	}
+	return changes
+}
+
+// equalValues reports whether two flag values are equal. Nil and empty slices are equal.
+func equalValues(a, b any) bool {
+	if sa, ok := a.([]string); ok {
+		if sb, ok := b.([]string); ok && len(sa) == 0 && len(sb) == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
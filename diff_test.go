@@ -0,0 +1,49 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestDiffFromDefaults(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("DIFFAPP_PORT", "8080")
+
+	root := &cobra.Command{Use: "diffapp"}
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+
+	(&cobraflags.StringFlag{Name: "level", Value: "info", Persistent: true}).Register(root)
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(serve)
+	(&cobraflags.StringFlag{Name: "host", Value: "localhost"}).Register(serve)
+	(&cobraflags.StringSliceFlag{Name: "tags"}).Register(serve)
+	(&cobraflags.BoolFlag{Name: "verbose"}).Register(serve)
+	cobraflags.CobraOnInitialize("DIFFAPP", root)
+
+	root.SetArgs([]string{"serve", "--verbose", "--host", "localhost"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(cobraflags.DiffFromDefaults(root), qt.DeepEquals, []cobraflags.Change{
+		{Command: "diffapp serve", Name: "port", Default: 80, Value: 8080, Source: cobraflags.SourceEnv},
+		{Command: "diffapp serve", Name: "verbose", Default: false, Value: true, Source: cobraflags.SourceFlag},
+	})
+}
+
+func TestDiffFromDefaults_Sensitive(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.StringFlag{Name: "dsn", Value: "postgres://localhost", Sensitive: true}).Register(cmd)
+	(&cobraflags.StringFlag{Name: "api-token"}).Register(cmd)
+	cobraflags.CobraOnInitialize("DIFFSENSAPP", cmd)
+
+	cmd.SetArgs([]string{"--dsn", "postgres://admin:hunter2@db", "--api-token", "t0ken"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(cobraflags.DiffFromDefaults(cmd), qt.DeepEquals, []cobraflags.Change{
+		{Command: "myapp", Name: "dsn", Default: "***", Value: "***", Source: cobraflags.SourceFlag},
+		{Command: "myapp", Name: "api-token", Default: "***", Value: "***", Source: cobraflags.SourceFlag},
+	})
+}
@@ -0,0 +1,51 @@
+package cobraflags
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// DockerInstruction is a Dockerfile instruction emitted by GenDockerfile.
+type DockerInstruction string
+
+const (
+	DockerEnv DockerInstruction = "ENV" // Environment variables of the container
+	DockerArg DockerInstruction = "ARG" // Build arguments
+)
+
+// GenDockerfile writes a Dockerfile stanza that sets the environment variables of all flags
+// of cmd and its subcommands to their defaults, as ENV or ARG instructions, keeping container
+// defaults in sync with the CLI. Secrets (see NewPrintConfigCommand) are left out, so that
+// they are never baked into images. Values are quoted as needed.
+//
+// Example output:
+//
+//	ENV MYAPP_LOG_LEVEL=info
+//	ENV MYAPP_GREETING="hello world"
+func GenDockerfile(cmd *cobra.Command, w io.Writer, instruction DockerInstruction) error {
+	if instruction != DockerEnv && instruction != DockerArg {
+		return fmt.Errorf("invalid Dockerfile instruction %q", instruction)
+	}
+
+	var sb strings.Builder
+	for _, b := range envBindings(cmd) {
+		if b.secret {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s %s=%s\n", instruction, b.EnvVar, dockerQuote(b.defaultValue))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// dockerQuote quotes a value for a Dockerfile instruction if it is empty or contains
+// whitespace, quotes, backslashes or variable references.
+func dockerQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"'\\$") {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(value) + `"`
+}
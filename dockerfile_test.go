@@ -0,0 +1,40 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestGenDockerfile(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.GenDockerfile(newDeployCommand(), &buf, cobraflags.DockerEnv), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `ENV DEPLOYAPP_LOG_LEVEL=info
+ENV DEPLOYAPP_SERVER_MAX_CONNS=100
+ENV DEPLOYAPP_TAGS=a,b
+ENV DEPLOYAPP_GREETING="hello world"
+`)
+
+	buf.Reset()
+	c.Assert(cobraflags.GenDockerfile(newDeployCommand(), &buf, cobraflags.DockerArg), qt.IsNil)
+	c.Assert(buf.String(), qt.Contains, "ARG DEPLOYAPP_LOG_LEVEL=info\n")
+
+	c.Assert(cobraflags.GenDockerfile(newDeployCommand(), &buf, "RUN"), qt.ErrorMatches, `invalid Dockerfile instruction "RUN"`)
+}
+
+func TestGenDockerfile_EnvSeparator(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.StringSliceFlag{Name: "hosts", Value: []string{"a,1", "b"}}).Register(cmd)
+	cobraflags.CobraOnInitialize("SEPAPP", cmd, cobraflags.WithEnvSeparator(";"))
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.GenDockerfile(cmd, &buf, cobraflags.DockerEnv), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "ENV SEPAPP_HOSTS=a,1;b\n")
+}
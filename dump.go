@@ -0,0 +1,59 @@
+package cobraflags
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// CommandFlags groups the flags registered on a single command.
+type CommandFlags struct {
+	Command string     `json:"command" yaml:"command"` // Full command path, e.g. "myapp server start"
+	Flags   []FlagInfo `json:"flags" yaml:"flags"`     // Flags registered on the command
+}
+
+// CollectFlags returns the flags registered on cmd and all of its subcommands,
+// in depth-first order. Commands without cobraflags-registered flags are omitted.
+func CollectFlags(cmd *cobra.Command) []CommandFlags {
+	var result []CommandFlags
+	walkCommands(cmd, func(c *cobra.Command) {
+		if flags := FlagsOf(c); len(flags) > 0 {
+			result = append(result, CommandFlags{Command: c.CommandPath(), Flags: flags})
+		}
+	})
+	return result
+}
+
+// DumpJSON writes the metadata of all flags registered on cmd and its subcommands
+// (defaults, current values, environment variable names, descriptions) as indented JSON.
+// The output is meant as machine-readable CLI documentation for external tooling. The values
+// and defaults of secrets are redacted, see NewPrintConfigCommand.
+//
+// Example:
+//
+//	_ = cobraflags.DumpJSON(rootCmd, os.Stdout)
+func DumpJSON(cmd *cobra.Command, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(redactedFlags(CollectFlags(cmd)))
+}
+
+// DumpYAML writes the same metadata as DumpJSON as YAML.
+func DumpYAML(cmd *cobra.Command, w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(redactedFlags(CollectFlags(cmd))); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// walkCommands calls fn for cmd and all of its subcommands, depth-first.
+func walkCommands(cmd *cobra.Command, fn func(*cobra.Command)) {
+	fn(cmd)
+	for _, sub := range cmd.Commands() {
+		walkCommands(sub, fn)
+	}
+}
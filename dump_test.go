@@ -0,0 +1,103 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func newDumpCommand() *cobra.Command {
+	root := &cobra.Command{Use: "dumpapp", Run: func(*cobra.Command, []string) {}}
+	sub := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	empty := &cobra.Command{Use: "version", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub, empty)
+
+	(&cobraflags.StringFlag{Name: "config", Usage: "Config file", Value: "app.yaml"}).Register(root)
+	(&cobraflags.IntFlag{Name: "port", Shorthand: "p", Usage: "Port", Value: 80, Required: true}).Register(sub)
+	cobraflags.CobraOnInitialize("DUMPAPP", root)
+
+	root.SetArgs([]string{"serve", "--port", "8080"})
+	return root
+}
+
+func TestDumpJSON(t *testing.T) {
+	c := qt.New(t)
+
+	root := newDumpCommand()
+	c.Assert(root.Execute(), qt.IsNil)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.DumpJSON(root, &buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `[
+  {
+    "command": "dumpapp",
+    "flags": [
+      {
+        "name": "config",
+        "type": "string",
+        "usage": "Config file",
+        "default": "app.yaml",
+        "value": "app.yaml",
+        "viperKey": "config",
+        "envVar": "DUMPAPP_CONFIG",
+        "source": "default"
+      }
+    ]
+  },
+  {
+    "command": "dumpapp serve",
+    "flags": [
+      {
+        "name": "port",
+        "shorthand": "p",
+        "type": "int",
+        "usage": "Port",
+        "default": 80,
+        "value": 8080,
+        "viperKey": "port",
+        "envVar": "DUMPAPP_PORT",
+        "required": true,
+        "source": "flag"
+      }
+    ]
+  }
+]
+`)
+}
+
+func TestDumpYAML(t *testing.T) {
+	c := qt.New(t)
+
+	root := newDumpCommand()
+	c.Assert(root.Execute(), qt.IsNil)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.DumpYAML(root, &buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `- command: dumpapp
+  flags:
+    - name: config
+      type: string
+      usage: Config file
+      default: app.yaml
+      value: app.yaml
+      viperKey: config
+      envVar: DUMPAPP_CONFIG
+      source: default
+- command: dumpapp serve
+  flags:
+    - name: port
+      shorthand: p
+      type: int
+      usage: Port
+      default: 80
+      value: 8080
+      viperKey: port
+      envVar: DUMPAPP_PORT
+      required: true
+      source: flag
+`)
+}
@@ -248,12 +248,12 @@ func TestValidationPrecedence(t *testing.T) {
 			expectedPrecedence: "ValidateFunc",
 		},
 		{
-			name:               "both_set_validatefunc_success_validator_ignored",
+			name:               "both_set_validatefunc_success_validator_runs",
 			hasValidateFunc:    true,
 			hasValidator:       true,
 			validateFuncError:  false,
 			validatorError:     true,
-			expectedError:      "Validator error", // Current implementation runs both validators
+			expectedError:      "Validator error", // ValidationFirstError runs Validator after a successful ValidateFunc
 			expectedPrecedence: "Both",
 		},
 		{
@@ -318,3 +318,162 @@ func TestViperKeyEdgeCases(t *testing.T) {
 	c.Assert(err, qt.IsNil)
 	c.Assert(valueE, qt.Equals, "custom.yaml")
 }
+
+// TestSetErrorHandler tests that a custom error handler replaces the default panic.
+func TestSetErrorHandler(t *testing.T) {
+	c := qt.New(t)
+
+	var handled []error
+	cobraflags.SetErrorHandler(func(err error) {
+		handled = append(handled, err)
+	})
+	c.Cleanup(func() {
+		cobraflags.SetErrorHandler(nil)
+	})
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	(&cobraflags.StringFlag{Name: "duplicate"}).Register(cmd)
+	c.Assert(func() {
+		(&cobraflags.StringFlag{Name: "duplicate"}).Register(cmd)
+	}, qt.Not(qt.PanicMatches), ".*")
+
+	c.Assert(handled, qt.HasLen, 1)
+	c.Assert(errors.Is(handled[0], cobraflags.ErrDuplicateFlag), qt.IsTrue)
+
+	// Restoring the default handler brings the panic back.
+	cobraflags.SetErrorHandler(nil)
+	c.Assert(func() {
+		(&cobraflags.StringFlag{Name: "duplicate"}).Register(cmd)
+	}, qt.PanicMatches, ".*flag already registered.*")
+}
+
+// TestSetPanicOnInternalError tests that internal errors are logged instead of causing
+// a panic once panics are disabled, while RegisterE and GetE still return them.
+func TestSetPanicOnInternalError(t *testing.T) {
+	c := qt.New(t)
+	logs := captureLogs(c)
+	cobraflags.SetPanicOnInternalError(false)
+	c.Cleanup(func() {
+		cobraflags.SetPanicOnInternalError(true)
+	})
+
+	cmd := newCobraCommand()
+	(&cobraflags.StringFlag{Name: "name"}).Register(cmd)
+	duplicate := &cobraflags.StringFlag{Name: "name", Value: "other"}
+	c.Assert(func() {
+		duplicate.Register(cmd)
+	}, qt.Not(qt.PanicMatches), ".*")
+	c.Assert(logs.String(), qt.Contains, "flag already registered")
+	c.Assert(errors.Is(duplicate.RegisterE(cmd), cobraflags.ErrDuplicateFlag), qt.IsTrue)
+
+	unregistered := &cobraflags.IntFlag{Name: "count", Value: 5}
+	c.Assert(unregistered.GetInt(), qt.Equals, 0)
+	_, err := unregistered.GetIntE()
+	c.Assert(errors.Is(err, cobraflags.ErrNotRegistered), qt.IsTrue)
+
+	cobraflags.SetPanicOnInternalError(true)
+	c.Assert(func() {
+		unregistered.GetInt()
+	}, qt.PanicMatches, `flag not registered: "count"`)
+}
+
+// TestMustAccessors tests that Must accessors return valid values and panic
+// with an actionable message for invalid ones.
+func TestMustAccessors(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("MUSTAPP_PORT", "80")
+
+	cmd := &cobra.Command{
+		Use: "test",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	portFlag := &cobraflags.IntFlag{
+		Name:  "port",
+		Value: 8080,
+		ValidateFunc: func(port int) error {
+			if port < 1024 {
+				return errors.New("port must not be privileged")
+			}
+			return nil
+		},
+	}
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "app"}
+	verboseFlag := &cobraflags.BoolFlag{Name: "verbose"}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags", Value: []string{"a"}}
+	levelFlag := &cobraflags.Uint8Flag{Name: "level", Value: 3}
+	cobraflags.Register(cmd, portFlag, nameFlag, verboseFlag, tagsFlag, levelFlag)
+	cobraflags.CobraOnInitialize("MUSTAPP", cmd)
+
+	cmd.SetArgs([]string{"--verbose"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	c.Assert(nameFlag.MustString(), qt.Equals, "app")
+	c.Assert(verboseFlag.MustBool(), qt.IsTrue)
+	c.Assert(tagsFlag.MustStringSlice(), qt.DeepEquals, []string{"a"})
+	c.Assert(levelFlag.MustUint8(), qt.Equals, uint8(3))
+
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		c.Assert(ok, qt.IsTrue)
+		c.Assert(err, qt.ErrorMatches, `cobraflags: invalid value 80 for flag "port" \(from env\): port must not be privileged`)
+	}()
+	portFlag.MustInt()
+}
+
+// TestGetE_NotRegistered tests that reading an unregistered flag returns an error from
+// GetE instead of invoking the error handler, and that binding is retried once the flag
+// has been registered.
+func TestGetE_NotRegistered(t *testing.T) {
+	c := qt.New(t)
+
+	var handled []error
+	cobraflags.SetErrorHandler(func(err error) {
+		handled = append(handled, err)
+	})
+	c.Cleanup(func() {
+		cobraflags.SetErrorHandler(nil)
+	})
+
+	flag := &cobraflags.IntFlag{Name: "count", Value: 5}
+
+	_, err := flag.GetIntE()
+	c.Assert(errors.Is(err, cobraflags.ErrNotRegistered), qt.IsTrue)
+	c.Assert(err, qt.ErrorMatches, `flag not registered: "count"`)
+	c.Assert(handled, qt.HasLen, 0)
+
+	c.Assert(flag.GetInt(), qt.Equals, 0)
+	c.Assert(handled, qt.HasLen, 1)
+	c.Assert(errors.Is(handled[0], cobraflags.ErrNotRegistered), qt.IsTrue)
+
+	cmd := newCobraCommand()
+	flag.Register(cmd)
+	cmd.SetArgs([]string{"--count", "7"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	value, err := flag.GetIntE()
+	c.Assert(err, qt.IsNil)
+	c.Assert(value, qt.Equals, 7)
+	c.Assert(handled, qt.HasLen, 1)
+}
+
+// TestGet_RebindAfterResetState tests that flags are bound to the new Viper instance
+// of their command tree after ResetState.
+func TestGet_RebindAfterResetState(t *testing.T) {
+	c := qt.New(t)
+	c.Cleanup(cobraflags.ResetState)
+
+	cmd := newCobraCommand()
+	flag := &cobraflags.StringFlag{Name: "name", ViperKey: "app.name", Value: "default"}
+	flag.Register(cmd)
+	c.Assert(flag.GetString(), qt.Equals, "default")
+
+	cobraflags.ResetState()
+	c.Assert(flag.GetString(), qt.Equals, "default")
+	c.Assert(cobraflags.ViperFor(cmd).GetString("app.name"), qt.Equals, "default")
+}
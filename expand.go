@@ -0,0 +1,90 @@
+package cobraflags
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// WithExpandEnv enables the expansion of environment variable references in the values
+// of all string and string slice flags, whether they come from the command line, the
+// environment, a configuration file or the default. For example, --log-dir '${HOME}/logs'
+// yields the path in the user's home directory. To opt in for individual flags, set
+// FlagBase.ExpandEnv instead.
+//
+// Both ${VAR} and $VAR are expanded, and "$$" stands for a literal "$". Undefined variables
+// expand to the empty string; with WithStrictEnv, they make the GetE methods fail instead.
+// Expansion happens when the value is read, before validation.
+func WithExpandEnv() InitOption {
+	return func(c *initConfig) {
+		c.expandEnv = true
+	}
+}
+
+// expand expands environment variable references in v, if enabled for the flag
+// and v is a string or a string slice.
+func (s *FlagBase[T]) expand(v T) (T, error) {
+	s.mu.RLock()
+	cmd := s.cmd
+	s.mu.RUnlock()
+	if cmd == nil {
+		return v, nil
+	}
+
+	cfg := configFor(cmd)
+	if !s.ExpandEnv && !cfg.expandEnv {
+		return v, nil
+	}
+
+	switch value := any(v).(type) {
+	case string:
+		expanded, err := expandEnv(value, cfg.strictEnv)
+		if err != nil {
+			return v, fmt.Errorf(translate(cmd, MessageFlagError), s.Name, err)
+		}
+		return any(expanded).(T), nil
+	case []string:
+		// The slice may be shared with the cache, so it is copied once an item changes.
+		expanded, copied := value, false
+		for i, item := range value {
+			item, err := expandEnv(item, cfg.strictEnv)
+			if err != nil {
+				return v, fmt.Errorf(translate(cmd, MessageFlagError), s.Name, err)
+			}
+			if item != value[i] {
+				if !copied {
+					expanded, copied = slices.Clone(value), true
+				}
+				expanded[i] = item
+			}
+		}
+		return any(expanded).(T), nil
+	}
+	return v, nil
+}
+
+// expandEnv expands ${VAR} and $VAR references in s with the values of environment
+// variables, where "$$" stands for a literal "$". Undefined variables expand to the
+// empty string, or are an error if strict.
+func expandEnv(s string, strict bool) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var undefined []string
+	expanded := os.Expand(s, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			undefined = append(undefined, name)
+		}
+		return value
+	})
+	if strict && len(undefined) > 0 {
+		return "", fmt.Errorf("undefined environment variable %s", strings.Join(undefined, ", "))
+	}
+	return expanded, nil
+}
@@ -0,0 +1,81 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestExpandEnv(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("EXPANDTEST_HOME", "/home/gopher")
+	c.Setenv("EXPANDTEST_REGION", "eu-west-1")
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "region: ${EXPANDTEST_REGION}\nzones: [\"${EXPANDTEST_REGION}a\", \"${EXPANDTEST_REGION}b\"]\n")
+
+	cmd := newCobraCommand()
+	logDirFlag := &cobraflags.StringFlag{Name: "log-dir"}
+	regionFlag := &cobraflags.StringFlag{Name: "region"}
+	zonesFlag := &cobraflags.StringSliceFlag{Name: "zones"}
+	priceFlag := &cobraflags.StringFlag{Name: "price", Value: "$$5 for $EXPANDTEST_UNDEFINED"}
+	cobraflags.Register(cmd, logDirFlag, regionFlag, zonesFlag, priceFlag)
+	cobraflags.CobraOnInitialize("EXPANDAPP", cmd,
+		cobraflags.WithConfigFile("config", "yaml", dir), cobraflags.WithExpandEnv())
+
+	cmd.SetArgs([]string{"--log-dir", "${EXPANDTEST_HOME}/logs"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(logDirFlag.GetString(), qt.Equals, "/home/gopher/logs")
+	c.Assert(regionFlag.GetString(), qt.Equals, "eu-west-1")
+	c.Assert(zonesFlag.GetStringSlice(), qt.DeepEquals, []string{"eu-west-1a", "eu-west-1b"})
+	c.Assert(priceFlag.GetString(), qt.Equals, "$5 for ")
+}
+
+func TestExpandEnv_Flag(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("EXPANDTEST_HOME", "/home/gopher")
+
+	cmd := newCobraCommand()
+	logDirFlag := &cobraflags.StringFlag{Name: "log-dir", Value: "$EXPANDTEST_HOME/logs", ExpandEnv: true}
+	pattern := &cobraflags.StringFlag{Name: "pattern", Value: "$EXPANDTEST_HOME"}
+	cobraflags.Register(cmd, logDirFlag, pattern)
+	cobraflags.CobraOnInitialize("EXPANDAPP", cmd)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(logDirFlag.GetString(), qt.Equals, "/home/gopher/logs")
+	c.Assert(pattern.GetString(), qt.Equals, "$EXPANDTEST_HOME")
+}
+
+func TestExpandEnv_Strict(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	logDirFlag := &cobraflags.StringFlag{Name: "log-dir", Value: "${EXPANDTEST_UNDEFINED}/logs"}
+	cobraflags.Register(cmd, logDirFlag)
+	cobraflags.CobraOnInitialize("EXPANDAPP", cmd, cobraflags.WithExpandEnv(), cobraflags.WithStrictEnv())
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	_, err := logDirFlag.GetStringE()
+	c.Assert(err, qt.ErrorMatches, `flag "log-dir": undefined environment variable EXPANDTEST_UNDEFINED`)
+	c.Assert(func() { logDirFlag.MustString() }, qt.PanicMatches, `cobraflags: flag "log-dir": undefined environment variable EXPANDTEST_UNDEFINED`)
+}
+
+func TestExpandEnv_StringSliceShared(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("EXPANDTEST_ZONE", "a")
+
+	cmd := newCobraCommand()
+	zonesFlag := &cobraflags.StringSliceFlag{Name: "zones", ExpandEnv: true}
+	zonesFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("EXPANDAPP", cmd)
+
+	cmd.SetArgs([]string{"--zones", "${EXPANDTEST_ZONE},b"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(zonesFlag.GetStringSlice(), qt.DeepEquals, []string{"a", "b"})
+
+	// Expansion copies the cached value instead of changing it.
+	c.Setenv("EXPANDTEST_ZONE", "c")
+	c.Assert(zonesFlag.GetStringSlice(), qt.DeepEquals, []string{"c", "b"})
+}
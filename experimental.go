@@ -0,0 +1,77 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// experimentalAnnotation marks the experimental flags, see FlagBase.Experimental.
+const experimentalAnnotation = "cobraflags-experimental"
+
+// experimentalKey is the key the environment variable unlocking experimental flags is
+// derived from, e.g. MYAPP_EXPERIMENTAL for the prefix "MYAPP".
+const experimentalKey = "experimental"
+
+// ErrExperimentalFlag is returned when an experimental flag (see FlagBase.Experimental)
+// is set while experimental flags are not unlocked.
+var ErrExperimentalFlag = errors.New("experimental flag")
+
+// WithExperimentalFlags unlocks the experimental flags (see FlagBase.Experimental) of the
+// command tree, e.g. in development builds, as if <prefix>_EXPERIMENTAL=1 was set.
+func WithExperimentalFlags() InitOption {
+	return func(c *initConfig) {
+		c.experimental = true
+	}
+}
+
+// experimentalEnvVar returns the environment variable that unlocks experimental flags.
+func experimentalEnvVar(cfg *initConfig) string {
+	name, _ := envVarFor(cfg.envPrefix, cfg, &pflag.Flag{}, experimentalKey)
+	return name
+}
+
+// experimentalUnlocked reports whether experimental flags are accepted: with
+// WithExperimentalFlags, or if their environment variable is set to a true value.
+func experimentalUnlocked(cfg *initConfig) bool {
+	if cfg.experimental {
+		return true
+	}
+	unlocked, err := parseBool(os.Getenv(experimentalEnvVar(cfg)))
+	return err == nil && unlocked
+}
+
+// checkExperimental returns an error for each experimental flag available to cmd that is
+// set, from any source, while experimental flags are locked. Once they are unlocked, it
+// logs a warning for each of them instead.
+func checkExperimental(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	experimental := false
+	flags.VisitAll(func(f *pflag.Flag) {
+		experimental = experimental || len(f.Annotations[experimentalAnnotation]) > 0
+	})
+	if !experimental {
+		return nil
+	}
+
+	cfg := configFor(cmd)
+	unlocked := experimentalUnlocked(cfg)
+	var errs []error
+	for _, p := range Explain(cmd) {
+		f := flags.Lookup(p.Name)
+		if f == nil || len(f.Annotations[experimentalAnnotation]) == 0 || p.Source == SourceDefault || p.Source == SourceDerived {
+			continue
+		}
+		if !unlocked {
+			errs = append(errs, fmt.Errorf("%w: --%s is not enabled, set %s=1 to enable experimental flags",
+				ErrExperimentalFlag, p.Name, experimentalEnvVar(cfg)))
+			continue
+		}
+		slog.Warn("experimental flag in use, it may change or be removed", "flag", p.Name, "source", p.Source)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,54 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestExperimental(t *testing.T) {
+	c := qt.New(t)
+
+	execute := func(args []string, opts ...cobraflags.InitOption) (*cobraflags.BoolFlag, error) {
+		cmd := newCobraCommand()
+		turboFlag := &cobraflags.BoolFlag{Name: "turbo", Usage: "Preview engine", Experimental: true}
+		(&cobraflags.IntFlag{Name: "port"}).Register(cmd)
+		turboFlag.Register(cmd)
+		cobraflags.CobraOnInitialize("EXPAPP", cmd, opts...)
+		cmd.SetArgs(args)
+		return turboFlag, cmd.Execute()
+	}
+
+	c.Run("not set", func(c *qt.C) {
+		_, err := execute([]string{"--port", "80"})
+		c.Assert(err, qt.IsNil)
+	})
+
+	c.Run("locked", func(c *qt.C) {
+		_, err := execute([]string{"--turbo"})
+		c.Assert(err, qt.ErrorIs, cobraflags.ErrExperimentalFlag)
+		c.Assert(err, qt.ErrorMatches, `experimental flag: --turbo is not enabled, set EXPAPP_EXPERIMENTAL=1 to enable experimental flags`)
+
+		c.Setenv("EXPAPP_TURBO", "true")
+		_, err = execute(nil)
+		c.Assert(err, qt.ErrorIs, cobraflags.ErrExperimentalFlag)
+	})
+
+	c.Run("unlocked", func(c *qt.C) {
+		logs := captureLogs(c)
+		c.Setenv("EXPAPP_EXPERIMENTAL", "1")
+		turboFlag, err := execute([]string{"--turbo"})
+		c.Assert(err, qt.IsNil)
+		c.Assert(turboFlag.GetBool(), qt.IsTrue)
+		c.Assert(logs.String(), qt.Contains, `msg="experimental flag in use, it may change or be removed" flag=turbo source=flag`)
+	})
+
+	c.Run("unlocked with option", func(c *qt.C) {
+		captureLogs(c)
+		turboFlag, err := execute([]string{"--turbo"}, cobraflags.WithExperimentalFlags())
+		c.Assert(err, qt.IsNil)
+		c.Assert(turboFlag.GetBool(), qt.IsTrue)
+	})
+}
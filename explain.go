@@ -0,0 +1,131 @@
+package cobraflags
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Provenance describes the effective value of a flag and where it comes from.
+type Provenance struct {
+	Command    string `json:"command" yaml:"command"`                           // Path of the command the flag is registered on
+	Name       string `json:"name" yaml:"name"`                                 // Flag name
+	Value      any    `json:"value" yaml:"value"`                               // Current effective value
+	Source     Source `json:"source" yaml:"source"`                             // Where the effective value comes from
+	EnvVar     string `json:"envVar,omitempty" yaml:"envVar,omitempty"`         // Environment variable consulted
+	ConfigKey  string `json:"configKey" yaml:"configKey"`                       // Configuration key consulted
+	ConfigFile string `json:"configFile,omitempty" yaml:"configFile,omitempty"` // Configuration file the value was read from, if its source is SourceConfig
+	Sensitive  bool   `json:"sensitive,omitempty" yaml:"sensitive,omitempty"`   // Whether the value is a secret, see FlagBase.Sensitive
+}
+
+// Explain returns the provenance of every flag available to cmd: the flags registered
+// on cmd, followed by the persistent flags inherited from its ancestors, nearest first.
+// Each entry names the effective value, its source, and the environment variable and
+// configuration key that were consulted, answering "why is my app using this value?".
+// Call Explain after the command has been executed, e.g. from Run.
+//
+// Example:
+//
+//	for _, p := range cobraflags.Explain(cmd) {
+//		log.Printf("%s=%v (from %s)", p.Name, p.Value, p.Source)
+//	}
+func Explain(cmd *cobra.Command) []Provenance {
+	configFile := ConfigFileUsed(cmd)
+
+	var result []Provenance
+	seen := make(map[string]bool)
+	add := func(c *cobra.Command, info FlagInfo) {
+		if seen[info.Name] {
+			return // Shadowed by a flag of a nearer command.
+		}
+		seen[info.Name] = true
+
+		p := Provenance{
+			Command:   c.CommandPath(),
+			Name:      info.Name,
+			Value:     info.Value,
+			Source:    info.Source,
+			EnvVar:    info.EnvVar,
+			ConfigKey: info.ViperKey,
+			Sensitive: info.Sensitive,
+		}
+		if info.Source == SourceConfig {
+			p.ConfigFile = configFile
+		}
+		result = append(result, p)
+	}
+
+	for _, info := range FlagsOf(cmd) {
+		add(cmd, info)
+	}
+	for c := cmd.Parent(); c != nil; c = c.Parent() {
+		for _, info := range FlagsOf(c) {
+			if info.Persistent {
+				add(c, info)
+			}
+		}
+	}
+	return result
+}
+
+// PrintExplain writes the provenance of the flags available to cmd (see Explain)
+// to w as a table. Values of secrets are redacted, see NewPrintConfigCommand.
+//
+// Example output:
+//
+//	FLAG  VALUE      SOURCE   ENV VAR      CONFIG KEY
+//	port  8080       env      MYAPP_PORT   port
+//	host  localhost  default  MYAPP_HOST   host
+func PrintExplain(cmd *cobra.Command, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "FLAG\tVALUE\tSOURCE\tENV VAR\tCONFIG KEY"); err != nil {
+		return err
+	}
+	for _, p := range Explain(cmd) {
+		source := string(p.Source)
+		if p.ConfigFile != "" {
+			source += " (" + p.ConfigFile + ")"
+		}
+		value := p.Value
+		if p.secret() {
+			value = redacted
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%v\t%s\t%s\t%s\n", p.Name, value, source, p.EnvVar, p.ConfigKey); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// LogEffectiveConfig logs the effective configuration of cmd at the info level, one record
+// per flag available to cmd (see Explain) with its name, value and source, for services
+// to log their configuration at boot in a standard way. Values of secrets are redacted,
+// see NewPrintConfigCommand. If logger is nil, slog.Default() is used. Call it once the
+// command runs, e.g. from its Run or PersistentPreRun function.
+//
+// Example output with a text handler:
+//
+//	level=INFO msg="effective configuration" flag=port value=8080 source=env
+//	level=INFO msg="effective configuration" flag=token value=*** source=flag
+func LogEffectiveConfig(logger *slog.Logger, cmd *cobra.Command) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	for _, p := range Explain(cmd) {
+		value := p.Value
+		if p.secret() {
+			value = redacted
+		}
+		logger.LogAttrs(context.Background(), slog.LevelInfo, "effective configuration",
+			slog.String("flag", p.Name), slog.Any("value", value), slog.String("source", string(p.Source)))
+	}
+}
+
+// secret reports whether the value must not be shown, see isSecret.
+func (p Provenance) secret() bool {
+	return isSecret(FlagInfo{Name: p.Name, Source: p.Source, Sensitive: p.Sensitive})
+}
@@ -0,0 +1,94 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestExplain(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("EXPLAINAPP_PORT", "8080")
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "level: debug\n")
+
+	root := &cobra.Command{Use: "explainapp"}
+	var provenance []cobraflags.Provenance
+	serve := &cobra.Command{Use: "serve", Run: func(cmd *cobra.Command, _ []string) {
+		provenance = cobraflags.Explain(cmd)
+	}}
+	root.AddCommand(serve)
+
+	(&cobraflags.StringFlag{Name: "level", Value: "info", Persistent: true}).Register(root)
+	(&cobraflags.StringFlag{Name: "unused", Value: "local"}).Register(root)
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(serve)
+	(&cobraflags.StringFlag{Name: "host", Value: "localhost"}).Register(serve)
+	(&cobraflags.BoolFlag{Name: "verbose"}).Register(serve)
+	cobraflags.CobraOnInitialize("EXPLAINAPP", root, cobraflags.WithConfigFile("config", "yaml", dir))
+
+	root.SetArgs([]string{"serve", "--verbose"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(provenance, qt.DeepEquals, []cobraflags.Provenance{
+		{Command: "explainapp serve", Name: "port", Value: 8080, Source: cobraflags.SourceEnv, EnvVar: "EXPLAINAPP_PORT", ConfigKey: "port"},
+		{Command: "explainapp serve", Name: "host", Value: "localhost", Source: cobraflags.SourceDefault, EnvVar: "EXPLAINAPP_HOST", ConfigKey: "host"},
+		{Command: "explainapp serve", Name: "verbose", Value: true, Source: cobraflags.SourceFlag, EnvVar: "EXPLAINAPP_VERBOSE", ConfigKey: "verbose"},
+		{Command: "explainapp", Name: "level", Value: "debug", Source: cobraflags.SourceConfig, EnvVar: "EXPLAINAPP_LEVEL", ConfigKey: "level",
+			ConfigFile: filepath.Join(dir, "config.yaml")},
+	})
+}
+
+func TestPrintExplain(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("EXPLAINAPP_PORT", "8080")
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	(&cobraflags.StringFlag{Name: "host", Value: "localhost"}).Register(cmd)
+	cobraflags.CobraOnInitialize("EXPLAINAPP", cmd)
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.PrintExplain(cmd, &buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, ""+
+		"FLAG  VALUE      SOURCE   ENV VAR          CONFIG KEY\n"+
+		"port  8080       env      EXPLAINAPP_PORT  port\n"+
+		"host  localhost  default  EXPLAINAPP_HOST  host\n")
+}
+
+func TestLogEffectiveConfig(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("EXPLAINAPP_PORT", "8080")
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	(&cobraflags.StringFlag{Name: "api-token"}).Register(cmd)
+	cobraflags.CobraOnInitialize("EXPLAINAPP", cmd)
+	cmd.SetArgs([]string{"--api-token", "s3cr3t"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	cobraflags.LogEffectiveConfig(logger, cmd)
+	c.Assert(buf.String(), qt.Equals, ""+
+		"level=INFO msg=\"effective configuration\" flag=port value=8080 source=env\n"+
+		"level=INFO msg=\"effective configuration\" flag=api-token value=*** source=flag\n")
+
+	// The default logger is used without one.
+	logs := captureLogs(c)
+	cobraflags.LogEffectiveConfig(nil, cmd)
+	c.Assert(logs.String(), qt.Contains, "flag=port value=8080 source=env")
+}
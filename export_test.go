@@ -0,0 +1,16 @@
+package cobraflags
+
+import "github.com/spf13/cobra"
+
+// Backend exposes backend to the tests.
+type Backend = backend
+
+// SetBackend makes the store of cmd's command tree use b instead of its Viper instance.
+func SetBackend(cmd *cobra.Command, b Backend) {
+	st := storeFor(cmd)
+	st.lock()
+	defer st.mu.Unlock()
+
+	st.b = b
+	assignments.Add(1) // Bound flags bind anew, see FlagBase.bind.
+}
@@ -1,9 +1,12 @@
 package cobraflags
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cast"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 )
 
 var _ Flag = (*BoolFlag)(nil)
@@ -22,7 +25,8 @@ var _ Flag = (*BoolFlag)(nil)
 // Boolean flags have special behavior:
 //   - They can be used without a value: --verbose (sets to true)
 //   - They can be explicitly set: --verbose=true or --verbose=false
-//   - Environment variables accept: true/false, 1/0, yes/no, on/off
+//   - Environment variables and configuration files accept true/false, 1/0, yes/no
+//     and on/off in any case, anything else is an error (see GetBoolE and WithStrictEnv)
 //
 // Example usage:
 //
@@ -46,25 +50,35 @@ type BoolFlag FlagBase[bool]
 // pBoolFlag is an alias for a pointer to FlagBase[bool].
 type pBoolFlag = *FlagBase[bool]
 
+// Register registers the flag with the given cobra command.
+// It panics if the flag cannot be registered; use RegisterE to handle the error instead.
 func (s *BoolFlag) Register(cmd *cobra.Command) {
-	var flags *pflag.FlagSet
-	if s.Persistent {
-		flags = cmd.PersistentFlags()
-	} else {
-		flags = cmd.Flags()
-	}
+	noError(s.RegisterE(cmd))
+}
 
-	flags.BoolP(s.Name, s.Shorthand, s.Value, s.Usage)
+// RegisterE registers the flag with the given cobra command.
+// It returns an error if a flag with the same name or shorthand is already registered,
+// if the shorthand is invalid, or if the flag cannot be marked as required.
+func (s *BoolFlag) RegisterE(cmd *cobra.Command) error {
+	return pBoolFlag(s).register(cmd, s, getBool, func(flags *pflag.FlagSet) {
+		flags.BoolP(s.Name, s.Shorthand, s.Value, s.Usage)
+	})
+}
 
-	if s.Required {
-		noError(cmd.MarkFlagRequired(s.Name))
-	}
-	s.flag = flags.Lookup(s.Name)
+// Clone returns an independent copy of the flag definition, without any registration
+// or binding state, so that the same specification can be attached to another command.
+func (s *BoolFlag) Clone() *BoolFlag {
+	return (*BoolFlag)(pBoolFlag(s).clone())
+}
 
-	if s.flag.Annotations == nil {
-		s.flag.Annotations = make(map[string][]string)
-	}
-	s.flag.Annotations[viperKeyAnnotation] = []string{pBoolFlag(s).getViperKey()}
+// RegisterOn registers a separate clone of the flag on each of the given commands
+// and returns the clones in the same order. Values must be read from the clone
+// registered on the command of interest: each clone reads the value given for its own
+// command, while the environment variable and configuration key are shared. Values set
+// on the Viper instance directly, e.g. with ViperFor(cmd).Set, do not reach clones.
+// It panics if a clone cannot be registered.
+func (s *BoolFlag) RegisterOn(cmds ...*cobra.Command) []*BoolFlag {
+	return registerClones(s.Clone, cmds)
 }
 
 // GetBool retrieves the current boolean value of the flag.
@@ -77,13 +91,7 @@ func (s *BoolFlag) Register(cmd *cobra.Command) {
 //
 // Returns the boolean value, which may be the default value if the flag was not set.
 func (s *BoolFlag) GetBool() bool {
-	viperKey := pBoolFlag(s).getViperKey()
-
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
-
-	return viper.GetBool(viperKey)
+	return pBoolFlag(s).get(getBool)
 }
 
 // GetBoolE retrieves the current boolean value of the flag with validation.
@@ -92,26 +100,59 @@ func (s *BoolFlag) GetBool() bool {
 //
 // Validation behavior:
 //   - If ValidateFunc is set, it is called with the boolean value
-//   - If ValidateFunc is nil but Validator is set, Validator.Validate() is called
+//   - If Validator is set, Validator.Validate() is called, after a successful
+//     ValidateFunc by default (see ValidationMode)
 //   - If neither is set, no validation is performed
 //
+// Values that are not booleans, e.g. MYAPP_VERBOSE=maybe, are reported with an error
+// naming the flag and the offending value, while GetBool returns false for them.
+//
 // Returns:
 //   - On success: the boolean value and nil error
+//   - On a value that is not a boolean: false and the parse error
 //   - On validation failure: false and the validation error
 //
 // Use this method when you need to ensure the flag value meets your validation criteria.
 func (s *BoolFlag) GetBoolE() (bool, error) {
-	viperKey := pBoolFlag(s).getViperKey()
+	return pBoolFlag(s).getE(getBool)
+}
 
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
+// MustBool retrieves the current value of the flag like GetBoolE, but panics
+// if the value is not a boolean or validation fails. The panic value is an error naming
+// the flag, the offending value and its source, which makes it suitable for wiring in
+// main() where returning errors would just be boilerplate.
+func (s *BoolFlag) MustBool() bool {
+	return pBoolFlag(s).must(getBool)
+}
 
-	v := viper.GetBool(viperKey)
+// parseBool parses a boolean given in an environment variable or a configuration file:
+// true/false, 1/0, yes/no or on/off, in any case.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "1", "yes", "on":
+		return true, nil
+	case "false", "0", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q, use true/false, 1/0, yes/no or on/off", value)
+}
 
-	if result, err := pBoolFlag(s).validate(v); err != nil {
-		return result, err
+// toBool converts a raw value stored in Viper to a boolean, parsing strings with parseBool.
+func toBool(raw any) (bool, error) {
+	if value, ok := raw.(string); ok {
+		return parseBool(value)
 	}
+	return cast.ToBoolE(raw)
+}
+
+// isBool reports an error if the raw value stored in Viper is not a boolean.
+func isBool(raw any) error {
+	_, err := toBool(raw)
+	return err
+}
 
-	return v, nil
+// getBool reads the value from the backend like toBool. Values that are not booleans yield false.
+func getBool(b backend, key string) bool {
+	v, _ := toBool(b.Get(key))
+	return v
 }
@@ -234,3 +234,71 @@ func TestBoolFlag_ViperKey_HappyPath(t *testing.T) {
 		})
 	}
 }
+
+func TestBoolFlag_EnvValues(t *testing.T) {
+	tests := []struct {
+		env   string
+		value bool
+		err   string
+	}{
+		{env: "true", value: true},
+		{env: "FALSE", value: false},
+		{env: "1", value: true},
+		{env: "0", value: false},
+		{env: "Yes", value: true},
+		{env: "no", value: false},
+		{env: "ON", value: true},
+		{env: "off", value: false},
+		{env: "maybe", err: `invalid boolean "maybe", use true/false, 1/0, yes/no or on/off`},
+		{env: "t", err: `invalid boolean "t", use true/false, 1/0, yes/no or on/off`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			c := qt.New(t)
+			c.Setenv("BOOLAPP_VERBOSE", tt.env)
+
+			cmd := newCobraCommand()
+			flag := &cobraflags.BoolFlag{Name: "verbose", Value: !tt.value}
+			flag.Register(cmd)
+			cobraflags.CobraOnInitialize("BOOLAPP", cmd)
+			c.Assert(cmd.Execute(), qt.IsNil)
+
+			value, err := flag.GetBoolE()
+			if tt.err == "" {
+				c.Assert(err, qt.IsNil)
+				c.Assert(value, qt.Equals, tt.value)
+				c.Assert(flag.GetBool(), qt.Equals, tt.value)
+				return
+			}
+			c.Assert(err, qt.ErrorMatches, `flag "verbose": `+tt.err)
+			c.Assert(flag.GetBool(), qt.IsFalse)
+			c.Assert(func() { flag.MustBool() }, qt.PanicMatches, `cobraflags: flag "verbose": `+tt.err)
+
+			// WithStrictEnv makes the execution fail instead.
+			cmd = newCobraCommand()
+			(&cobraflags.BoolFlag{Name: "verbose"}).Register(cmd)
+			cobraflags.CobraOnInitialize("BOOLAPP", cmd, cobraflags.WithStrictEnv())
+			c.Assert(cmd.Execute(), qt.ErrorMatches, `environment variable BOOLAPP_VERBOSE: `+tt.err)
+		})
+	}
+}
+
+func TestBoolFlag_ConfigValues(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "verbose: \"yes\"\ncolor: off\ndebug: true\n")
+
+	cmd := newCobraCommand()
+	verboseFlag := &cobraflags.BoolFlag{Name: "verbose"}
+	colorFlag := &cobraflags.BoolFlag{Name: "color", Value: true}
+	debugFlag := &cobraflags.BoolFlag{Name: "debug"}
+	cobraflags.Register(cmd, verboseFlag, colorFlag, debugFlag)
+	cobraflags.CobraOnInitialize("BOOLCFGAPP", cmd, cobraflags.WithConfigFile("config", "yaml", dir))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(verboseFlag.GetBool(), qt.IsTrue)
+	c.Assert(colorFlag.GetBool(), qt.IsFalse)
+	c.Assert(debugFlag.GetBool(), qt.IsTrue)
+}
@@ -1,9 +1,12 @@
 package cobraflags
 
 import (
+	"fmt"
+	"math"
+
+	"github.com/spf13/cast"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 )
 
 var _ Flag = (*IntFlag)(nil)
@@ -44,27 +47,35 @@ type IntFlag FlagBase[int]
 // pIntFlag is an alias for a pointer to FlagBase[int].
 type pIntFlag = *FlagBase[int]
 
+// Register registers the flag with the given cobra command.
+// It panics if the flag cannot be registered; use RegisterE to handle the error instead.
 func (s *IntFlag) Register(cmd *cobra.Command) {
-	var flags *pflag.FlagSet
-	if s.Persistent {
-		flags = cmd.PersistentFlags()
-	} else {
-		flags = cmd.Flags()
-	}
-	if s.Shorthand == "" {
-		flags.Int(s.Name, s.Value, s.Usage)
-	} else {
+	noError(s.RegisterE(cmd))
+}
+
+// RegisterE registers the flag with the given cobra command.
+// It returns an error if a flag with the same name or shorthand is already registered,
+// if the shorthand is invalid, or if the flag cannot be marked as required.
+func (s *IntFlag) RegisterE(cmd *cobra.Command) error {
+	return pIntFlag(s).register(cmd, s, backend.GetInt, func(flags *pflag.FlagSet) {
 		flags.IntP(s.Name, s.Shorthand, s.Value, s.Usage)
-	}
-	if s.Required {
-		noError(cmd.MarkFlagRequired(s.Name))
-	}
-	s.flag = flags.Lookup(s.Name)
+	})
+}
 
-	if s.flag.Annotations == nil {
-		s.flag.Annotations = make(map[string][]string)
-	}
-	s.flag.Annotations[viperKeyAnnotation] = []string{pIntFlag(s).getViperKey()}
+// Clone returns an independent copy of the flag definition, without any registration
+// or binding state, so that the same specification can be attached to another command.
+func (s *IntFlag) Clone() *IntFlag {
+	return (*IntFlag)(pIntFlag(s).clone())
+}
+
+// RegisterOn registers a separate clone of the flag on each of the given commands
+// and returns the clones in the same order. Values must be read from the clone
+// registered on the command of interest: each clone reads the value given for its own
+// command, while the environment variable and configuration key are shared. Values set
+// on the Viper instance directly, e.g. with ViperFor(cmd).Set, do not reach clones.
+// It panics if a clone cannot be registered.
+func (s *IntFlag) RegisterOn(cmds ...*cobra.Command) []*IntFlag {
+	return registerClones(s.Clone, cmds)
 }
 
 // GetInt retrieves the current integer value of the flag.
@@ -77,13 +88,7 @@ func (s *IntFlag) Register(cmd *cobra.Command) {
 //
 // Returns the integer value, which may be the default value if the flag was not set.
 func (s *IntFlag) GetInt() int {
-	viperKey := pIntFlag(s).getViperKey()
-
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
-
-	return viper.GetInt(viperKey)
+	return pIntFlag(s).get(backend.GetInt)
 }
 
 // GetIntE retrieves the current integer value of the flag with validation.
@@ -92,26 +97,48 @@ func (s *IntFlag) GetInt() int {
 //
 // Validation behavior:
 //   - If ValidateFunc is set, it is called with the integer value
-//   - If ValidateFunc is nil but Validator is set, Validator.Validate() is called
+//   - If Validator is set, Validator.Validate() is called, after a successful
+//     ValidateFunc by default (see ValidationMode)
 //   - If neither is set, no validation is performed
 //
+// Values that are not integers, e.g. MYAPP_PORT=abc or port: 1.5 in a configuration
+// file, are reported with an error naming the flag and the offending value, while
+// GetInt returns 0 (or the truncated number) for them.
+//
 // Returns:
 //   - On success: the integer value and nil error
+//   - On a value that is not an integer: 0 and the parse error
 //   - On validation failure: 0 and the validation error
 //
 // Use this method when you need to ensure the flag value meets your validation criteria.
 func (s *IntFlag) GetIntE() (int, error) {
-	viperKey := pIntFlag(s).getViperKey()
-
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
+	return pIntFlag(s).getE(backend.GetInt)
+}
 
-	v := viper.GetInt(viperKey)
+// MustInt retrieves the current value of the flag like GetIntE, but panics
+// if the value is not an integer or validation fails. The panic value is an error naming
+// the flag, the offending value and its source, which makes it suitable for wiring in
+// main() where returning errors would just be boilerplate.
+func (s *IntFlag) MustInt() int {
+	return pIntFlag(s).must(backend.GetInt)
+}
 
-	if result, err := pIntFlag(s).validate(v); err != nil {
-		return result, err
+// toInt64 converts a raw value stored in Viper to an integer. Unlike Viper's conversion,
+// which yields 0 for such values, it fails for strings that are not integers and for
+// numbers with a fractional part.
+func toInt64(raw any) (int64, error) {
+	if f, ok := raw.(float64); ok && f != math.Trunc(f) {
+		return 0, fmt.Errorf("invalid integer %v", raw)
 	}
+	n, err := cast.ToInt64E(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer %q", fmt.Sprint(raw))
+	}
+	return n, nil
+}
 
-	return v, nil
+// isInt reports raw values that are not integers, see toInt64.
+func isInt(raw any) error {
+	_, err := toInt64(raw)
+	return err
 }
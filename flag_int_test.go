@@ -238,3 +238,47 @@ func TestIntFlag_ViperKey_HappyPath(t *testing.T) {
 		})
 	}
 }
+
+func TestIntFlag_InvalidValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string
+		config string
+		value  int
+		err    string
+	}{
+		{name: "zero", env: "0", value: 0},
+		{name: "env", env: "abc", value: 0, err: `flag "port": invalid integer "abc"`},
+		{name: "config", config: "port: 1.5\n", value: 1, err: `flag "port": invalid integer 1.5`},
+		{name: "config string", config: "port: eighty\n", value: 0, err: `flag "port": invalid integer "eighty"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			if tt.env != "" {
+				c.Setenv("PARSEAPP_PORT", tt.env)
+			}
+			dir := c.TempDir()
+			writeConfig(c, dir, "config.yaml", tt.config)
+
+			cmd := newCobraCommand()
+			flag := &cobraflags.IntFlag{Name: "port", Value: 8080}
+			flag.Register(cmd)
+			cobraflags.CobraOnInitialize("PARSEAPP", cmd, cobraflags.WithConfigFile("config", "yaml", dir))
+			c.Assert(cmd.Execute(), qt.IsNil)
+
+			c.Assert(flag.GetInt(), qt.Equals, tt.value)
+
+			value, err := flag.GetIntE()
+			if tt.err == "" {
+				c.Assert(err, qt.IsNil)
+				c.Assert(value, qt.Equals, tt.value)
+				return
+			}
+			c.Assert(err, qt.ErrorMatches, tt.err)
+			c.Assert(value, qt.Equals, 0)
+			c.Assert(func() { flag.MustInt() }, qt.PanicMatches, "cobraflags: "+tt.err)
+		})
+	}
+}
@@ -3,7 +3,6 @@ package cobraflags
 import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 )
 
 var _ Flag = (*StringFlag)(nil)
@@ -44,27 +43,35 @@ type StringFlag FlagBase[string]
 // pStringFlag is an alias for a pointer to FlagBase[string].
 type pStringFlag = *FlagBase[string]
 
+// Register registers the flag with the given cobra command.
+// It panics if the flag cannot be registered; use RegisterE to handle the error instead.
 func (s *StringFlag) Register(cmd *cobra.Command) {
-	var flags *pflag.FlagSet
-	if s.Persistent {
-		flags = cmd.PersistentFlags()
-	} else {
-		flags = cmd.Flags()
-	}
-	if s.Shorthand == "" {
-		flags.String(s.Name, s.Value, s.Usage)
-	} else {
+	noError(s.RegisterE(cmd))
+}
+
+// RegisterE registers the flag with the given cobra command.
+// It returns an error if a flag with the same name or shorthand is already registered,
+// if the shorthand is invalid, or if the flag cannot be marked as required.
+func (s *StringFlag) RegisterE(cmd *cobra.Command) error {
+	return pStringFlag(s).register(cmd, s, backend.GetString, func(flags *pflag.FlagSet) {
 		flags.StringP(s.Name, s.Shorthand, s.Value, s.Usage)
-	}
-	if s.Required {
-		noError(cmd.MarkFlagRequired(s.Name))
-	}
-	s.flag = flags.Lookup(s.Name)
+	})
+}
 
-	if s.flag.Annotations == nil {
-		s.flag.Annotations = make(map[string][]string)
-	}
-	s.flag.Annotations[viperKeyAnnotation] = []string{pStringFlag(s).getViperKey()}
+// Clone returns an independent copy of the flag definition, without any registration
+// or binding state, so that the same specification can be attached to another command.
+func (s *StringFlag) Clone() *StringFlag {
+	return (*StringFlag)(pStringFlag(s).clone())
+}
+
+// RegisterOn registers a separate clone of the flag on each of the given commands
+// and returns the clones in the same order. Values must be read from the clone
+// registered on the command of interest: each clone reads the value given for its own
+// command, while the environment variable and configuration key are shared. Values set
+// on the Viper instance directly, e.g. with ViperFor(cmd).Set, do not reach clones.
+// It panics if a clone cannot be registered.
+func (s *StringFlag) RegisterOn(cmds ...*cobra.Command) []*StringFlag {
+	return registerClones(s.Clone, cmds)
 }
 
 // GetString retrieves the current string value of the flag.
@@ -77,13 +84,7 @@ func (s *StringFlag) Register(cmd *cobra.Command) {
 //
 // Returns the string value, which may be the default value if the flag was not set.
 func (s *StringFlag) GetString() string {
-	viperKey := pStringFlag(s).getViperKey()
-
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
-
-	return viper.GetString(viperKey)
+	return pStringFlag(s).get(backend.GetString)
 }
 
 // GetStringE retrieves the current string value of the flag with validation.
@@ -92,7 +93,8 @@ func (s *StringFlag) GetString() string {
 //
 // Validation behavior:
 //   - If ValidateFunc is set, it is called with the string value
-//   - If ValidateFunc is nil but Validator is set, Validator.Validate() is called
+//   - If Validator is set, Validator.Validate() is called, after a successful
+//     ValidateFunc by default (see ValidationMode)
 //   - If neither is set, no validation is performed
 //
 // Returns:
@@ -101,17 +103,13 @@ func (s *StringFlag) GetString() string {
 //
 // Use this method when you need to ensure the flag value meets your validation criteria.
 func (s *StringFlag) GetStringE() (string, error) {
-	viperKey := pStringFlag(s).getViperKey()
-
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
-
-	v := viper.GetString(viperKey)
-
-	if result, err := pStringFlag(s).validate(v); err != nil {
-		return result, err
-	}
+	return pStringFlag(s).getE(backend.GetString)
+}
 
-	return v, nil
+// MustString retrieves the current value of the flag like GetStringE, but panics
+// if validation fails. The panic value is an error naming the flag, the offending value
+// and its source, which makes it suitable for wiring in main() where returning errors
+// would just be boilerplate.
+func (s *StringFlag) MustString() string {
+	return pStringFlag(s).must(backend.GetString)
 }
@@ -1,9 +1,10 @@
 package cobraflags
 
 import (
+	"slices"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 )
 
 var _ Flag = (*StringSliceFlag)(nil)
@@ -22,7 +23,11 @@ var _ Flag = (*StringSliceFlag)(nil)
 // String slice flags accept multiple values in several ways:
 //   - Multiple flag instances: --item value1 --item value2
 //   - Comma-separated values: --item value1,value2,value3
-//   - Environment variables as comma-separated strings
+//   - Environment variables as comma-separated strings, or as JSON arrays
+//
+// An explicitly empty value overrides a non-empty default with an empty slice:
+// --tags= or --tags "" on the command line, MYAPP_TAGS='[]' in the environment
+// (or MYAPP_TAGS="" with AllowEmptyEnv) and tags: [] in a configuration file.
 //
 // Example usage:
 //
@@ -48,27 +53,37 @@ type StringSliceFlag FlagBase[[]string]
 // pStringSliceFlag is an alias for a pointer to FlagBase[[]string].
 type pStringSliceFlag = *FlagBase[[]string]
 
+// Register registers the flag with the given cobra command.
+// It panics if the flag cannot be registered; use RegisterE to handle the error instead.
 func (s *StringSliceFlag) Register(cmd *cobra.Command) {
-	var flags *pflag.FlagSet
-	if s.Persistent {
-		flags = cmd.PersistentFlags()
-	} else {
-		flags = cmd.Flags()
-	}
-	if s.Shorthand == "" {
-		flags.StringSlice(s.Name, s.Value, s.Usage)
-	} else {
+	noError(s.RegisterE(cmd))
+}
+
+// RegisterE registers the flag with the given cobra command.
+// It returns an error if a flag with the same name or shorthand is already registered,
+// if the shorthand is invalid, or if the flag cannot be marked as required.
+func (s *StringSliceFlag) RegisterE(cmd *cobra.Command) error {
+	return pStringSliceFlag(s).register(cmd, s, backend.GetStringSlice, func(flags *pflag.FlagSet) {
 		flags.StringSliceP(s.Name, s.Shorthand, s.Value, s.Usage)
-	}
-	if s.Required {
-		noError(cmd.MarkFlagRequired(s.Name))
-	}
-	s.flag = flags.Lookup(s.Name)
+	})
+}
+
+// Clone returns an independent copy of the flag definition, without any registration
+// or binding state, so that the same specification can be attached to another command.
+func (s *StringSliceFlag) Clone() *StringSliceFlag {
+	c := (*StringSliceFlag)(pStringSliceFlag(s).clone())
+	c.Value = slices.Clone(s.Value)
+	return c
+}
 
-	if s.flag.Annotations == nil {
-		s.flag.Annotations = make(map[string][]string)
-	}
-	s.flag.Annotations[viperKeyAnnotation] = []string{pStringSliceFlag(s).getViperKey()}
+// RegisterOn registers a separate clone of the flag on each of the given commands
+// and returns the clones in the same order. Values must be read from the clone
+// registered on the command of interest: each clone reads the value given for its own
+// command, while the environment variable and configuration key are shared. Values set
+// on the Viper instance directly, e.g. with ViperFor(cmd).Set, do not reach clones.
+// It panics if a clone cannot be registered.
+func (s *StringSliceFlag) RegisterOn(cmds ...*cobra.Command) []*StringSliceFlag {
+	return registerClones(s.Clone, cmds)
 }
 
 // GetStringSlice retrieves the current string slice value of the flag.
@@ -80,14 +95,12 @@ func (s *StringSliceFlag) Register(cmd *cobra.Command) {
 // validation to be executed.
 //
 // Returns the string slice value, which may be the default value if the flag was not set.
+//
+// The returned slice may be shared with other callers, since values are cached once the
+// command runs (see InvalidateCache), and must not be modified. Use slices.Clone to get
+// a copy to modify. This keeps repeated reads, e.g. in loops, free of allocations.
 func (s *StringSliceFlag) GetStringSlice() []string {
-	viperKey := pStringSliceFlag(s).getViperKey()
-
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
-
-	return viper.GetStringSlice(viperKey)
+	return pStringSliceFlag(s).get(backend.GetStringSlice)
 }
 
 // GetStringSliceE retrieves the current string slice value of the flag with validation.
@@ -96,26 +109,25 @@ func (s *StringSliceFlag) GetStringSlice() []string {
 //
 // Validation behavior:
 //   - If ValidateFunc is set, it is called with the string slice value
-//   - If ValidateFunc is nil but Validator is set, Validator.Validate() is called
+//   - If Validator is set, Validator.Validate() is called, after a successful
+//     ValidateFunc by default (see ValidationMode)
 //   - If neither is set, no validation is performed
 //
 // Returns:
 //   - On success: the string slice value and nil error
 //   - On validation failure: nil slice and the validation error
 //
+// Like the slice returned by GetStringSlice, the returned slice must not be modified.
+//
 // Use this method when you need to ensure the flag value meets your validation criteria.
 func (s *StringSliceFlag) GetStringSliceE() ([]string, error) {
-	viperKey := pStringSliceFlag(s).getViperKey()
-
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
-
-	v := viper.GetStringSlice(viperKey)
-
-	if result, err := pStringSliceFlag(s).validate(v); err != nil {
-		return result, err
-	}
+	return pStringSliceFlag(s).getE(backend.GetStringSlice)
+}
 
-	return v, nil
+// MustStringSlice retrieves the current value of the flag like GetStringSliceE, but panics
+// if validation fails. The panic value is an error naming the flag, the offending value
+// and its source, which makes it suitable for wiring in main() where returning errors
+// would just be boilerplate.
+func (s *StringSliceFlag) MustStringSlice() []string {
+	return pStringSliceFlag(s).must(backend.GetStringSlice)
 }
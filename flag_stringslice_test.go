@@ -251,3 +251,47 @@ func TestStringSliceFlag_ViperKey_HappyPath(t *testing.T) {
 		})
 	}
 }
+
+func TestStringSliceFlag_ExplicitEmpty(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		setEnv bool
+		env    string
+		config string
+		opts   []cobraflags.InitOption
+		want   []string
+	}{
+		{name: "not set", want: []string{"default"}},
+		{name: "flag with equals sign", args: []string{"--items="}, want: []string{}},
+		{name: "flag with empty argument", args: []string{"--items", ""}, want: []string{}},
+		{name: "empty env", setEnv: true, want: []string{"default"}},
+		{name: "empty env allowed", setEnv: true, opts: []cobraflags.InitOption{cobraflags.WithAllowEmptyEnv()}, want: []string{}},
+		{name: "empty JSON array in env", setEnv: true, env: "[]", want: []string{}},
+		{name: "empty list in config", config: "items: []\n", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			if tt.setEnv {
+				c.Setenv("EMPTYSLICEAPP_ITEMS", tt.env)
+			}
+			opts := tt.opts
+			if tt.config != "" {
+				dir := c.TempDir()
+				writeConfig(c, dir, "config.yaml", tt.config)
+				opts = append(opts, cobraflags.WithConfigFile("config", "yaml", dir))
+			}
+
+			cmd := newCobraCommand()
+			flag := &cobraflags.StringSliceFlag{Name: "items", Value: []string{"default"}}
+			flag.Register(cmd)
+			cobraflags.CobraOnInitialize("EMPTYSLICEAPP", cmd, opts...)
+
+			cmd.SetArgs(tt.args)
+			c.Assert(cmd.Execute(), qt.IsNil)
+			c.Assert(flag.GetStringSlice(), qt.DeepEquals, tt.want)
+		})
+	}
+}
@@ -1,10 +1,11 @@
 package cobraflags
 
 import (
-	"github.com/spf13/cast"
+	"fmt"
+	"math"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 )
 
 var _ Flag = (*Uint8Flag)(nil)
@@ -20,8 +21,10 @@ var _ Flag = (*Uint8Flag)(nil)
 //   - Custom Viper keys for configuration binding
 //   - Validation with custom functions or validators
 //
-// Uint8 flags accept values in the range 0-255. Values outside this range
-// will be automatically clamped by the underlying cast.ToUint8() function.
+// Uint8 flags accept values in the range 0-255. The command line rejects values outside
+// this range, but environment variables and configuration files may still provide them:
+// GetUint8 clamps such values to the range, while GetUint8E and MustUint8 report them
+// as errors.
 //
 // Example usage:
 //
@@ -47,27 +50,35 @@ type Uint8Flag FlagBase[uint8]
 // pUint8Flag is an alias for a pointer to FlagBase[uint8].
 type pUint8Flag = *FlagBase[uint8]
 
+// Register registers the flag with the given cobra command.
+// It panics if the flag cannot be registered; use RegisterE to handle the error instead.
 func (s *Uint8Flag) Register(cmd *cobra.Command) {
-	var flags *pflag.FlagSet
-	if s.Persistent {
-		flags = cmd.PersistentFlags()
-	} else {
-		flags = cmd.Flags()
-	}
-	if s.Shorthand == "" {
-		flags.Uint8(s.Name, s.Value, s.Usage)
-	} else {
+	noError(s.RegisterE(cmd))
+}
+
+// RegisterE registers the flag with the given cobra command.
+// It returns an error if a flag with the same name or shorthand is already registered,
+// if the shorthand is invalid, or if the flag cannot be marked as required.
+func (s *Uint8Flag) RegisterE(cmd *cobra.Command) error {
+	return pUint8Flag(s).register(cmd, s, getUint8, func(flags *pflag.FlagSet) {
 		flags.Uint8P(s.Name, s.Shorthand, s.Value, s.Usage)
-	}
-	if s.Required {
-		noError(cmd.MarkFlagRequired(s.Name))
-	}
-	s.flag = flags.Lookup(s.Name)
+	})
+}
 
-	if s.flag.Annotations == nil {
-		s.flag.Annotations = make(map[string][]string)
-	}
-	s.flag.Annotations[viperKeyAnnotation] = []string{pUint8Flag(s).getViperKey()}
+// Clone returns an independent copy of the flag definition, without any registration
+// or binding state, so that the same specification can be attached to another command.
+func (s *Uint8Flag) Clone() *Uint8Flag {
+	return (*Uint8Flag)(pUint8Flag(s).clone())
+}
+
+// RegisterOn registers a separate clone of the flag on each of the given commands
+// and returns the clones in the same order. Values must be read from the clone
+// registered on the command of interest: each clone reads the value given for its own
+// command, while the environment variable and configuration key are shared. Values set
+// on the Viper instance directly, e.g. with ViperFor(cmd).Set, do not reach clones.
+// It panics if a clone cannot be registered.
+func (s *Uint8Flag) RegisterOn(cmds ...*cobra.Command) []*Uint8Flag {
+	return registerClones(s.Clone, cmds)
 }
 
 // GetUint8 retrieves the current uint8 value of the flag.
@@ -78,18 +89,12 @@ func (s *Uint8Flag) Register(cmd *cobra.Command) {
 // Note: This method does NOT perform validation. Use GetUint8E() if you need
 // validation to be executed.
 //
-// The value is retrieved as uint16 from Viper and then cast to uint8 using
-// spf13/cast.ToUint8(), which handles overflow by clamping to the uint8 range.
+// Values outside the uint8 range, e.g. MYAPP_LEVEL=300, are clamped to 0 or 255.
+// Use GetUint8E to have them reported instead.
 //
 // Returns the uint8 value, which may be the default value if the flag was not set.
 func (s *Uint8Flag) GetUint8() uint8 {
-	viperKey := pUint8Flag(s).getViperKey()
-
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
-
-	return cast.ToUint8(viper.GetUint16(viperKey))
+	return pUint8Flag(s).get(getUint8)
 }
 
 // GetUint8E retrieves the current uint8 value of the flag with validation.
@@ -98,30 +103,47 @@ func (s *Uint8Flag) GetUint8() uint8 {
 //
 // Validation behavior:
 //   - If ValidateFunc is set, it is called with the uint8 value
-//   - If ValidateFunc is nil but Validator is set, Validator.Validate() is called
+//   - If Validator is set, Validator.Validate() is called, after a successful
+//     ValidateFunc by default (see ValidationMode)
 //   - If neither is set, no validation is performed
 //
-// The value is retrieved as uint16 from Viper and then cast to uint8 using
-// spf13/cast.ToUint8(), which handles overflow by clamping to the uint8 range.
+// Values outside the uint8 range are not clamped like by GetUint8, but reported
+// with an error naming the flag and the offending value, as are values that are not
+// integers, e.g. MYAPP_LEVEL=high.
 //
 // Returns:
 //   - On success: the uint8 value and nil error
+//   - On a value that is not an integer: 0 and the parse error
+//   - On a value out of range: 0 and the range error
 //   - On validation failure: 0 and the validation error
 //
 // Use this method when you need to ensure the flag value meets your validation criteria.
 func (s *Uint8Flag) GetUint8E() (uint8, error) {
-	viperKey := pUint8Flag(s).getViperKey()
+	return pUint8Flag(s).getE(getUint8)
+}
 
-	s.bindOnce.Do(func() {
-		noError(viper.BindPFlag(viperKey, s.flag))
-	})
+// MustUint8 retrieves the current value of the flag like GetUint8E, but panics
+// if the value is not an integer, is out of range or validation fails. The panic value
+// is an error naming the flag, the offending value and its source, which makes it
+// suitable for wiring in main() where returning errors would just be boilerplate.
+func (s *Uint8Flag) MustUint8() uint8 {
+	return pUint8Flag(s).must(getUint8)
+}
 
-	u16 := viper.GetUint16(viperKey)
-	v := cast.ToUint8(u16)
+// getUint8 reads the value as int64 from the backend and clamps it to the uint8 range.
+func getUint8(b backend, key string) uint8 {
+	return uint8(min(max(b.GetInt64(key), 0), math.MaxUint8))
+}
 
-	if result, err := pUint8Flag(s).validate(v); err != nil {
-		return result, err
+// uint8InRange reports raw values that are not integers (see toInt64) or are outside
+// the uint8 range.
+func uint8InRange(raw any) error {
+	n, err := toInt64(raw)
+	if err != nil {
+		return err
 	}
-
-	return v, nil
+	if n < 0 || n > math.MaxUint8 {
+		return fmt.Errorf("value %v is out of range for uint8 (0-255)", raw)
+	}
+	return nil
 }
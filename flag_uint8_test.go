@@ -251,3 +251,42 @@ func TestUint8Flag_ViperKey_HappyPath(t *testing.T) {
 		})
 	}
 }
+
+func TestUint8Flag_OutOfRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		value uint8
+		err   string
+	}{
+		{name: "in range", env: "200", value: 200},
+		{name: "too large", env: "300", value: 255, err: `flag "level": value 300 is out of range for uint8 \(0-255\)`},
+		{name: "negative", env: "-1", value: 0, err: `flag "level": value -1 is out of range for uint8 \(0-255\)`},
+		{name: "not a number", env: "high", value: 0, err: `flag "level": invalid integer "high"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			c.Setenv("RANGEAPP_LEVEL", tt.env)
+
+			cmd := newCobraCommand()
+			flag := &cobraflags.Uint8Flag{Name: "level"}
+			flag.Register(cmd)
+			cobraflags.CobraOnInitialize("RANGEAPP", cmd)
+			c.Assert(cmd.Execute(), qt.IsNil)
+
+			c.Assert(flag.GetUint8(), qt.Equals, tt.value)
+
+			value, err := flag.GetUint8E()
+			if tt.err == "" {
+				c.Assert(err, qt.IsNil)
+				c.Assert(value, qt.Equals, tt.value)
+				return
+			}
+			c.Assert(err, qt.ErrorMatches, tt.err)
+			c.Assert(value, qt.Equals, uint8(0))
+			c.Assert(func() { flag.MustUint8() }, qt.PanicMatches, "cobraflags: "+tt.err)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cobraflags
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// NewFlagsCommand adds a hidden "flags" subcommand to root that lists every flag registered
+// through cobraflags in the command tree with its current value, default, source,
+// environment variable and Viper key, for users to diagnose their configuration.
+// Values of secrets are redacted, see NewPrintConfigCommand.
+//
+// The command is returned so that it can be customized, e.g. renamed or unhidden.
+//
+// Example:
+//
+//	cobraflags.NewFlagsCommand(rootCmd)
+//	// $ myapp flags
+//	// COMMAND      FLAG     VALUE  DEFAULT  SOURCE  ENV VAR            VIPER KEY
+//	// myapp        verbose  true   false    env     MYAPP_VERBOSE      verbose
+//	// myapp serve  port     8080   80       config  MYAPP_SERVER_PORT  server.port
+func NewFlagsCommand(root *cobra.Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "flags",
+		Short:  "List all flags with their values and sources",
+		Args:   cobra.NoArgs,
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return printFlags(cmd.OutOrStdout(), root)
+		},
+	}
+	root.AddCommand(cmd)
+	return cmd
+}
+
+// printFlags writes the flags of the tree rooted at root to w as a table.
+func printFlags(w io.Writer, root *cobra.Command) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "COMMAND\tFLAG\tVALUE\tDEFAULT\tSOURCE\tENV VAR\tVIPER KEY"); err != nil {
+		return err
+	}
+
+	var err error
+	walkCommands(root, func(c *cobra.Command) {
+		for _, b := range flagsWithEnv(c) {
+			value, def := b.Value, b.Default
+			if b.secret {
+				value, def = redacted, redacted
+			}
+			if err == nil {
+				_, err = fmt.Fprintf(tw, "%s\t%s\t%v\t%v\t%s\t%s\t%s\n", c.CommandPath(), b.Name, value, def, b.Source, b.EnvVar, b.ViperKey)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,38 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestNewFlagsCommand(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("FLAGSAPP_VERBOSE", "true")
+	c.Setenv("FLAGSAPP_SERVE_TOKEN", "s3cr3t")
+
+	root := &cobra.Command{Use: "flagsapp"}
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+	(&cobraflags.BoolFlag{Name: "verbose", Persistent: true}).Register(root)
+	(&cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80}).Register(serve)
+	(&cobraflags.StringFlag{Name: "token", EnvVar: "FLAGSAPP_SERVE_TOKEN"}).Register(serve)
+	cmd := cobraflags.NewFlagsCommand(root)
+	cobraflags.CobraOnInitialize("FLAGSAPP", root)
+
+	c.Assert(cmd.Hidden, qt.IsTrue)
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"flags"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Equals, `COMMAND         FLAG     VALUE  DEFAULT  SOURCE   ENV VAR               VIPER KEY
+flagsapp        verbose  true   false    env      FLAGSAPP_VERBOSE      verbose
+flagsapp serve  port     80     80       default  FLAGSAPP_SERVER_PORT  server.port
+flagsapp serve  token    ***    ***      env      FLAGSAPP_SERVE_TOKEN  token
+`)
+}
@@ -0,0 +1,121 @@
+package cobraflags
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flagSetCommands maps the flag sets used with RegisterOnFlagSet to the hidden commands
+// standing in for them, so that their flags are bound like those of a command tree.
+var flagSetCommands = make(map[*pflag.FlagSet]*cobra.Command)
+var flagSetCommandsMutex sync.Mutex
+
+// flagSetCommand returns the command standing in for fs, after adding the flags of fs it
+// does not know yet, e.g. those defined with pflag directly.
+func flagSetCommand(fs *pflag.FlagSet) *cobra.Command {
+	flagSetCommandsMutex.Lock()
+	cmd, ok := flagSetCommands[fs]
+	if !ok {
+		cmd = &cobra.Command{Use: fs.Name()}
+		flagSetCommands[fs] = cmd
+	}
+	flagSetCommandsMutex.Unlock()
+
+	fs.VisitAll(func(f *pflag.Flag) {
+		if cmd.Flags().Lookup(f.Name) == nil {
+			cmd.Flags().AddFlag(f)
+		}
+	})
+	return cmd
+}
+
+// RegisterOnFlagSet registers the flags on the pflag.FlagSet fs, for programs and libraries
+// that use pflag without cobra. The flags offer the same typed getters and validation as
+// flags registered on a command; InitFlagSet binds them to the environment and Viper.
+// Persistent has no effect. It returns the first error of registering a flag, see RegisterE.
+//
+// Example:
+//
+//	fs := pflag.NewFlagSet("mytool", pflag.ExitOnError)
+//	portFlag := &cobraflags.IntFlag{Name: "port", Value: 8080}
+//	if err := cobraflags.RegisterOnFlagSet(fs, portFlag); err != nil {
+//		log.Fatal(err)
+//	}
+//	_ = fs.Parse(os.Args[1:])
+//	if err := cobraflags.InitFlagSet("MYTOOL", fs); err != nil { // Reads MYTOOL_PORT
+//		log.Fatal(err)
+//	}
+//	port := portFlag.GetInt()
+func RegisterOnFlagSet(fs *pflag.FlagSet, flags ...Flag) error {
+	cmd := flagSetCommand(fs)
+	for _, flag := range flags {
+		if err := flag.RegisterE(cmd); err != nil {
+			return err
+		}
+		entries := registeredOn(cmd)
+		f, _ := entries[len(entries)-1].base.identity()
+		if fs.Lookup(f.Name) == nil {
+			fs.AddFlag(f)
+		}
+		if cmd.Flags().Lookup(f.Name) == nil {
+			cmd.Flags().AddFlag(f) // Persistent flags.
+		}
+	}
+	return nil
+}
+
+// InitFlagSet does for the flag set fs what CobraOnInitialize does for a command tree:
+// it reads the configuration files, presets the flags that were not given on the command
+// line from environment variables, and checks the required flags. Call it after fs has
+// been parsed. The flags defined on fs with pflag directly are bound as well. The options
+// are those of CobraOnInitialize, except the ones adding flags, such as WithSetFlag, and
+// EnablePrompting, which apply to commands only.
+//
+// Unlike CobraOnInitialize, InitFlagSet reports errors right away, as CobraOnInitializeE
+// does, and may be called again after fs is parsed anew.
+func InitFlagSet(envPrefix string, fs *pflag.FlagSet, opts ...InitOption) error {
+	if !fs.Parsed() {
+		return errors.New("cobraflags: InitFlagSet requires a parsed flag set")
+	}
+
+	cfg := initConfig{envPrefix: envPrefix, strictEnv: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cmd := flagSetCommand(fs)
+
+	setupMutex.Lock()
+	initConfigsMutex.Lock()
+	initConfigs[cmd] = &cfg
+	initConfigsMutex.Unlock()
+	if cfg.viper != nil {
+		setViper(cmd, cfg.viper)
+	}
+	if cfg.caseSensitive {
+		setCaseSensitive(cmd)
+	}
+	setupMutex.Unlock()
+
+	if err := initializeE(envPrefix, cmd, &cfg); err != nil {
+		return err
+	}
+	if err := checkExperimental(cmd); err != nil {
+		return err
+	}
+	if err := resolveDefaults(cmd); err != nil {
+		return err
+	}
+	if err := checkRequiredFlags(cmd); err != nil {
+		return err
+	}
+	if err := checkDependencies(cmd); err != nil {
+		return err
+	}
+	warnDeprecated(cmd)
+	settleStores(cmd)
+	notifySources(cmd)
+	return nil
+}
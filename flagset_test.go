@@ -0,0 +1,68 @@
+package cobraflags_test
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/pflag"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestRegisterOnFlagSet(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("FSAPP_PORT", "9090")
+	c.Setenv("FSAPP_DEBUG", "true")
+	c.Setenv("FSAPP_HOST", "env.example.com")
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "server:\n  name: from-config\n")
+
+	fs := pflag.NewFlagSet("fsapp", pflag.ContinueOnError)
+	debug := fs.Bool("debug", false, "Debug output")
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 8080, Validator: cobraflags.OneOf(8080, 9090)}
+	hostFlag := &cobraflags.StringFlag{Name: "host", Shorthand: "H", Value: "localhost"}
+	nameFlag := &cobraflags.StringFlag{Name: "name", ViperKey: "server.name", Persistent: true}
+	c.Assert(cobraflags.RegisterOnFlagSet(fs, portFlag, hostFlag, nameFlag), qt.IsNil)
+	c.Assert(fs.Lookup("port"), qt.Not(qt.IsNil))
+
+	c.Assert(fs.Parse([]string{"-H", "cli.example.com"}), qt.IsNil)
+	c.Assert(cobraflags.InitFlagSet("FSAPP", fs, cobraflags.WithConfigFile("config", "yaml", dir)), qt.IsNil)
+
+	port, err := portFlag.GetIntE()
+	c.Assert(err, qt.IsNil)
+	c.Assert(port, qt.Equals, 9090)
+	c.Assert(hostFlag.GetString(), qt.Equals, "cli.example.com")
+	c.Assert(nameFlag.GetString(), qt.Equals, "from-config")
+	c.Assert(*debug, qt.IsTrue) // Plain pflag flags are preset as well.
+}
+
+func TestRegisterOnFlagSet_Duplicate(t *testing.T) {
+	c := qt.New(t)
+
+	fs := pflag.NewFlagSet("fsapp", pflag.ContinueOnError)
+	fs.StringP("host", "H", "", "Host")
+	err := cobraflags.RegisterOnFlagSet(fs, &cobraflags.StringFlag{Name: "host"})
+	c.Assert(errors.Is(err, cobraflags.ErrDuplicateFlag), qt.IsTrue)
+	err = cobraflags.RegisterOnFlagSet(fs, &cobraflags.StringFlag{Name: "hostname", Shorthand: "H"})
+	c.Assert(errors.Is(err, cobraflags.ErrInvalidShorthand), qt.IsTrue)
+}
+
+func TestInitFlagSet_Errors(t *testing.T) {
+	c := qt.New(t)
+
+	fs := pflag.NewFlagSet("fsapp", pflag.ContinueOnError)
+	c.Assert(cobraflags.RegisterOnFlagSet(fs, &cobraflags.StringFlag{Name: "token", Required: true}), qt.IsNil)
+	c.Assert(cobraflags.InitFlagSet("FSAPP", fs), qt.ErrorMatches, "cobraflags: InitFlagSet requires a parsed flag set")
+
+	c.Assert(fs.Parse(nil), qt.IsNil)
+	c.Assert(cobraflags.InitFlagSet("FSAPP", fs), qt.ErrorMatches,
+		`required flag "token" not set, use --token, the environment variable FSAPP_TOKEN or the config key "token"`)
+
+	c.Setenv("FSAPP_COUNT", "abc")
+	fs = pflag.NewFlagSet("fsapp", pflag.ContinueOnError)
+	c.Assert(cobraflags.RegisterOnFlagSet(fs, &cobraflags.IntFlag{Name: "count"}), qt.IsNil)
+	c.Assert(fs.Parse(nil), qt.IsNil)
+	c.Assert(cobraflags.InitFlagSet("FSAPP", fs), qt.ErrorMatches, `.*FSAPP_COUNT.*`)
+}
@@ -0,0 +1,127 @@
+package cobraflags
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// TableFormat is an output format of GenFlagTable.
+type TableFormat string
+
+const (
+	TableMarkdown TableFormat = "markdown" // GitHub flavored Markdown tables
+	TableHTML     TableFormat = "html"     // HTML tables
+)
+
+// flagTableHeader lists the columns of the tables written by GenFlagTable.
+var flagTableHeader = []string{"Flag", "Shorthand", "Type", "Default", "Env Var", "Description"}
+
+// GenFlagTable writes a reference table of the flags registered on cmd and each of its
+// subcommands, with name, shorthand, type, default, environment variable and description,
+// so that the flag documentation of a README is generated rather than maintained by hand.
+// Every command with flags gets a heading with its command path followed by its table.
+// Hidden flags are left out, and the defaults of secrets are redacted (see FlagBase.Sensitive).
+//
+// Example output (TableMarkdown):
+//
+//	### myapp serve
+//
+//	| Flag | Shorthand | Type | Default | Env Var | Description |
+//	| --- | --- | --- | --- | --- | --- |
+//	| `--port` | `-p` | int | `8080` | `MYAPP_PORT` | Port to listen on |
+func GenFlagTable(cmd *cobra.Command, w io.Writer, format TableFormat) error {
+	if format != TableMarkdown && format != TableHTML {
+		return fmt.Errorf("invalid table format %q", format)
+	}
+
+	var sb strings.Builder
+	walkCommands(cmd, func(c *cobra.Command) {
+		var rows [][]string
+		for _, b := range flagsWithEnv(c) {
+			if b.Hidden {
+				continue
+			}
+			def := b.defaultValue
+			if b.secret && def != "" {
+				def = redacted
+			}
+			rows = append(rows, []string{
+				"--" + b.Name,
+				prefixed("-", b.Shorthand),
+				b.Type,
+				def,
+				b.EnvVar,
+				b.Usage,
+			})
+		}
+		if len(rows) == 0 {
+			return
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		if format == TableHTML {
+			writeHTMLTable(&sb, c.CommandPath(), rows)
+		} else {
+			writeMarkdownTable(&sb, c.CommandPath(), rows)
+		}
+	})
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeMarkdownTable writes a Markdown heading and table. The flag, shorthand, default and
+// environment variable columns are code-formatted.
+func writeMarkdownTable(sb *strings.Builder, title string, rows [][]string) {
+	fmt.Fprintf(sb, "### %s\n\n", title)
+	fmt.Fprintf(sb, "| %s |\n", strings.Join(flagTableHeader, " | "))
+	sb.WriteString(strings.Repeat("| --- ", len(flagTableHeader)) + "|\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			switch {
+			case cell == "":
+			case i == 0 || i == 1 || i == 3 || i == 4:
+				cells[i] = "`" + markdownEscape(cell) + "`"
+			default:
+				cells[i] = markdownEscape(cell)
+			}
+		}
+		fmt.Fprintf(sb, "| %s |\n", strings.Join(cells, " | "))
+	}
+}
+
+// writeHTMLTable writes an HTML heading and table.
+func writeHTMLTable(sb *strings.Builder, title string, rows [][]string) {
+	fmt.Fprintf(sb, "<h3>%s</h3>\n<table>\n<thead>\n<tr>", html.EscapeString(title))
+	for _, h := range flagTableHeader {
+		fmt.Fprintf(sb, "<th>%s</th>", h)
+	}
+	sb.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for _, row := range rows {
+		sb.WriteString("<tr>")
+		for _, cell := range row {
+			fmt.Fprintf(sb, "<td>%s</td>", html.EscapeString(cell))
+		}
+		sb.WriteString("</tr>\n")
+	}
+	sb.WriteString("</tbody>\n</table>\n")
+}
+
+// markdownEscape escapes the characters that would break a Markdown table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// prefixed returns prefix+s, or "" if s is empty.
+func prefixed(prefix, s string) string {
+	if s == "" {
+		return ""
+	}
+	return prefix + s
+}
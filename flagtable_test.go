@@ -0,0 +1,56 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestGenFlagTable_Markdown(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.GenFlagTable(newDeployCommand(), &buf, cobraflags.TableMarkdown), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "### deployapp\n"+
+		"\n"+
+		"| Flag | Shorthand | Type | Default | Env Var | Description |\n"+
+		"| --- | --- | --- | --- | --- | --- |\n"+
+		"| `--log-level` |  | string | `info` | `DEPLOYAPP_LOG_LEVEL` | Log level |\n"+
+		"\n"+
+		"### deployapp serve\n"+
+		"\n"+
+		"| Flag | Shorthand | Type | Default | Env Var | Description |\n"+
+		"| --- | --- | --- | --- | --- | --- |\n"+
+		"| `--max-conns` |  | int | `100` | `DEPLOYAPP_SERVER_MAX_CONNS` | Maximum connections |\n"+
+		"| `--tags` |  | stringSlice | `a,b` | `DEPLOYAPP_TAGS` | Tags |\n"+
+		"| `--greeting` |  | string | `hello world` | `DEPLOYAPP_GREETING` | Greeting |\n"+
+		"| `--api-token` |  | string |  | `DEPLOYAPP_API_TOKEN` | API token |\n")
+}
+
+func TestGenFlagTable_HTML(t *testing.T) {
+	c := qt.New(t)
+
+	root := &cobra.Command{Use: "app"}
+	(&cobraflags.StringFlag{Name: "format", Shorthand: "f", Usage: "Output <format> | template", Value: "a&b"}).Register(root)
+	(&cobraflags.BoolFlag{Name: "internal"}).Register(root)
+	c.Assert(root.Flags().MarkHidden("internal"), qt.IsNil)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.GenFlagTable(root, &buf, cobraflags.TableHTML), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "<h3>app</h3>\n<table>\n<thead>\n"+
+		"<tr><th>Flag</th><th>Shorthand</th><th>Type</th><th>Default</th><th>Env Var</th><th>Description</th></tr>\n"+
+		"</thead>\n<tbody>\n"+
+		"<tr><td>--format</td><td>-f</td><td>string</td><td>a&amp;b</td><td>FORMAT</td><td>Output &lt;format&gt; | template</td></tr>\n"+
+		"</tbody>\n</table>\n")
+}
+
+func TestGenFlagTable_InvalidFormat(t *testing.T) {
+	c := qt.New(t)
+
+	err := cobraflags.GenFlagTable(&cobra.Command{Use: "app"}, &bytes.Buffer{}, "pdf")
+	c.Assert(err, qt.ErrorMatches, `invalid table format "pdf"`)
+}
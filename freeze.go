@@ -0,0 +1,69 @@
+package cobraflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Freeze snapshots the effective values of all flags registered through cobraflags
+// on cmd and its subcommands. From then on, Get and GetE calls (and FlagsOf) return
+// the snapshot, so later changes to environment variables or to the Viper instance
+// are invisible. This gives long-running daemons a stable configuration.
+//
+// Freeze is typically called once execution has begun, e.g. from PersistentPreRun
+// or Run, after all sources have been applied. Call Unfreeze to opt back into
+// live values, e.g. before reloading the configuration.
+//
+// Example:
+//
+//	rootCmd.PersistentPreRun = func(cmd *cobra.Command, _ []string) {
+//		cobraflags.Freeze(cmd.Root())
+//	}
+func Freeze(cmd *cobra.Command) {
+	walkCommands(cmd, func(c *cobra.Command) {
+		for _, entry := range registeredOn(c) {
+			entry.base.freeze()
+		}
+	})
+}
+
+// Unfreeze discards the snapshots taken by Freeze for cmd and its subcommands,
+// so that getters read live values again.
+func Unfreeze(cmd *cobra.Command) {
+	walkCommands(cmd, func(c *cobra.Command) {
+		for _, entry := range registeredOn(c) {
+			entry.base.unfreeze()
+		}
+	})
+}
+
+// freeze stores the current value of the flag as its snapshot.
+func (s *FlagBase[T]) freeze() {
+	s.mu.RLock()
+	read := s.read
+	s.mu.RUnlock()
+
+	v := s.get(read)
+
+	s.mu.Lock()
+	s.frozen = &v
+	s.mu.Unlock()
+}
+
+// unfreeze discards the snapshot of the flag.
+func (s *FlagBase[T]) unfreeze() {
+	s.mu.Lock()
+	s.frozen = nil
+	s.mu.Unlock()
+	s.cache.Store(nil)
+	if s.published.Load() != nil {
+		s.publish()
+	}
+}
+
+// isFrozen reports whether the flag currently returns a snapshot.
+func (s *FlagBase[T]) isFrozen() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.frozen != nil
+}
@@ -0,0 +1,66 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestFreeze(t *testing.T) {
+	c := qt.New(t)
+
+	root := &cobra.Command{Use: "daemon"}
+	sub := &cobra.Command{Use: "run", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+
+	levelFlag := &cobraflags.StringFlag{Name: "level", Value: "info", Persistent: true}
+	workersFlag := &cobraflags.IntFlag{Name: "workers", Value: 4}
+	levelFlag.Register(root)
+	workersFlag.Register(sub)
+
+	root.SetArgs([]string{"run", "--workers", "8"})
+	c.Assert(root.Execute(), qt.IsNil)
+
+	cobraflags.Freeze(root)
+
+	v := cobraflags.ViperFor(root)
+	v.Set("level", "debug")
+	v.Set("workers", 16)
+
+	c.Assert(levelFlag.GetString(), qt.Equals, "info")
+	c.Assert(workersFlag.GetInt(), qt.Equals, 8)
+	value, err := workersFlag.GetIntE()
+	c.Assert(err, qt.IsNil)
+	c.Assert(value, qt.Equals, 8)
+	c.Assert(cobraflags.FlagsOf(sub)[0].Value, qt.Equals, 8)
+
+	cobraflags.Unfreeze(root)
+
+	c.Assert(levelFlag.GetString(), qt.Equals, "debug")
+	c.Assert(workersFlag.GetInt(), qt.Equals, 16)
+}
+
+func TestFreeze_Subtree(t *testing.T) {
+	c := qt.New(t)
+
+	root := &cobra.Command{Use: "daemon"}
+	sub := &cobra.Command{Use: "run", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+
+	levelFlag := &cobraflags.StringFlag{Name: "level", Value: "info"}
+	workersFlag := &cobraflags.IntFlag{Name: "workers", Value: 4}
+	levelFlag.Register(root)
+	workersFlag.Register(sub)
+
+	cobraflags.Freeze(sub)
+
+	v := cobraflags.ViperFor(root)
+	v.Set("level", "debug")
+	v.Set("workers", 16)
+
+	c.Assert(levelFlag.GetString(), qt.Equals, "debug")
+	c.Assert(workersFlag.GetInt(), qt.Equals, 4)
+}
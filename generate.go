@@ -0,0 +1,79 @@
+package cobraflags
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envBinding is a flag bound to an environment variable, as emitted by the
+// deployment generators (GenHelmValues, GenHelmEnv, ...).
+type envBinding struct {
+	FlagInfo
+	defaultValue string // the default in environment variable syntax
+	secret       bool   // whether the value is a secret, see isSecret
+}
+
+// envBindings returns the flags registered on cmd and its subcommands that are bound
+// to environment variables, in depth-first order, one per variable.
+func envBindings(cmd *cobra.Command) []envBinding {
+	var bindings []envBinding
+	seen := make(map[string]bool)
+	walkCommands(cmd, func(c *cobra.Command) {
+		for _, b := range flagsWithEnv(c) {
+			if b.EnvVar == "" || seen[b.EnvVar] {
+				continue
+			}
+			seen[b.EnvVar] = true
+			bindings = append(bindings, b)
+		}
+	})
+	return bindings
+}
+
+// flagsWithEnv describes the flags registered on cmd like FlagsOf. The environment variable
+// names are the ones resolved by CobraOnInitialize, or derived the same way if the command
+// tree has not been initialized yet; they are empty for flags excluded from binding.
+func flagsWithEnv(cmd *cobra.Command) []envBinding {
+	var bindings []envBinding
+	for _, entry := range registeredOn(cmd) {
+		f, _ := entry.base.identity()
+		if f == nil {
+			continue
+		}
+
+		cfg := configFor(cmd)
+		info := entry.base.info()
+		if excludedFromEnv(f.Name) || len(f.Annotations[noEnvAnnotation]) > 0 {
+			info.EnvVar = ""
+		} else if info.EnvVar == "" {
+			info.EnvVar, _ = flagEnvVar(cfg.envPrefix, cfg, cmd, f)
+		}
+		bindings = append(bindings, envBinding{
+			FlagInfo:     info,
+			defaultValue: envDefault(f, envSeparatorOf(cfg, f)),
+			secret:       isSecret(info),
+		})
+	}
+	return bindings
+}
+
+// envDefault returns the default value of a flag as it would be given in its environment
+// variable: slices as comma-separated values, or joined with sep if not empty (see
+// WithEnvSeparator), everything else as printed by pflag.
+func envDefault(f *pflag.Flag, sep string) string {
+	if _, ok := f.Value.(pflag.SliceValue); !ok {
+		return f.DefValue
+	}
+	items := strings.Trim(f.DefValue, "[]")
+	if sep == "" || items == "" {
+		return items
+	}
+	values, err := csv.NewReader(strings.NewReader(items)).Read()
+	if err != nil {
+		return items
+	}
+	return strings.Join(values, sep)
+}
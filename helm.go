@@ -0,0 +1,102 @@
+package cobraflags
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// helmConfigKey is the values.yaml key that holds the configuration, see GenHelmValues.
+const helmConfigKey = "config"
+
+// helmSecretNameKey is the values.yaml key naming the Secret of secret flags, see GenHelmValues.
+const helmSecretNameKey = "secretName"
+
+// GenHelmValues writes a Helm values.yaml fragment with the defaults of all flags of cmd and
+// its subcommands that are bound to environment variables, nested under "config" by their
+// Viper keys, in camel case (e.g. config.server.maxConns for server.max-conns). Secrets (see
+// NewPrintConfigCommand) are not listed; instead, "secretName" names the Secret they are read
+// from. GenHelmEnv writes the matching env block of the Deployment.
+//
+// Example output:
+//
+//	config:
+//	  logLevel: info
+//	  server:
+//	    port: 8080
+//	secretName: myapp-secrets
+func GenHelmValues(cmd *cobra.Command, w io.Writer) error {
+	config := make(map[string]any)
+	values := map[string]any{helmConfigKey: config}
+	for _, b := range envBindings(cmd) {
+		if b.secret {
+			values[helmSecretNameKey] = cmd.Root().Name() + "-secrets"
+			continue
+		}
+		setNested(config, helmPath(b.ViperKey), b.Default)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(values); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// GenHelmEnv writes the env block of a Deployment's container that passes the values written
+// by GenHelmValues to the environment variables of the flags, as a Helm template. Secrets are
+// read from the Secret named by secretName, with the variable names as keys.
+//
+// Example output:
+//
+//	env:
+//	  - name: MYAPP_LOG_LEVEL
+//	    value: {{ .Values.config.logLevel | quote }}
+//	  - name: MYAPP_API_TOKEN
+//	    valueFrom:
+//	      secretKeyRef:
+//	        name: {{ .Values.secretName }}
+//	        key: MYAPP_API_TOKEN
+func GenHelmEnv(cmd *cobra.Command, w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("env:\n")
+	for _, b := range envBindings(cmd) {
+		fmt.Fprintf(&sb, "  - name: %s\n", b.EnvVar)
+		if b.secret {
+			fmt.Fprintf(&sb, "    valueFrom:\n      secretKeyRef:\n        name: {{ .Values.%s }}\n        key: %s\n",
+				helmSecretNameKey, b.EnvVar)
+			continue
+		}
+
+		ref := ".Values." + helmConfigKey + "." + strings.Join(helmPath(b.ViperKey), ".")
+		if _, ok := b.Default.([]string); ok {
+			fmt.Fprintf(&sb, "    value: {{ join \",\" %s | quote }}\n", ref)
+		} else {
+			fmt.Fprintf(&sb, "    value: {{ %s | quote }}\n", ref)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// helmPath splits a Viper key into the camel-cased path of its values.yaml key.
+func helmPath(viperKey string) []string {
+	parts := strings.Split(viperKey, ".")
+	for i, part := range parts {
+		parts[i] = camelCase(part)
+	}
+	return parts
+}
+
+// camelCase converts a dashed or underscored name to camel case, e.g. "max-conns" to "maxConns".
+func camelCase(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' })
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
@@ -0,0 +1,64 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func newDeployCommand() *cobra.Command {
+	root := &cobra.Command{Use: "deployapp"}
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+
+	(&cobraflags.StringFlag{Name: "log-level", Usage: "Log level", Value: "info", Persistent: true}).Register(root)
+	(&cobraflags.IntFlag{Name: "max-conns", ViperKey: "server.max-conns", Usage: "Maximum connections", Value: 100}).Register(serve)
+	(&cobraflags.StringSliceFlag{Name: "tags", Usage: "Tags", Value: []string{"a", "b"}}).Register(serve)
+	(&cobraflags.StringFlag{Name: "greeting", Usage: "Greeting", Value: "hello world"}).Register(serve)
+	(&cobraflags.StringFlag{Name: "api-token", Usage: "API token"}).Register(serve)
+	cobraflags.CobraOnInitialize("DEPLOYAPP", root)
+	return root
+}
+
+func TestGenHelmValues(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.GenHelmValues(newDeployCommand(), &buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `config:
+  greeting: hello world
+  logLevel: info
+  server:
+    maxConns: 100
+  tags:
+    - a
+    - b
+secretName: deployapp-secrets
+`)
+}
+
+func TestGenHelmEnv(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.GenHelmEnv(newDeployCommand(), &buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `env:
+  - name: DEPLOYAPP_LOG_LEVEL
+    value: {{ .Values.config.logLevel | quote }}
+  - name: DEPLOYAPP_SERVER_MAX_CONNS
+    value: {{ .Values.config.server.maxConns | quote }}
+  - name: DEPLOYAPP_TAGS
+    value: {{ join "," .Values.config.tags | quote }}
+  - name: DEPLOYAPP_GREETING
+    value: {{ .Values.config.greeting | quote }}
+  - name: DEPLOYAPP_API_TOKEN
+    valueFrom:
+      secretKeyRef:
+        name: {{ .Values.secretName }}
+        key: DEPLOYAPP_API_TOKEN
+`)
+}
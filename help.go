@@ -0,0 +1,486 @@
+package cobraflags
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// helpAnnotation marks the commands whose help output is rendered by InstallHelp.
+const helpAnnotation = "cobraflags-help"
+
+// groupAnnotation holds the FlagBase.Group of a flag.
+const groupAnnotation = "cobraflags-group"
+
+// advancedAnnotation marks the flags that are listed by --help-all only, see FlagBase.Advanced.
+const advancedAnnotation = "cobraflags-advanced"
+
+// helpAllFlag is the name of the flag added by WithHelpAll.
+const helpAllFlag = "help-all"
+
+// flagSectionsFunc is the name of the template function rendering the flags, see InstallHelp.
+const flagSectionsFunc = "cobraflagsFlagSections"
+
+// defaultFlagSections is the part of cobra's default usage template that lists the flags,
+// which InstallHelp replaces.
+const defaultFlagSections = `{{if .HasAvailableLocalFlags}}
+
+Flags:
+{{.LocalFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
+
+Global Flags:
+{{.InheritedFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}`
+
+var addTemplateFunc sync.Once
+
+// ColorMode controls when the help output of InstallHelp is colored, see HelpTheme.
+type ColorMode int
+
+const (
+	ColorAuto   ColorMode = iota // Colors if the output is a terminal and NO_COLOR is not set
+	ColorAlways                  // Colors regardless of the output and NO_COLOR
+	ColorNever                   // No colors
+)
+
+// HelpTheme configures the colors of the help output of InstallHelp. The colors are
+// given as ANSI SGR parameters, e.g. "36" for cyan or "1;31" for bold red; empty ones
+// leave the text uncolored.
+type HelpTheme struct {
+	Colors   ColorMode // When to color the output
+	EnvVar   string    // Color of the environment variables
+	Default  string    // Color of the default values
+	Required string    // Color of the required marker
+}
+
+// DefaultHelpTheme is a HelpTheme that works on light and dark terminals.
+var DefaultHelpTheme = HelpTheme{EnvVar: "36", Default: "2", Required: "1;31"}
+
+// HelpOption configures InstallHelp.
+type HelpOption func(*helpConfig)
+
+// WithHelpTheme colors the help output of InstallHelp with the given theme, e.g.
+// DefaultHelpTheme. By default, the output is not colored.
+func WithHelpTheme(theme HelpTheme) HelpOption {
+	return func(c *helpConfig) {
+		c.theme = &theme
+	}
+}
+
+// WithHelpAll adds a persistent --help-all flag to the command passed to InstallHelp, which
+// shows the help like --help, but also lists the advanced flags (see FlagBase.Advanced).
+// The flags after --help-all are not parsed, and nothing is left changed once the help
+// has been shown.
+func WithHelpAll() HelpOption {
+	return func(c *helpConfig) {
+		c.helpAll = &helpAllValue{}
+	}
+}
+
+// helpConfig is the configuration of InstallHelp for a command.
+type helpConfig struct {
+	theme     *HelpTheme
+	helpAll   *helpAllValue // the value of --help-all, see WithHelpAll
+	rendering atomic.Bool   // whether help or usage output is being rendered, see render
+	colors    atomic.Bool   // whether the output being rendered is colored
+}
+
+// helpConfigs stores the configuration of InstallHelp, keyed by the command it was called with.
+var helpConfigs = make(map[*cobra.Command]*helpConfig)
+var helpConfigsMutex sync.Mutex
+
+// helpConfigFor returns the configuration of the closest InstallHelp call made for cmd
+// or one of its ancestors, or nil if there is none.
+func helpConfigFor(cmd *cobra.Command) *helpConfig {
+	helpConfigsMutex.Lock()
+	defer helpConfigsMutex.Unlock()
+
+	for c := cmd; c != nil; c = c.Parent() {
+		if hc, ok := helpConfigs[c]; ok {
+			return hc
+		}
+	}
+	return nil
+}
+
+// render renders help or usage output to w with fn. The outermost rendering decides
+// whether the output is colored, since cobra renders the usage into a buffer for the help.
+func (hc *helpConfig) render(w io.Writer, fn func() error) error {
+	if hc.theme != nil && hc.rendering.CompareAndSwap(false, true) {
+		hc.colors.Store(hc.theme.enabled(w))
+		defer func() {
+			hc.colors.Store(false)
+			hc.rendering.Store(false)
+		}()
+	}
+	return fn()
+}
+
+// enabled reports whether output written to w is colored.
+func (t *HelpTheme) enabled(w io.Writer) bool {
+	switch t.Colors {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// InstallHelp makes the help and usage output of cmd and its subcommands list the flags
+// in aligned columns: the flag and its usage, the environment variable it is bound to,
+// and notes on required, deprecated and experimental flags and on the values allowed by
+// OneOf. Flags are grouped under a heading for each FlagBase.Group, after the ungrouped ones,
+// while the flags inherited from parent commands are listed under "Global Flags".
+//
+// With WithHelpTheme, the environment variables, default values and required markers are
+// colored, if the output is a terminal and the NO_COLOR environment variable is not set.
+//
+// Example output:
+//
+//	Flags:
+//	  -h, --help          help for serve
+//	      --mode string   Run mode (default "dev")   [env: MYAPP_MODE]   one of: dev, prod
+//
+//	Server Flags:
+//	  -p, --port int   Server port (default 8080)   [env: MYAPP_PORT]   required
+//
+// The rest of cobra's default usage template is kept, and the usage text of the flags is
+// no longer decorated with the environment variable (see WithEnvUsageFormat). InstallHelp
+// replaces a custom usage template set on cmd, unless it contains the flag sections of
+// the default template. Hidden flags are left out, and so are the advanced flags unless
+// --help-all is given (see WithHelpAll). Deprecated flags are listed with their deprecation
+// message, although pflag hides them when they are marked deprecated.
+func InstallHelp(cmd *cobra.Command, opts ...HelpOption) {
+	addTemplateFunc.Do(func() {
+		cobra.AddTemplateFunc(flagSectionsFunc, flagSections)
+	})
+
+	hc := &helpConfig{}
+	for _, opt := range opts {
+		opt(hc)
+	}
+	helpConfigsMutex.Lock()
+	helpConfigs[cmd] = hc
+	helpConfigsMutex.Unlock()
+
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[helpAnnotation] = "true"
+
+	if hc.helpAll != nil {
+		cmd.PersistentFlags().VarPF(hc.helpAll, helpAllFlag, "", "help for "+cmd.Name()+", including advanced flags").NoOptDefVal = "true"
+	}
+
+	help := cmd.HelpFunc()
+	cmd.SetHelpFunc(func(c *cobra.Command, args []string) {
+		if hc.helpAll != nil && hc.helpAll.set {
+			defer showAdvanced(c, hc.helpAll)()
+		}
+		_ = hc.render(c.OutOrStdout(), func() error {
+			help(c, args)
+			return nil
+		})
+	})
+	usage := cmd.UsageFunc()
+	cmd.SetUsageFunc(func(c *cobra.Command) error {
+		return hc.render(c.OutOrStderr(), func() error {
+			return usage(c)
+		})
+	})
+
+	tmpl := cmd.UsageTemplate()
+	if !strings.Contains(tmpl, defaultFlagSections) {
+		tmpl = (&cobra.Command{}).UsageTemplate()
+	}
+	cmd.SetUsageTemplate(strings.Replace(tmpl, defaultFlagSections, "{{"+flagSectionsFunc+" .}}", 1))
+}
+
+// helpAllValue is the value of the --help-all flag. Setting it requests the help of the
+// executed command like --help does, without changing the --help flags, and is undone
+// once the help has been shown, see reset.
+type helpAllValue struct {
+	set bool
+}
+
+// String returns whether --help-all is given.
+func (v *helpAllValue) String() string {
+	return strconv.FormatBool(v.set)
+}
+
+// Type returns "bool", so that pflag and cobra treat the flag as a boolean.
+func (v *helpAllValue) Type() string {
+	return "bool"
+}
+
+// Set parses a boolean and, if it is true, requests the help by returning pflag.ErrHelp,
+// which makes cobra stop parsing and show the help of the executed command.
+func (v *helpAllValue) Set(s string) error {
+	set, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	v.set = set
+	if set {
+		return pflag.ErrHelp
+	}
+	return nil
+}
+
+// reset unsets the value once the help has been shown.
+func (v *helpAllValue) reset() {
+	v.set = false
+}
+
+// showAdvanced unhides the advanced flags available to cmd for --help-all and returns
+// a function that hides them again.
+func showAdvanced(cmd *cobra.Command, helpAll *helpAllValue) func() {
+	var shown []*pflag.Flag
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Hidden && len(f.Annotations[advancedAnnotation]) > 0 {
+			f.Hidden = false
+			shown = append(shown, f)
+		}
+	})
+	return func() {
+		for _, f := range shown {
+			f.Hidden = true
+		}
+		helpAll.reset()
+	}
+}
+
+// helpInstalled reports whether InstallHelp was called for cmd or one of its ancestors.
+func helpInstalled(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[helpAnnotation] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// segment is a part of a cell of the flag table, colored with an ANSI SGR color, if any.
+type segment struct {
+	text  string
+	color string
+}
+
+// cell is a cell of the flag table.
+type cell []segment
+
+// width returns the number of characters of the cell, without colors.
+func (c cell) width() int {
+	n := 0
+	for _, s := range c {
+		n += utf8.RuneCountInString(s.text)
+	}
+	return n
+}
+
+// write writes the cell, colored if colors is set.
+func (c cell) write(sb *strings.Builder, colors bool) {
+	for _, s := range c {
+		if colors && s.color != "" && s.text != "" {
+			sb.WriteString("\x1b[" + s.color + "m" + s.text + "\x1b[0m")
+		} else {
+			sb.WriteString(s.text)
+		}
+	}
+}
+
+// flagSections renders the flag sections of the usage template installed by InstallHelp.
+func flagSections(cmd *cobra.Command) string {
+	var sb strings.Builder
+	var groups []string
+	byGroup := make(map[string][]*pflag.Flag)
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if !listed(f) {
+			return
+		}
+		group := groupOf(f)
+		if _, ok := byGroup[group]; !ok && group != "" {
+			groups = append(groups, group)
+		}
+		byGroup[group] = append(byGroup[group], f)
+	})
+	if flags := byGroup[""]; len(flags) > 0 {
+		writeFlagSection(&sb, cmd, "Flags", flags)
+	}
+	for _, group := range groups {
+		writeFlagSection(&sb, cmd, group+" Flags", byGroup[group])
+	}
+
+	var inherited []*pflag.Flag
+	cmd.InheritedFlags().VisitAll(func(f *pflag.Flag) {
+		if listed(f) {
+			inherited = append(inherited, f)
+		}
+	})
+	if len(inherited) > 0 {
+		writeFlagSection(&sb, cmd, "Global Flags", inherited)
+	}
+	return sb.String()
+}
+
+// listed reports whether the flag sections list f. Hidden flags are left out, except the
+// deprecated ones, which pflag hides, so that their deprecation note is shown (see flagNotes).
+func listed(f *pflag.Flag) bool {
+	return !f.Hidden || f.Deprecated != ""
+}
+
+// writeFlagSection writes a heading and the flags under it in aligned columns.
+func writeFlagSection(sb *strings.Builder, cmd *cobra.Command, title string, flags []*pflag.Flag) {
+	cfg, initialized := lookupConfig(cmd)
+	var theme HelpTheme
+	var colors bool
+	if hc := helpConfigFor(cmd); hc != nil && hc.theme != nil {
+		theme, colors = *hc.theme, hc.colors.Load()
+	}
+
+	rows := make([][]cell, 0, len(flags))
+	var widths [3]int
+	for _, f := range flags {
+		envVar := envVarOf(f)
+		if initialized && !cfg.noEnvUsage {
+			envVar = usageEnvVar(cfg, cmd, f)
+		}
+		var env cell
+		if envVar != "" {
+			env = cell{{text: fmt.Sprintf(translate(cmd, MessageEnvUsage), envVar), color: theme.EnvVar}}
+		}
+		row := []cell{{{text: flagSpec(f)}}, flagUsage(cmd, f, theme), env, flagNotes(cmd, f, theme)}
+		for i := range widths {
+			widths[i] = max(widths[i], row[i].width())
+		}
+		rows = append(rows, row)
+	}
+
+	fmt.Fprintf(sb, "\n\n%s:", title)
+	for _, row := range rows {
+		// Columns are separated by three spaces; trailing empty columns are left out.
+		last := len(row) - 1
+		for last > 0 && row[last].width() == 0 {
+			last--
+		}
+		sb.WriteString("\n")
+		for i, c := range row[:last+1] {
+			c.write(sb, colors)
+			if i < last {
+				sb.WriteString(strings.Repeat(" ", widths[i]-c.width()+3))
+			}
+		}
+	}
+}
+
+// flagSpec returns the flag as shown by pflag, e.g. "  -p, --port int".
+func flagSpec(f *pflag.Flag) string {
+	spec := "      --" + f.Name
+	if f.Shorthand != "" && f.ShorthandDeprecated == "" {
+		spec = "  -" + f.Shorthand + ", --" + f.Name
+	}
+	if name, _ := pflag.UnquoteUsage(f); name != "" {
+		spec += " " + name
+	}
+	return spec
+}
+
+// flagUsage returns the translated usage text of the flag f of cmd with its default value,
+// as shown by pflag.
+func flagUsage(cmd *cobra.Command, f *pflag.Flag, theme HelpTheme) cell {
+	_, usage := pflag.UnquoteUsage(f)
+	if original := f.Annotations[usageAnnotation]; len(original) > 0 { // Decorated, see decorateUsage.
+		usage = original[0]
+	}
+	usage = translate(cmd, usage)
+	if !showsDefault(f) {
+		return cell{{text: usage}}
+	}
+	def := f.DefValue
+	switch {
+	case secretFlag(f):
+		def = redacted
+	case f.Value.Type() == "string":
+		def = fmt.Sprintf("%q", def)
+	}
+	return cell{{text: usage + " "}, {text: fmt.Sprintf(translate(cmd, MessageDefault), def), color: theme.Default}}
+}
+
+// showsDefault reports whether pflag shows the default value of f in the help output,
+// which it leaves out for zero values.
+func showsDefault(f *pflag.Flag) bool {
+	switch f.DefValue {
+	case "", "false", "0", "[]", "<nil>", "0s":
+		return false
+	}
+	return true
+}
+
+// flagNotes returns the notes shown for the flag f of cmd: whether it is required,
+// deprecated or experimental, the flags it depends on and the values it allows.
+func flagNotes(cmd *cobra.Command, f *pflag.Flag, theme HelpTheme) cell {
+	var notes []string
+	var required bool
+	if annotations := f.Annotations[cobra.BashCompOneRequiredFlag]; len(annotations) > 0 && annotations[0] == "true" {
+		required = true
+	}
+	if f.Deprecated != "" {
+		notes = append(notes, fmt.Sprintf(translate(cmd, MessageDeprecated), translate(cmd, f.Deprecated)))
+	}
+	if len(f.Annotations[experimentalAnnotation]) > 0 {
+		notes = append(notes, translate(cmd, MessageExperimental))
+	}
+	if deps := f.Annotations[dependsOnAnnotation]; len(deps) > 0 {
+		notes = append(notes, fmt.Sprintf(translate(cmd, MessageRequires), "--"+strings.Join(deps, ", --")))
+	}
+	if values := enumOf(cmd, f); len(values) > 0 {
+		items := make([]string, len(values))
+		for i, v := range values {
+			items[i] = fmt.Sprint(v)
+		}
+		notes = append(notes, fmt.Sprintf(translate(cmd, MessageOneOf), strings.Join(items, ", ")))
+	}
+
+	var c cell
+	if required {
+		c = append(c, segment{text: translate(cmd, MessageRequired), color: theme.Required})
+		if len(notes) > 0 {
+			c = append(c, segment{text: "; "})
+		}
+	}
+	return append(c, segment{text: strings.Join(notes, "; ")})
+}
+
+// groupOf returns the FlagBase.Group of a flag.
+func groupOf(f *pflag.Flag) string {
+	if annotations := f.Annotations[groupAnnotation]; len(annotations) > 0 {
+		return annotations[0]
+	}
+	return ""
+}
+
+// enumOf returns the values allowed for the flag f available to cmd, if it was registered
+// through cobraflags on cmd or one of its ancestors with a OneOf validator.
+func enumOf(cmd *cobra.Command, f *pflag.Flag) []any {
+	for c := cmd; c != nil; c = c.Parent() {
+		for _, entry := range registeredOn(c) {
+			if flag, _ := entry.base.identity(); flag == f {
+				return entry.base.enum()
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestInstallHelp(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	serve := &cobra.Command{Use: "serve", Short: "Serve requests", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+	(&cobraflags.BoolFlag{Name: "verbose", Shorthand: "v", Usage: "Verbose output", Persistent: true}).Register(root)
+	(&cobraflags.IntFlag{Name: "port", Shorthand: "p", Usage: "Server port", Value: 8080, Group: "Server", Required: true}).Register(serve)
+	(&cobraflags.StringFlag{Name: "host", Usage: "Server host", Group: "Server"}).Register(serve)
+	(&cobraflags.StringFlag{Name: "mode", Usage: "Run mode", Value: "dev", Validator: cobraflags.OneOf("dev", "prod")}).Register(serve)
+	(&cobraflags.StringFlag{Name: "token", Usage: "API token", NoEnvUsage: true}).Register(serve)
+	serve.Flags().String("old", "", "Old option")
+	c.Assert(serve.Flags().MarkDeprecated("old", "use --mode"), qt.IsNil)
+	cobraflags.CobraOnInitialize("HELPAPP", root)
+	cobraflags.InstallHelp(root)
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"serve", "--help"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Equals, `Serve requests
+
+Usage:
+  myapp serve [flags]
+
+Flags:
+  -h, --help           help for serve
+      --mode string    Run mode (default "dev")   [env: HELPAPP_MODE]   one of: dev, prod
+      --old string     Old option                 [env: HELPAPP_OLD]    deprecated: use --mode
+      --token string   API token
+
+Server Flags:
+      --host string   Server host                  [env: HELPAPP_HOST]
+  -p, --port int      Server port (default 8080)   [env: HELPAPP_PORT]   required
+
+Global Flags:
+  -v, --verbose   Verbose output   [env: HELPAPP_VERBOSE]
+`)
+
+	// The usage text of the flags is left alone.
+	c.Assert(serve.Flags().Lookup("port").Usage, qt.Equals, "Server port")
+}
+
+func TestInstallHelp_NotInitialized(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Usage: "Server port", EnvVar: "PORT"}).Register(cmd)
+	(&cobraflags.StringFlag{Name: "host", Usage: "Server host"}).Register(cmd)
+	cobraflags.InstallHelp(cmd)
+
+	c.Assert(cmd.UsageString(), qt.Equals, `Usage:
+  myapp [flags]
+
+Flags:
+      --host string   Server host
+      --port int      Server port   [env: PORT]
+`)
+}
+
+func TestInstallHelp_Theme(t *testing.T) {
+	c := qt.New(t)
+
+	t.Setenv("NO_COLOR", "")
+	newCommand := func(colors cobraflags.ColorMode) (*cobra.Command, *bytes.Buffer) {
+		cmd := newCobraCommand()
+		cmd.Run = func(*cobra.Command, []string) {}
+		(&cobraflags.IntFlag{Name: "port", Usage: "Server port", Value: 8080, Required: true}).Register(cmd)
+		(&cobraflags.StringFlag{Name: "host", Usage: "Server host"}).Register(cmd)
+		cobraflags.CobraOnInitialize("THEMEAPP", cmd)
+		theme := cobraflags.DefaultHelpTheme
+		theme.Colors = colors
+		cobraflags.InstallHelp(cmd, cobraflags.WithHelpTheme(theme))
+
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{"--help"})
+		return cmd, &out
+	}
+
+	cmd, out := newCommand(cobraflags.ColorAlways)
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Equals, "An example CLI\n\nUsage:\n  myapp [flags]\n\nFlags:\n"+
+		"  -h, --help          help for myapp\n"+
+		"      --host string   Server host                  \x1b[36m[env: THEMEAPP_HOST]\x1b[0m\n"+
+		"      --port int      Server port \x1b[2m(default 8080)\x1b[0m   \x1b[36m[env: THEMEAPP_PORT]\x1b[0m   \x1b[1;31mrequired\x1b[0m\n")
+
+	// A buffer is not a terminal.
+	cmd, out = newCommand(cobraflags.ColorAuto)
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Not(qt.Contains), "\x1b[")
+
+	cmd, out = newCommand(cobraflags.ColorNever)
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Contains, "Server port (default 8080)   [env: THEMEAPP_PORT]   required\n")
+	c.Assert(out.String(), qt.Not(qt.Contains), "\x1b[")
+}
+
+func TestInstallHelp_HelpAll(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	ran := 0
+	root.RunE = func(*cobra.Command, []string) error {
+		ran++
+		return nil
+	}
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+	(&cobraflags.IntFlag{Name: "port", Usage: "Server port"}).Register(serve)
+	(&cobraflags.IntFlag{Name: "max-idle-conns", Usage: "Idle connection pool size", Advanced: true}).Register(serve)
+	(&cobraflags.BoolFlag{Name: "trace", Usage: "Trace requests", Advanced: true, Persistent: true}).Register(root)
+	cobraflags.InstallHelp(root, cobraflags.WithHelpAll())
+
+	help := func(args ...string) string {
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetArgs(args)
+		c.Assert(root.Execute(), qt.IsNil)
+		return out.String()
+	}
+
+	out := help("serve", "--help")
+	c.Assert(out, qt.Contains, "--port int")
+	c.Assert(out, qt.Contains, "--help-all   help for myapp, including advanced flags")
+	c.Assert(out, qt.Not(qt.Contains), "max-idle-conns")
+	c.Assert(out, qt.Not(qt.Contains), "trace")
+
+	out = help("serve", "--help-all")
+	c.Assert(out, qt.Contains, "--max-idle-conns int   Idle connection pool size")
+	c.Assert(out, qt.Contains, "--trace")
+	c.Assert(ran, qt.Equals, 0)
+
+	// The advanced flags are hidden again, and the next execution runs as usual.
+	c.Assert(serve.Flags().Lookup("max-idle-conns").Hidden, qt.IsTrue)
+	c.Assert(help(), qt.Equals, "")
+	c.Assert(ran, qt.Equals, 1)
+	c.Assert(help("--help-all"), qt.Contains, "--trace")
+	c.Assert(ran, qt.Equals, 1)
+
+	// --help-all requests the help of the executed command only, and ends the parsing, so
+	// that neither the --help flags nor the flags after it are changed.
+	c.Assert(help("serve", "--help-all", "--unknown"), qt.Contains, "--max-idle-conns")
+	c.Assert(root.Flags().Lookup("help").Value.String(), qt.Equals, "false")
+	c.Assert(help(), qt.Equals, "")
+	c.Assert(ran, qt.Equals, 2)
+}
@@ -0,0 +1,103 @@
+package cobraflags
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// The messages cobraflags shows to users, which a Translator set with WithTranslator can
+// translate. They are format strings: a translation must keep their verbs, in the same
+// order or indexed, e.g. "%[2]s".
+const (
+	// MessageEnvUsage follows the usage text of a flag in the help output, with its environment variable.
+	MessageEnvUsage = "[env: %s]"
+	// MessageDefault follows the usage text of a flag in the help output of InstallHelp, with its default value.
+	MessageDefault = "(default %s)"
+	// MessageRequired marks the required flags in the help output of InstallHelp.
+	MessageRequired = "required"
+	// MessageDeprecated marks the deprecated flags in the help output of InstallHelp, with the hint.
+	MessageDeprecated = "deprecated: %s"
+	// MessageExperimental marks the experimental flags in the help output of InstallHelp.
+	MessageExperimental = "experimental"
+	// MessageRequires lists the flags a flag depends on in the help output of InstallHelp.
+	MessageRequires = "requires: %s"
+	// MessageOneOf lists the values allowed by OneOf in the help output of InstallHelp.
+	MessageOneOf = "one of: %s"
+	// MessageFlagError prefixes the errors of the value of a flag with its name.
+	MessageFlagError = "flag %q: %w"
+	// MessageArgumentError prefixes the errors of the value of a positional argument with its name, see Arg.
+	MessageArgumentError = "argument %q: %w"
+	// MessageRequiredFlag is the error for a required flag that is not set, with its name, its
+	// name again, its environment variable and its configuration key.
+	MessageRequiredFlag = "required flag %q not set, use --%s, the environment variable %s or the config key %q"
+	// MessageRequiredFlagNoEnv is the error for a required flag without environment variable that
+	// is not set, with its name, its name again and its configuration key.
+	MessageRequiredFlagNoEnv = "required flag %q not set, use --%s or the config key %q"
+	// MessageRequiredArgument is the error for a required positional argument that is not set,
+	// with its name and its environment variable.
+	MessageRequiredArgument = "required argument %q not set, give it on the command line or in the environment variable %s"
+	// MessageRequiredArgumentNoEnv is the error for a required positional argument without
+	// environment variable that is not set, with its name.
+	MessageRequiredArgumentNoEnv = "required argument %q not set"
+)
+
+// Translator translates the texts cobraflags shows to users: the Message constants and the
+// usage texts of flags.
+type Translator interface {
+	// Translate returns the translation of message, or message itself if there is none.
+	Translate(message string) string
+}
+
+// TranslatorFunc is a function type that implements the Translator interface.
+type TranslatorFunc func(message string) string
+
+// Translate calls the TranslatorFunc itself to translate the message.
+func (f TranslatorFunc) Translate(message string) string {
+	return f(message)
+}
+
+// Catalog is a Translator backed by a message catalog, mapping each message to its
+// translation. Messages missing from the catalog are not translated.
+//
+// Example:
+//
+//	cobraflags.Catalog{
+//		cobraflags.MessageEnvUsage: "[variable d'environnement : %s]",
+//		cobraflags.MessageRequired: "obligatoire",
+//		"Server port":              "Port du serveur",
+//	}
+type Catalog map[string]string
+
+// Translate returns the translation of message in the catalog, or message itself if there is none.
+func (c Catalog) Translate(message string) string {
+	if translated, ok := c[message]; ok {
+		return translated
+	}
+	return message
+}
+
+// WithTranslator translates the texts the command tree shows to users, for CLIs shipped to
+// non-English users: the usage texts of flags and the environment variables appended to
+// them, the notes of InstallHelp on required, deprecated and other flags, and the error
+// messages naming flags and positional arguments. The translator is usually a Catalog
+// selected for the user's locale:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd, cobraflags.WithTranslator(catalogs[locale]))
+//
+// A format set with WithEnvUsageFormat receives the translated usage text.
+func WithTranslator(t Translator) InitOption {
+	return func(c *initConfig) {
+		c.translator = t
+	}
+}
+
+// translate returns the translation of message for the command tree of cmd, see WithTranslator.
+func translate(cmd *cobra.Command, message string) string {
+	if cmd == nil {
+		return message
+	}
+	cfg := configFor(cmd)
+	if cfg.translator == nil {
+		return message
+	}
+	return cfg.translator.Translate(message)
+}
@@ -0,0 +1,93 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+var frenchCatalog = cobraflags.Catalog{
+	cobraflags.MessageEnvUsage:         "[variable : %s]",
+	cobraflags.MessageDefault:          "(par défaut %s)",
+	cobraflags.MessageRequired:         "obligatoire",
+	cobraflags.MessageDeprecated:       "obsolète : %s",
+	cobraflags.MessageFlagError:        "option %q : %w",
+	cobraflags.MessageArgumentError:    "argument %q : %w",
+	cobraflags.MessageRequiredFlag:     "option %[1]q obligatoire, utilisez --%[2]s, la variable %[3]s ou la clé %[4]q",
+	cobraflags.MessageRequiredArgument: "argument %q obligatoire, donnez-le ou utilisez la variable %s",
+	"Server port":                      "Port du serveur",
+	"use --mode":                       "utilisez --mode",
+}
+
+func TestWithTranslator_Usage(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Usage: "Server port", Value: 8080}).Register(cmd)
+	(&cobraflags.StringFlag{Name: "host", Usage: "Server host", NoEnvUsage: true}).Register(cmd)
+	cobraflags.CobraOnInitialize("I18NAPP", cmd, cobraflags.WithTranslator(frenchCatalog))
+
+	usage := cmd.UsageString()
+	c.Assert(usage, qt.Contains, "Port du serveur [variable : I18NAPP_PORT] (default 8080)")
+	c.Assert(usage, qt.Contains, "Server host\n")
+	c.Assert(cmd.Flags().Lookup("port").Usage, qt.Equals, "Server port")
+}
+
+func TestWithTranslator_InstallHelp(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Shorthand: "p", Usage: "Server port", Value: 8080, Required: true}).Register(cmd)
+	(&cobraflags.StringFlag{Name: "old", Usage: "Old option", Deprecated: "use --mode"}).Register(cmd)
+	cobraflags.CobraOnInitialize("I18NAPP", cmd, cobraflags.WithTranslator(frenchCatalog))
+	cobraflags.InstallHelp(cmd)
+
+	c.Assert(cmd.UsageString(), qt.Equals, `Usage:
+  myapp [flags]
+
+Flags:
+      --old string   Old option                          [variable : I18NAPP_OLD]    obsolète : utilisez --mode
+  -p, --port int     Port du serveur (par défaut 8080)   [variable : I18NAPP_PORT]   obligatoire
+`)
+}
+
+func TestWithTranslator_Errors(t *testing.T) {
+	c := qt.New(t)
+
+	execute := func(args ...string) error {
+		cmd := newCobraCommand()
+		(&cobraflags.StringFlag{Name: "host", Required: true}).Register(cmd)
+		(&cobraflags.Arg[int]{Name: "count", Required: true}).Register(cmd)
+		cobraflags.CobraOnInitialize("I18NAPP", cmd, cobraflags.WithTranslator(frenchCatalog))
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+
+	c.Assert(execute("--host", "example.com"), qt.ErrorMatches,
+		`argument "count" obligatoire, donnez-le ou utilisez la variable I18NAPP_COUNT`)
+	c.Assert(execute("--host", "example.com", "many"), qt.ErrorMatches,
+		`argument "count" : invalid value "many": invalid syntax`)
+	c.Assert(execute("3"), qt.ErrorMatches,
+		`option "host" obligatoire, utilisez --host, la variable I18NAPP_HOST ou la clé "host"`)
+}
+
+func TestTranslatorFunc(t *testing.T) {
+	c := qt.New(t)
+
+	upper := cobraflags.TranslatorFunc(strings.ToUpper)
+	c.Assert(upper.Translate("required"), qt.Equals, "REQUIRED")
+	c.Assert(frenchCatalog.Translate("unknown"), qt.Equals, "unknown")
+
+	var out bytes.Buffer
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Usage: "Server port"}).Register(cmd)
+	cobraflags.CobraOnInitialize("I18NAPP", cmd, cobraflags.WithTranslator(upper), cobraflags.WithoutEnvUsage())
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--help"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Contains, "--port int   SERVER PORT\n")
+}
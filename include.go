@@ -0,0 +1,95 @@
+package cobraflags
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// includeKey is the configuration key listing the files a configuration file includes.
+const includeKey = "include"
+
+// WithConfigIncludes enables the include directive in configuration files: a file may
+// list other files, or glob patterns, under the key "include". Relative paths are resolved
+// against the directory of the including file. Included files are merged in the listed
+// order, with the matches of a pattern in lexical order, and the including file is merged
+// on top of them, so its own values take precedence. Included files may include further
+// files; an include cycle makes the command execution fail, as does a missing file that is
+// not named by a pattern.
+//
+// Example config.yaml:
+//
+//	include:
+//	  - defaults.yaml
+//	  - conf.d/*.yaml
+//	server:
+//	  port: 8080
+func WithConfigIncludes() InitOption {
+	return func(c *initConfig) {
+		c.configIncludes = true
+	}
+}
+
+// resolveIncludes returns the given config files with the files they include, recursively,
+// in merge order: every file is preceded by the files it includes.
+func resolveIncludes(files []string) ([]string, error) {
+	var result []string
+	for _, path := range files {
+		resolved, err := resolveFileIncludes(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, resolved...)
+	}
+	return result, nil
+}
+
+// resolveFileIncludes returns the files included by the config file at path, recursively,
+// followed by path itself. The stack holds the absolute paths of the including files.
+func resolveFileIncludes(path string, stack []string) ([]string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(stack, abs) {
+		return nil, fmt.Errorf("config include cycle: %s", strings.Join(append(stack, abs), " -> "))
+	}
+	stack = append(stack, abs)
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("reading config file %q: %w", path, err)
+	}
+
+	var result []string
+	for _, pattern := range v.GetStringSlice(includeKey) {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("config file %q: include %q: %w", path, pattern, err)
+		}
+		if len(matches) == 0 && !hasMeta(pattern) {
+			matches = []string{pattern} // Fail on reading the missing file.
+		}
+		slices.Sort(matches)
+		for _, match := range matches {
+			included, err := resolveFileIncludes(match, stack)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, included...)
+		}
+	}
+	return append(result, path), nil
+}
+
+// hasMeta reports whether path contains any of the glob magic characters.
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
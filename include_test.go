@@ -0,0 +1,79 @@
+package cobraflags_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestWithConfigIncludes(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	c.Assert(os.Mkdir(filepath.Join(dir, "conf.d"), 0o700), qt.IsNil)
+	writeConfig(c, dir, "config.yaml", "include:\n  - defaults.yaml\n  - conf.d/*.yaml\nport: 8080\n")
+	writeConfig(c, dir, "defaults.yaml", "port: 80\nhost: default.example.com\nlevel: info\n")
+	writeConfig(c, filepath.Join(dir, "conf.d"), "20-level.yaml", "level: error\n")
+	writeConfig(c, filepath.Join(dir, "conf.d"), "10-level.yaml", "include: ../extra.yaml\nlevel: debug\n")
+	writeConfig(c, dir, "extra.yaml", "host: extra.example.com\n")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port"}
+	hostFlag := &cobraflags.StringFlag{Name: "host"}
+	levelFlag := &cobraflags.StringFlag{Name: "level"}
+	cobraflags.Register(cmd, portFlag, hostFlag, levelFlag)
+	cobraflags.CobraOnInitialize("INCLUDEAPP", cmd,
+		cobraflags.WithConfigFile("config", "yaml", dir), cobraflags.WithConfigIncludes(), cobraflags.WithStrictConfig())
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(hostFlag.GetString(), qt.Equals, "extra.example.com")
+	c.Assert(levelFlag.GetString(), qt.Equals, "error")
+	c.Assert(cobraflags.ConfigFilesUsed(cmd), qt.DeepEquals, []string{
+		filepath.Join(dir, "defaults.yaml"),
+		filepath.Join(dir, "extra.yaml"),
+		filepath.Join(dir, "conf.d", "10-level.yaml"),
+		filepath.Join(dir, "conf.d", "20-level.yaml"),
+		filepath.Join(dir, "config.yaml"),
+	})
+}
+
+func TestWithConfigIncludes_Errors(t *testing.T) {
+	c := qt.New(t)
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{{
+		name: "cycle",
+		files: map[string]string{
+			"config.yaml": "include: [a.yaml]\n",
+			"a.yaml":      "include: [b.yaml]\n",
+			"b.yaml":      "include: [a.yaml]\n",
+		},
+		wantErr: `config include cycle: .*config.yaml -> .*a.yaml -> .*b.yaml -> .*a.yaml`,
+	}, {
+		name:    "missing",
+		files:   map[string]string{"config.yaml": "include: [missing.yaml]\n"},
+		wantErr: `reading config file ".*missing.yaml": open .*`,
+	}}
+
+	for _, tt := range tests {
+		c.Run(tt.name, func(c *qt.C) {
+			dir := c.TempDir()
+			for name, content := range tt.files {
+				writeConfig(c, dir, name, content)
+			}
+
+			cmd := newCobraCommand()
+			cobraflags.CobraOnInitialize("INCLUDEAPP", cmd,
+				cobraflags.WithConfigFile("config", "yaml", dir), cobraflags.WithConfigIncludes())
+			c.Assert(cmd.Execute(), qt.ErrorMatches, tt.wantErr)
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package cobraflags_test
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"sync"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
@@ -258,3 +261,118 @@ func TestConcurrentFlagAccess(t *testing.T) {
 		}
 	}
 }
+
+// TestConcurrentFlagAccessDuringExecute tests that flag values can be read from
+// other goroutines while the command is executing and applying environment presets.
+func TestConcurrentFlagAccessDuringExecute(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("RACEEXEC_NAME", "from-env")
+
+	nameFlag := &cobraflags.StringFlag{
+		Name:  "name",
+		Usage: "Name",
+		Value: "default",
+	}
+
+	cmd := &cobra.Command{
+		Use: "raceexec",
+		Run: func(_ *cobra.Command, _ []string) {},
+	}
+
+	nameFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("RACEEXEC", cmd)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = nameFlag.GetString()
+					_, _ = nameFlag.GetStringE()
+				}
+			}
+		}()
+	}
+
+	cmd.SetArgs(make([]string, 0))
+	err := cmd.Execute()
+	close(stop)
+	wg.Wait()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(nameFlag.GetString(), qt.Equals, "from-env")
+}
+
+// TestConcurrentRegisterAndGet tests that registering a flag and reading it
+// from another goroutine does not race.
+func TestConcurrentRegisterAndGet(t *testing.T) {
+	c := qt.New(t)
+
+	cobraflags.SetErrorHandler(func(error) {})
+	c.Cleanup(func() {
+		cobraflags.SetErrorHandler(nil)
+	})
+
+	flag := &cobraflags.IntFlag{Name: "count", Value: 5}
+	cmd := &cobra.Command{Use: "register-race"}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = flag.GetInt()
+	}()
+	flag.Register(cmd)
+	<-done
+
+	c.Assert(flag.GetInt(), qt.Equals, 5)
+}
+
+// TestConcurrentRegister tests that flags can be registered on the same commands and
+// command trees can be initialized from multiple goroutines, as plugin systems do.
+func TestConcurrentRegister(t *testing.T) {
+	c := qt.New(t)
+
+	root := &cobra.Command{Use: "plugins", Run: func(*cobra.Command, []string) {}}
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+
+	const plugins = 20
+	flags := make([]*cobraflags.IntFlag, plugins)
+	errs := make([]error, plugins)
+	duplicates := make([]error, plugins)
+	var wg sync.WaitGroup
+	for i := range plugins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			flags[i] = &cobraflags.IntFlag{Name: fmt.Sprintf("plugin-%d", i), Value: i, Persistent: i%2 == 0}
+			errs[i] = flags[i].RegisterE(root)
+			duplicates[i] = (&cobraflags.StringFlag{Name: "shared"}).RegisterE(sub)
+			cobraflags.CobraOnInitialize("PLUGINS", &cobra.Command{Use: fmt.Sprintf("other-%d", i)})
+		}()
+	}
+	wg.Wait()
+	cobraflags.CobraOnInitialize("PLUGINS", root)
+
+	registered := 0
+	for i := range plugins {
+		c.Assert(errs[i], qt.IsNil)
+		if duplicates[i] == nil {
+			registered++
+		} else {
+			c.Assert(errors.Is(duplicates[i], cobraflags.ErrDuplicateFlag), qt.IsTrue)
+		}
+	}
+	c.Assert(registered, qt.Equals, 1)
+
+	root.SetArgs([]string{"--plugin-3", "42"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(flags[3].GetInt(), qt.Equals, 42)
+	c.Assert(flags[4].GetInt(), qt.Equals, 4)
+}
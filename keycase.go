@@ -0,0 +1,207 @@
+package cobraflags
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// caseSensitiveRoots marks the root commands whose Viper keys preserve their case,
+// see WithCaseSensitiveKeys. It is guarded by vipersMutex.
+var caseSensitiveRoots = make(map[*cobra.Command]bool)
+
+// WithCaseSensitiveKeys makes the Viper keys of the command tree case-sensitive, so that
+// flags with the keys "app.ConfigFile" and "app.configfile" have values of their own, and
+// configuration files, --set assignments, value stores and remote configurations must
+// spell keys the way the flags do. By default, Viper lower-cases all keys.
+//
+// Viper has no case-sensitive mode, so cobraflags stores upper-case letters escaped, as
+// '^' followed by the lower-case letter ("app.^config^file"), and a literal '^' as "^^".
+// Values read through the flags, FlagsOf and the other functions of this package use the
+// keys as given, but the instance returned by ViperFor holds the escaped keys. Environment
+// variables are bound to the keys one by one, as with WithoutAutomaticEnv, and file names
+// in the secrets directory (see WithSecretsDir) still match case-insensitively.
+//
+// Since the escaping happens while configuration files are decoded, the option cannot be
+// combined with WithViper: CobraOnInitializeE and InitFlagSet return an error, and
+// CobraOnInitialize makes the execution fail.
+//
+// Example:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd, cobraflags.WithCaseSensitiveKeys())
+func WithCaseSensitiveKeys() InitOption {
+	return func(c *initConfig) {
+		c.caseSensitive = true
+	}
+}
+
+// setCaseSensitive marks cmd's command tree as having case-sensitive Viper keys, including
+// the stores created for it already.
+func setCaseSensitive(cmd *cobra.Command) {
+	vipersMutex.Lock()
+	defer vipersMutex.Unlock()
+
+	root := cmd.Root()
+	caseSensitiveRoots[root] = true
+	for key, st := range vipers {
+		if key.Root() == root {
+			st.caseSensitive.Store(true)
+		}
+	}
+	assignments.Add(1)
+}
+
+// newViper returns a Viper instance whose decoders escape the keys of the configuration
+// they read while escape reports true, see WithCaseSensitiveKeys.
+func newViper(escape func() bool) *viper.Viper {
+	return viper.NewWithOptions(viper.WithDecoderRegistry(caseDecoders{
+		registry: viper.NewCodecRegistry(),
+		escape:   escape,
+	}))
+}
+
+// foldKey returns the form of key that identifies it in the store: key itself if keys are
+// case-sensitive, or else key in lower case.
+func (st *store) foldKey(key string) string {
+	if st.caseSensitive.Load() {
+		return key
+	}
+	return strings.ToLower(key)
+}
+
+// escapeKey escapes the upper-case letters of key, and '^' itself, so that they survive
+// Viper lower-casing the key, see WithCaseSensitiveKeys.
+func escapeKey(key string) string {
+	if strings.IndexFunc(key, func(r rune) bool { return r == '^' || unicode.IsUpper(r) }) < 0 {
+		return key
+	}
+	var b strings.Builder
+	for _, r := range key {
+		switch {
+		case r == '^':
+			b.WriteString("^^")
+		case unicode.IsUpper(r):
+			b.WriteByte('^')
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// unescapeKey reverses escapeKey.
+func unescapeKey(key string) string {
+	if !strings.Contains(key, "^") {
+		return key
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] != '^' || i == len(key)-1 {
+			b.WriteByte(key[i])
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(key[i+1:])
+		if r == '^' {
+			b.WriteByte('^')
+		} else {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// mapKeys returns a copy of value in which the keys of all nested maps are replaced with
+// the result of fn, or value itself if it is no map.
+func mapKeys(value any, fn func(string) string) any {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	result := make(map[string]any, len(m))
+	for key, value := range m {
+		result[fn(key)] = mapKeys(value, fn)
+	}
+	return result
+}
+
+// caseDecoders is a viper.DecoderRegistry that escapes the keys of the configuration
+// decoded by the decoders of registry while escape reports true.
+type caseDecoders struct {
+	registry viper.DecoderRegistry
+	escape   func() bool
+}
+
+// Decoder returns the decoder for format.
+func (r caseDecoders) Decoder(format string) (viper.Decoder, error) {
+	d, err := r.registry.Decoder(format)
+	if err != nil || !r.escape() {
+		return d, err
+	}
+	return escapingDecoder{d}, nil
+}
+
+// escapingDecoder escapes the keys of the configuration decoded by Decoder.
+type escapingDecoder struct {
+	viper.Decoder
+}
+
+// Decode decodes b into v, with escaped keys.
+func (d escapingDecoder) Decode(b []byte, v map[string]any) error {
+	decoded := make(map[string]any)
+	if err := d.Decoder.Decode(b, decoded); err != nil {
+		return err
+	}
+	for key, value := range decoded {
+		v[escapeKey(key)] = mapKeys(value, escapeKey)
+	}
+	return nil
+}
+
+// caseBackend is a backend with case-sensitive keys on top of one that lower-cases them,
+// by escaping the keys, see WithCaseSensitiveKeys.
+type caseBackend struct {
+	b backend
+}
+
+func (c caseBackend) Get(key string) any {
+	return mapKeys(c.b.Get(escapeKey(key)), unescapeKey)
+}
+
+func (c caseBackend) GetString(key string) string { return c.b.GetString(escapeKey(key)) }
+func (c caseBackend) GetInt(key string) int       { return c.b.GetInt(escapeKey(key)) }
+func (c caseBackend) GetInt64(key string) int64   { return c.b.GetInt64(escapeKey(key)) }
+func (c caseBackend) IsSet(key string) bool       { return c.b.IsSet(escapeKey(key)) }
+func (c caseBackend) InConfig(key string) bool    { return c.b.InConfig(escapeKey(key)) }
+
+func (c caseBackend) GetStringSlice(key string) []string {
+	return c.b.GetStringSlice(escapeKey(key))
+}
+
+func (c caseBackend) AllKeys() []string {
+	keys := c.b.AllKeys()
+	for i, key := range keys {
+		keys[i] = unescapeKey(key)
+	}
+	return keys
+}
+
+func (c caseBackend) Set(key string, value any) {
+	c.b.Set(escapeKey(key), mapKeys(value, escapeKey))
+}
+
+func (c caseBackend) BindPFlag(key string, flag *pflag.Flag) error {
+	return c.b.BindPFlag(escapeKey(key), flag)
+}
+
+func (c caseBackend) BindEnv(input ...string) error {
+	if len(input) > 0 {
+		input = append([]string{escapeKey(input[0])}, input[1:]...)
+	}
+	return c.b.BindEnv(input...)
+}
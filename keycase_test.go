@@ -0,0 +1,64 @@
+package cobraflags_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/viper"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestWithCaseSensitiveKeys(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("CASEAPP_APP_CONFIGFILE", "env.yaml")
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "app:\n  ConfigFile: upper.yaml\n  configfile: lower.yaml\n  Timeout: 5\n")
+
+	cmd := newCobraCommand()
+	upperFlag := &cobraflags.StringFlag{Name: "config-file", ViperKey: "app.ConfigFile", EnvVar: "CASEAPP_UPPER"}
+	lowerFlag := &cobraflags.StringFlag{Name: "configfile", ViperKey: "app.configfile"}
+	timeoutFlag := &cobraflags.IntFlag{Name: "timeout", ViperKey: "app.Timeout"}
+	cobraflags.Register(cmd, upperFlag, lowerFlag, timeoutFlag)
+	c.Assert(cobraflags.CobraOnInitializeE("CASEAPP", cmd, cobraflags.WithCaseSensitiveKeys(),
+		cobraflags.WithConfigFile("config", "yaml", dir), cobraflags.WithStrictConfig(), cobraflags.WithSetFlag()), qt.IsNil)
+	c.Cleanup(func() { cobraflags.ResetCommandState(cmd) })
+
+	cmd.SetArgs([]string{"--set", "app.Timeout=10"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(upperFlag.GetString(), qt.Equals, "upper.yaml")
+	c.Assert(lowerFlag.GetString(), qt.Equals, "env.yaml")
+	c.Assert(timeoutFlag.GetInt(), qt.Equals, 10)
+
+	sources := make(map[string]cobraflags.Source)
+	for _, info := range cobraflags.FlagsOf(cmd) {
+		sources[info.Name] = info.Source
+	}
+	c.Assert(sources, qt.DeepEquals, map[string]cobraflags.Source{
+		"config-file": cobraflags.SourceConfig,
+		"configfile":  cobraflags.SourceEnv,
+		"timeout":     cobraflags.SourceConfig,
+	})
+	// The Viper instance holds the escaped keys.
+	c.Assert(cobraflags.ViperFor(cmd).GetString("app.^config^file"), qt.Equals, "upper.yaml")
+
+	// WriteConfig keeps the case of the keys.
+	path := filepath.Join(dir, "saved.yaml")
+	c.Assert(cobraflags.WriteConfig(cmd, path, ""), qt.IsNil)
+	saved, err := os.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(saved), qt.Contains, "ConfigFile: upper.yaml")
+	c.Assert(string(saved), qt.Contains, "configfile: env.yaml")
+	c.Assert(string(saved), qt.Contains, "Timeout: 10")
+}
+
+func TestWithCaseSensitiveKeys_Viper(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	err := cobraflags.CobraOnInitializeE("CASEAPP", cmd, cobraflags.WithCaseSensitiveKeys(), cobraflags.WithViper(viper.New()))
+	c.Assert(err, qt.ErrorMatches, "cobraflags: WithCaseSensitiveKeys cannot be combined with WithViper")
+}
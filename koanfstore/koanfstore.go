@@ -0,0 +1,91 @@
+// Package koanfstore implements cobraflags.ValueStore with koanf, so that applications
+// that keep their configuration in a koanf instance can exchange values with the flags
+// of a command tree, see cobraflags.WithValueStore.
+//
+// The package is a module of its own, so that cobraflags itself does not depend on koanf.
+// cobraflags still resolves the values of flags with Viper.
+package koanfstore
+
+import (
+	"sync"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/spf13/pflag"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// Store is a cobraflags.ValueStore backed by a koanf instance. The flags bound to it take
+// precedence over the values of the koanf instance once they are set, on the command line
+// or from the environment by CobraOnInitialize.
+type Store struct {
+	k *koanf.Koanf
+
+	mu    sync.RWMutex
+	flags map[string]*pflag.Flag
+}
+
+var _ cobraflags.ValueStore = (*Store)(nil)
+
+// New returns a Store backed by k.
+//
+// Example:
+//
+//	k := koanf.New(".")
+//	_ = k.Load(file.Provider("config.toml"), toml.Parser())
+//	store := koanfstore.New(k)
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd, cobraflags.WithValueStore(store))
+//	...
+//	port := store.Get("server.port") // From the command line or environment, or else from config.toml
+func New(k *koanf.Koanf) *Store {
+	return &Store{k: k, flags: make(map[string]*pflag.Flag)}
+}
+
+// Koanf returns the koanf instance of the store.
+func (s *Store) Koanf() *koanf.Koanf {
+	return s.k
+}
+
+// Get returns the value of the flag bound to key if it was set, otherwise the value of the
+// koanf instance, or nil if there is none. Flag values are returned as strings, or as string
+// slices for slice flags.
+func (s *Store) Get(key string) any {
+	if f := s.changed(key); f != nil {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			return sv.GetSlice()
+		}
+		return f.Value.String()
+	}
+	return s.k.Get(key)
+}
+
+// Set stores value under key in the koanf instance.
+func (s *Store) Set(key string, value any) error {
+	return s.k.Set(key, value)
+}
+
+// IsSet reports whether the flag bound to key was set, or the koanf instance has a value
+// under key.
+func (s *Store) IsSet(key string) bool {
+	return s.changed(key) != nil || s.k.Exists(key)
+}
+
+// BindFlag binds flag to key, see Get.
+func (s *Store) BindFlag(key string, flag *pflag.Flag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flags[key] = flag
+	return nil
+}
+
+// changed returns the flag bound to key if it was set, or nil.
+func (s *Store) changed(key string) *pflag.Flag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if f := s.flags[key]; f != nil && f.Changed {
+		return f
+	}
+	return nil
+}
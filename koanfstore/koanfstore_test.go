@@ -0,0 +1,46 @@
+package koanfstore_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/knadh/koanf/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+	"github.com/go-extras/cobraflags/koanfstore"
+)
+
+func TestStore(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("KOANFAPP_TAGS", "a,b")
+
+	k := koanf.New(".")
+	c.Assert(k.Set("server.port", 9090), qt.IsNil)
+	c.Assert(k.Set("host", "koanf.example.com"), qt.IsNil)
+	store := koanfstore.New(k)
+
+	cmd := &cobra.Command{Use: "koanfapp", Run: func(*cobra.Command, []string) {}}
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80}
+	hostFlag := &cobraflags.StringFlag{Name: "host"}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags"}
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	cobraflags.Register(cmd, portFlag, hostFlag, tagsFlag, nameFlag)
+	cobraflags.CobraOnInitialize("KOANFAPP", cmd, cobraflags.WithValueStore(store))
+
+	cmd.SetArgs([]string{"--host", "cli.example.com"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9090)
+	c.Assert(hostFlag.GetString(), qt.Equals, "cli.example.com")
+
+	// Flags that are set take precedence in the store.
+	c.Assert(store.Get("host"), qt.Equals, "cli.example.com")
+	c.Assert(store.Get("tags"), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(store.Get("server.port"), qt.Equals, "9090") // Preset from the store.
+	c.Assert(store.IsSet("name"), qt.IsFalse)
+	c.Assert(store.Get("name"), qt.IsNil)
+	c.Assert(store.Koanf().String("host"), qt.Equals, "koanf.example.com")
+
+	c.Assert(store.Set("name", "set"), qt.IsNil)
+	c.Assert(store.Get("name"), qt.Equals, "set")
+}
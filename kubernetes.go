@@ -0,0 +1,87 @@
+package cobraflags
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// kubernetesMetadata is the metadata of a generated Kubernetes object.
+type kubernetesMetadata struct {
+	Name string `yaml:"name"`
+}
+
+// kubernetesConfigMap is a generated ConfigMap.
+type kubernetesConfigMap struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   kubernetesMetadata `yaml:"metadata"`
+	Data       map[string]string  `yaml:"data"`
+}
+
+// kubernetesSecret is a generated Secret.
+type kubernetesSecret struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   kubernetesMetadata `yaml:"metadata"`
+	Type       string             `yaml:"type"`
+	StringData map[string]string  `yaml:"stringData"`
+}
+
+// GenKubernetesManifests writes a ConfigMap with the given name that sets the environment
+// variables of all flags of cmd and its subcommands to their defaults, followed by a Secret
+// of the same name with empty entries for the variables of secrets (see NewPrintConfigCommand),
+// if there are any. The manifests are ready to apply and to reference from a Deployment with
+// envFrom, once the secret values have been filled in.
+//
+// Example output:
+//
+//	apiVersion: v1
+//	kind: ConfigMap
+//	metadata:
+//	  name: myapp
+//	data:
+//	  MYAPP_LOG_LEVEL: info
+//	---
+//	apiVersion: v1
+//	kind: Secret
+//	metadata:
+//	  name: myapp
+//	type: Opaque
+//	stringData:
+//	  MYAPP_API_TOKEN: ""
+func GenKubernetesManifests(cmd *cobra.Command, w io.Writer, name string) error {
+	configMap := kubernetesConfigMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   kubernetesMetadata{Name: name},
+		Data:       make(map[string]string),
+	}
+	secret := kubernetesSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   kubernetesMetadata{Name: name},
+		Type:       "Opaque",
+		StringData: make(map[string]string),
+	}
+	for _, b := range envBindings(cmd) {
+		if b.secret {
+			secret.StringData[b.EnvVar] = ""
+		} else {
+			configMap.Data[b.EnvVar] = b.defaultValue
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(configMap); err != nil {
+		return err
+	}
+	if len(secret.StringData) > 0 {
+		if err := enc.Encode(secret); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
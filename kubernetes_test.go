@@ -0,0 +1,35 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestGenKubernetesManifests(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.GenKubernetesManifests(newDeployCommand(), &buf, "deployapp"), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: deployapp
+data:
+  DEPLOYAPP_GREETING: hello world
+  DEPLOYAPP_LOG_LEVEL: info
+  DEPLOYAPP_SERVER_MAX_CONNS: "100"
+  DEPLOYAPP_TAGS: a,b
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: deployapp
+type: Opaque
+stringData:
+  DEPLOYAPP_API_TOKEN: ""
+`)
+}
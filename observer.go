@@ -0,0 +1,123 @@
+package cobraflags
+
+import (
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+)
+
+// EventKind identifies what happened to a flag, see Event.
+type EventKind int
+
+const (
+	// EventRegistered is sent when a flag is registered on a command.
+	EventRegistered EventKind = iota
+	// EventBound is sent when a flag is bound to its Viper key, see ViperFor.
+	EventBound
+	// EventSet is sent for each flag that is set, from any source but its default, once the
+	// command is about to run.
+	EventSet
+	// EventValidationFailed is sent when the value of a flag or positional argument (see
+	// Arg) fails validation.
+	EventValidationFailed
+)
+
+// String returns the name of the kind, e.g. "validation-failed", for use as a metric label.
+func (k EventKind) String() string {
+	switch k {
+	case EventRegistered:
+		return "registered"
+	case EventBound:
+		return "bound"
+	case EventSet:
+		return "set"
+	case EventValidationFailed:
+		return "validation-failed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes configuration activity reported to the Observer, see SetObserver.
+// Values are left out, so that events never carry secrets.
+type Event struct {
+	Kind    EventKind // What happened
+	Command string    // Path of the command the flag is registered on, e.g. "myapp serve"
+	Flag    string    // Name of the flag, or of the positional argument
+	Source  Source    // Where the value comes from, for EventSet
+	Err     error     // The validation error, for EventValidationFailed (secrets redacted, see FlagBase.Sensitive)
+}
+
+// Observer receives the events of all flags, see SetObserver.
+type Observer interface {
+	Observe(e Event)
+}
+
+// ObserverFunc is a function type that implements the Observer interface.
+type ObserverFunc func(e Event)
+
+// Observe calls the ObserverFunc itself with the event.
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// observer is the Observer set with SetObserver, if any.
+var observer atomic.Pointer[Observer]
+
+// SetObserver installs an Observer that receives an event whenever a flag is registered,
+// bound, set from a source or fails validation, to feed metrics or tracing systems with
+// configuration activity. Passing nil removes it.
+//
+// The observer is called synchronously, possibly from several goroutines and while
+// cobraflags holds internal locks, so it must be safe for concurrent use and must not
+// call cobraflags itself.
+//
+// Example:
+//
+//	cobraflags.SetObserver(cobraflags.ObserverFunc(func(e cobraflags.Event) {
+//		configEvents.WithLabelValues(e.Kind.String(), e.Flag).Inc()
+//	}))
+func SetObserver(o Observer) {
+	if o == nil {
+		observer.Store(nil)
+		return
+	}
+	observer.Store(&o)
+}
+
+// observing reports whether an Observer is installed.
+func observing() bool {
+	return observer.Load() != nil
+}
+
+// notify sends e to the Observer, if any.
+func notify(e Event) {
+	if o := observer.Load(); o != nil {
+		(*o).Observe(e)
+	}
+}
+
+// notifyFlag sends an event of the given kind for the flag name of cmd to the Observer, if any.
+func notifyFlag(kind EventKind, cmd *cobra.Command, name string, err error) {
+	if !observing() {
+		return
+	}
+	var path string
+	if cmd != nil {
+		path = cmd.CommandPath()
+	}
+	notify(Event{Kind: kind, Command: path, Flag: name, Err: err})
+}
+
+// notifySources sends an EventSet for each flag available to cmd that is set, from any
+// source but its default, to the Observer, if any.
+func notifySources(cmd *cobra.Command) {
+	if !observing() {
+		return
+	}
+	for _, p := range Explain(cmd) {
+		if p.Source != SourceDefault {
+			notify(Event{Kind: EventSet, Command: p.Command, Flag: p.Name, Source: p.Source})
+		}
+	}
+}
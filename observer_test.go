@@ -0,0 +1,112 @@
+package cobraflags_test
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestSetObserver(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("OBSAPP_HOST", "example.com")
+
+	var events []cobraflags.Event
+	cobraflags.SetObserver(cobraflags.ObserverFunc(func(e cobraflags.Event) {
+		if e.Command == "obsapp" { // Command trees of other tests are initialized as well.
+			events = append(events, e)
+		}
+	}))
+	c.Cleanup(func() {
+		cobraflags.SetObserver(nil)
+	})
+
+	errTooLow := errors.New("too low")
+	cmd := newCobraCommand()
+	cmd.Use = "obsapp"
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80, ValidateFunc: func(port int) error {
+		if port < 1024 {
+			return errTooLow
+		}
+		return nil
+	}}
+	cobraflags.Register(cmd,
+		&cobraflags.StringFlag{Name: "host"},
+		portFlag,
+		&cobraflags.StringFlag{Name: "mode", Value: "dev"},
+	)
+	c.Assert(events, qt.DeepEquals, []cobraflags.Event{
+		{Kind: cobraflags.EventRegistered, Command: "obsapp", Flag: "host"},
+		{Kind: cobraflags.EventRegistered, Command: "obsapp", Flag: "port"},
+		{Kind: cobraflags.EventRegistered, Command: "obsapp", Flag: "mode"},
+	})
+
+	events = nil
+	cobraflags.CobraOnInitialize("OBSAPP", cmd)
+	cmd.RunE = func(*cobra.Command, []string) error {
+		_, err := portFlag.GetIntE()
+		return err
+	}
+	cmd.SetArgs([]string{"--port", "8"})
+	c.Assert(cmd.Execute(), qt.ErrorIs, errTooLow)
+
+	var bound, set []string
+	var failed []cobraflags.Event
+	for _, e := range events {
+		switch e.Kind {
+		case cobraflags.EventBound:
+			bound = append(bound, e.Flag)
+		case cobraflags.EventSet:
+			set = append(set, e.Flag+"="+string(e.Source))
+		case cobraflags.EventValidationFailed:
+			failed = append(failed, e)
+		}
+	}
+	c.Assert(bound, qt.DeepEquals, []string{"host", "port", "mode"})
+	c.Assert(set, qt.DeepEquals, []string{"host=env", "port=flag"})
+	c.Assert(failed, qt.HasLen, 1)
+	c.Assert(failed[0].Command, qt.Equals, "obsapp")
+	c.Assert(failed[0].Flag, qt.Equals, "port")
+	c.Assert(failed[0].Err, qt.ErrorIs, errTooLow)
+
+	// Without an observer, nothing is sent.
+	cobraflags.SetObserver(nil)
+	events = nil
+	(&cobraflags.StringFlag{Name: "other"}).Register(cmd)
+	c.Assert(events, qt.HasLen, 0)
+}
+
+func TestSetObserver_Args(t *testing.T) {
+	c := qt.New(t)
+
+	var events []cobraflags.Event
+	cobraflags.SetObserver(cobraflags.ObserverFunc(func(e cobraflags.Event) {
+		if e.Kind == cobraflags.EventValidationFailed {
+			events = append(events, e)
+		}
+	}))
+	c.Cleanup(func() {
+		cobraflags.SetObserver(nil)
+	})
+
+	cmd := newCobraCommand()
+	(&cobraflags.Arg[int]{Name: "level", Validator: cobraflags.Range(1, 9)}).Register(cmd)
+	cmd.SetArgs([]string{"12"})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `argument "level": invalid value 12, must be between 1 and 9`)
+	c.Assert(events, qt.HasLen, 1)
+	c.Assert(events[0].Flag, qt.Equals, "level")
+	c.Assert(events[0].Err, qt.ErrorMatches, `invalid value 12, must be between 1 and 9`)
+}
+
+func TestEventKind_String(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(cobraflags.EventRegistered.String(), qt.Equals, "registered")
+	c.Assert(cobraflags.EventBound.String(), qt.Equals, "bound")
+	c.Assert(cobraflags.EventSet.String(), qt.Equals, "set")
+	c.Assert(cobraflags.EventValidationFailed.String(), qt.Equals, "validation-failed")
+	c.Assert(cobraflags.EventKind(42).String(), qt.Equals, "unknown")
+}
@@ -0,0 +1,150 @@
+package cobraflags
+
+// Option configures a flag of value type T created by one of the New*Flag constructors.
+// Options are typed, so that a default value or validation function of the wrong type
+// is rejected by the compiler. The type parameter of WithDefault and WithValidateFunc is
+// inferred from their argument; the other options must be instantiated with the flag's
+// value type, since Go infers type parameters from arguments only.
+//
+// Options that do not involve a value of type T, e.g. WithUsage or Persistent, are not
+// untyped for that reason: a constructor accepting both them and typed options would
+// need an option type without T, which the compiler could no longer check WithDefault
+// against. The Builder returned by NewString and its siblings is typed once instead.
+//
+// Example usage:
+//
+//	portFlag := cobraflags.NewIntFlag("port",
+//		cobraflags.WithShorthand[int]("p"),
+//		cobraflags.WithDefault(8080),
+//		cobraflags.WithUsage[int]("Server port"),
+//		cobraflags.Required[int](),
+//	)
+type Option[T any] func(*FlagBase[T])
+
+// WithShorthand sets the single character shorthand of the flag.
+func WithShorthand[T any](shorthand string) Option[T] {
+	return func(f *FlagBase[T]) {
+		f.Shorthand = shorthand
+	}
+}
+
+// WithUsage sets the help text of the flag.
+func WithUsage[T any](usage string) Option[T] {
+	return func(f *FlagBase[T]) {
+		f.Usage = usage
+	}
+}
+
+// WithDefault sets the default value of the flag.
+func WithDefault[T any](value T) Option[T] {
+	return func(f *FlagBase[T]) {
+		f.Value = value
+	}
+}
+
+// WithViperKey sets a custom Viper configuration key for the flag.
+func WithViperKey[T any](key string) Option[T] {
+	return func(f *FlagBase[T]) {
+		f.ViperKey = key
+	}
+}
+
+// WithEnvVar sets an explicit environment variable name for the flag.
+func WithEnvVar[T any](name string) Option[T] {
+	return func(f *FlagBase[T]) {
+		f.EnvVar = name
+	}
+}
+
+// WithValidator sets the Validator of the flag.
+func WithValidator[T any](v Validator) Option[T] {
+	return func(f *FlagBase[T]) {
+		f.Validator = v
+	}
+}
+
+// WithValidateFunc sets the typed validation function of the flag.
+func WithValidateFunc[T any](fn func(T) error) Option[T] {
+	return func(f *FlagBase[T]) {
+		f.ValidateFunc = fn
+	}
+}
+
+// WithValidationMode sets how the validation function and the Validator of the flag
+// combine when both are set.
+func WithValidationMode[T any](mode ValidationMode) Option[T] {
+	return func(f *FlagBase[T]) {
+		f.ValidationMode = mode
+	}
+}
+
+// Persistent makes the flag available to all subcommands.
+func Persistent[T any]() Option[T] {
+	return func(f *FlagBase[T]) {
+		f.Persistent = true
+	}
+}
+
+// WithFileCompletion makes shells complete the value of the flag with the files of the
+// given extensions, e.g. "yaml", and the directories leading to them.
+func WithFileCompletion[T any](extensions ...string) Option[T] {
+	return func(f *FlagBase[T]) {
+		f.FileExtensions = extensions
+	}
+}
+
+// WithDirCompletion makes shells complete the value of the flag with directories only.
+func WithDirCompletion[T any]() Option[T] {
+	return func(f *FlagBase[T]) {
+		f.DirCompletion = true
+	}
+}
+
+// Required marks the flag as required.
+func Required[T any]() Option[T] {
+	return func(f *FlagBase[T]) {
+		f.Required = true
+	}
+}
+
+// NewStringFlag creates a StringFlag with the given name and options.
+func NewStringFlag(name string, opts ...Option[string]) *StringFlag {
+	f := &StringFlag{Name: name}
+	applyOptions(pStringFlag(f), opts)
+	return f
+}
+
+// NewBoolFlag creates a BoolFlag with the given name and options.
+func NewBoolFlag(name string, opts ...Option[bool]) *BoolFlag {
+	f := &BoolFlag{Name: name}
+	applyOptions(pBoolFlag(f), opts)
+	return f
+}
+
+// NewIntFlag creates an IntFlag with the given name and options.
+func NewIntFlag(name string, opts ...Option[int]) *IntFlag {
+	f := &IntFlag{Name: name}
+	applyOptions(pIntFlag(f), opts)
+	return f
+}
+
+// NewUint8Flag creates a Uint8Flag with the given name and options.
+func NewUint8Flag(name string, opts ...Option[uint8]) *Uint8Flag {
+	f := &Uint8Flag{Name: name}
+	applyOptions(pUint8Flag(f), opts)
+	return f
+}
+
+// NewStringSliceFlag creates a StringSliceFlag with the given name and options.
+func NewStringSliceFlag(name string, opts ...Option[[]string]) *StringSliceFlag {
+	f := &StringSliceFlag{Name: name}
+	applyOptions(pStringSliceFlag(f), opts)
+	return f
+}
+
+// applyOptions applies the given options to the flag, in order.
+func applyOptions[T any](f *FlagBase[T], opts []Option[T]) {
+	for _, opt := range opts {
+		opt(f)
+	}
+}
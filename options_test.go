@@ -0,0 +1,89 @@
+package cobraflags_test
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestNewStringFlag_Options(t *testing.T) {
+	c := qt.New(t)
+
+	flag := cobraflags.NewStringFlag("config",
+		cobraflags.WithShorthand[string]("c"),
+		cobraflags.WithUsage[string]("Path to configuration file"),
+		cobraflags.WithDefault("config.yaml"),
+		cobraflags.WithViperKey[string]("app.config"),
+		cobraflags.WithEnvVar[string]("APP_CONFIG"),
+		cobraflags.Persistent[string](),
+		cobraflags.Required[string](),
+	)
+
+	c.Assert(flag.Name, qt.Equals, "config")
+	c.Assert(flag.Shorthand, qt.Equals, "c")
+	c.Assert(flag.Usage, qt.Equals, "Path to configuration file")
+	c.Assert(flag.Value, qt.Equals, "config.yaml")
+	c.Assert(flag.ViperKey, qt.Equals, "app.config")
+	c.Assert(flag.EnvVar, qt.Equals, "APP_CONFIG")
+	c.Assert(flag.Persistent, qt.IsTrue)
+	c.Assert(flag.Required, qt.IsTrue)
+}
+
+func TestNewIntFlag_Register(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	flag := cobraflags.NewIntFlag("count", cobraflags.WithDefault(3), cobraflags.WithShorthand[int]("n"))
+	flag.Register(cmd)
+
+	cmd.SetArgs([]string{"-n", "7"})
+	err := cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(flag.GetInt(), qt.Equals, 7)
+}
+
+func TestNewFlag_Validation(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	sliceFlag := cobraflags.NewStringSliceFlag("tags",
+		cobraflags.WithValidateFunc(func(tags []string) error {
+			if len(tags) == 0 {
+				return errors.New("no tags")
+			}
+			return nil
+		}),
+	)
+	boolFlag := cobraflags.NewBoolFlag("force",
+		cobraflags.WithValidator[bool](cobraflags.ValidatorFunc[bool](func(bool) error {
+			return errors.New("never forced")
+		})),
+	)
+	sliceFlag.Register(cmd)
+	boolFlag.Register(cmd)
+
+	err := cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	_, err = sliceFlag.GetStringSliceE()
+	c.Assert(err, qt.ErrorMatches, "no tags")
+	_, err = boolFlag.GetBoolE()
+	c.Assert(err, qt.ErrorMatches, "never forced")
+}
+
+func TestNewFlag_LaterOptionsWin(t *testing.T) {
+	c := qt.New(t)
+
+	flag := cobraflags.NewUint8Flag("level",
+		cobraflags.WithDefault[uint8](3),
+		cobraflags.WithUsage[uint8]("Level"),
+		cobraflags.WithDefault[uint8](5),
+	)
+
+	c.Assert(flag.Value, qt.Equals, uint8(5))
+	c.Assert(flag.Usage, qt.Equals, "Level")
+}
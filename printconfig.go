@@ -0,0 +1,151 @@
+package cobraflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+)
+
+// secretNameParts are the words, or runs of words, that mark a flag as holding a secret when
+// its name ends with them, see isSecret.
+var secretNameParts = [][]string{
+	{"password"}, {"passwd"}, {"secret"}, {"token"}, {"credential"}, {"credentials"},
+	{"api", "key"}, {"private", "key"}, {"secret", "key"},
+}
+
+// NewPrintConfigCommand adds a "print-config" subcommand to root that prints the effective
+// configuration of the command tree, after all sources (defaults, configuration files,
+// environment variables, ...) have been applied. The values of all flags registered through
+// cobraflags are printed as YAML (or JSON with --output json), nested by their Viper keys, so
+// the output has the shape of a configuration file.
+//
+// Values of secrets are redacted: values of sensitive flags (see FlagBase.Sensitive),
+// values read from secret files (see WithFileEnv and WithSecretsDir) and values of flags
+// whose name ends with a word like "password", "secret" or "token". The flags of
+// ConfigFileFlag and ConfirmFlag are left out, since they are no settings.
+//
+// With --non-default, only the values that differ from their defaults are printed,
+// see DiffFromDefaults.
+//
+// The command is returned so that it can be customized, e.g. renamed or hidden.
+//
+// Example:
+//
+//	cobraflags.NewPrintConfigCommand(rootCmd)
+//	// $ myapp print-config --output json
+func NewPrintConfigCommand(root *cobra.Command) *cobra.Command {
+	var output string
+	var nonDefault bool
+	cmd := &cobra.Command{
+		Use:   "print-config",
+		Short: "Print the effective configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return printConfig(cmd.OutOrStdout(), root, output, nonDefault)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "yaml", "Output format (yaml or json)")
+	cmd.Flags().BoolVar(&nonDefault, "non-default", false, "Print only values that differ from their defaults")
+	root.AddCommand(cmd)
+	return cmd
+}
+
+// printConfig writes the effective configuration of the tree rooted at root to w in the given
+// format, optionally only the values that differ from their defaults.
+func printConfig(w io.Writer, root *cobra.Command, format string, nonDefault bool) error {
+	config := effectiveConfig(root, nonDefault, false)
+	switch format {
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(config); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(config)
+	default:
+		return fmt.Errorf("invalid output format %q, must be yaml or json", format)
+	}
+}
+
+// effectiveConfig returns the effective values of the flags of the tree rooted at root,
+// nested by their Viper keys, with secrets redacted, or omitted if omitSecrets is set.
+// The first flag bound to a key wins. If nonDefault is set, values equal to their
+// defaults are omitted. The flags that are no settings, the one of ConfigFileFlag and
+// the ones of ConfirmFlag, are left out.
+func effectiveConfig(root *cobra.Command, nonDefault, omitSecrets bool) map[string]any {
+	config := make(map[string]any)
+	walkCommands(root, func(c *cobra.Command) {
+		for _, entry := range registeredOn(c) {
+			if f, _ := entry.base.identity(); f != nil && !isSetting(f) {
+				continue
+			}
+			info := entry.base.info()
+			if nonDefault && equalValues(info.Default, info.Value) {
+				continue
+			}
+			value := info.Value
+			if isSecret(info) {
+				if omitSecrets {
+					continue
+				}
+				value = redacted
+			}
+			setNested(config, strings.Split(info.ViperKey, "."), value)
+		}
+	})
+	return config
+}
+
+// isSetting reports whether f holds a setting of the program, unlike the flags registered
+// by ConfigFileFlag and ConfirmFlag.
+func isSetting(f *pflag.Flag) bool {
+	return len(f.Annotations[configFileAnnotation]) == 0 && len(f.Annotations[confirmAnnotation]) == 0
+}
+
+// setNested sets value under the key path in m, creating intermediate maps as needed.
+// Existing values are not overwritten.
+func setNested(m map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			if _, exists := m[key]; exists {
+				return
+			}
+			next = make(map[string]any)
+			m[key] = next
+		}
+		m = next
+	}
+	if _, exists := m[path[len(path)-1]]; !exists {
+		m[path[len(path)-1]] = value
+	}
+}
+
+// isSecret reports whether the value of a flag must not be printed: if it is sensitive, read
+// from a secret file, or named like a secret. Names are split into words at '-', '_' and
+// '.', and must end with one of secretNameParts, e.g. "db-password" or "api_key", so that
+// "max-tokens" and "token-bucket-size" are not taken for secrets.
+func isSecret(info FlagInfo) bool {
+	if info.Sensitive || info.Source == SourceFile {
+		return true
+	}
+	words := strings.FieldsFunc(strings.ToLower(info.Name), func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	})
+	for _, part := range secretNameParts {
+		if len(words) >= len(part) && slices.Equal(words[len(words)-len(part):], part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func newPrintConfigCommand(c *qt.C) *cobra.Command {
+	c.Setenv("PRINTAPP_DB_PASSWORD", "s3cret")
+	c.Setenv("PRINTAPP_PORT", "8080")
+
+	root := &cobra.Command{Use: "printapp", SilenceUsage: true, SilenceErrors: true}
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+
+	(&cobraflags.StringFlag{Name: "log-level", ViperKey: "log.level", Value: "info", Persistent: true}).Register(root)
+	(&cobraflags.StringFlag{Name: "db-password"}).Register(root)
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(serve)
+	(&cobraflags.StringSliceFlag{Name: "tags", Value: []string{"a", "b"}}).Register(serve)
+	cobraflags.NewPrintConfigCommand(root)
+	cobraflags.CobraOnInitialize("PRINTAPP", root)
+	return root
+}
+
+func TestNewPrintConfigCommand(t *testing.T) {
+	c := qt.New(t)
+
+	root := newPrintConfigCommand(c)
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetArgs([]string{"print-config", "--log-level", "debug"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `db-password: '***'
+log:
+  level: debug
+port: 8080
+tags:
+  - a
+  - b
+`)
+}
+
+func TestNewPrintConfigCommand_JSON(t *testing.T) {
+	c := qt.New(t)
+
+	root := newPrintConfigCommand(c)
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetArgs([]string{"print-config", "-o", "json"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `{
+  "db-password": "***",
+  "log": {
+    "level": "info"
+  },
+  "port": 8080,
+  "tags": [
+    "a",
+    "b"
+  ]
+}
+`)
+
+	buf.Reset()
+	root.SetArgs([]string{"print-config", "-o", "json", "--non-default"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `{
+  "db-password": "***",
+  "port": 8080
+}
+`)
+
+	root.SetArgs([]string{"print-config", "-o", "toml"})
+	c.Assert(root.Execute(), qt.ErrorMatches, `invalid output format "toml", must be yaml or json`)
+}
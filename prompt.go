@@ -0,0 +1,117 @@
+package cobraflags
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// promptAnnotation marks the commands whose missing required flags are prompted for,
+// see EnablePrompting.
+const promptAnnotation = "cobraflags-prompt"
+
+// EnablePrompting makes cmd and its subcommands prompt for required flags that were not
+// set by any source, instead of failing. The prompt is written to the command's error
+// output (see cobra.Command.SetErr), and the answer is read from its input, just before
+// the Run function executes. The values of secrets, i.e. flags marked Sensitive and flags
+// whose name ends with "password", "secret" or "token" (see FlagBase.Sensitive), are read
+// from terminals without echo.
+//
+// Prompting only happens if the input is a terminal, or a reader that is not a file, set
+// with cobra.Command.SetIn, e.g. by tests or hosts embedding the command. Scripts and CI
+// jobs with redirected input still get the error naming the ways to set the flag, as they
+// do when the answer is empty. Prompted values report SourcePrompt as their source.
+//
+// EnablePrompting requires CobraOnInitialize, which checks the required flags.
+//
+// Example:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd)
+//	cobraflags.EnablePrompting(rootCmd)
+func EnablePrompting(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[promptAnnotation] = "true"
+}
+
+// promptingEnabled reports whether EnablePrompting was called for cmd or one of its ancestors.
+func promptingEnabled(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[promptAnnotation] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// prompter asks for the values of the missing required flags of a command, see EnablePrompting.
+type prompter struct {
+	cmd    *cobra.Command
+	in     io.Reader
+	reader *bufio.Reader
+}
+
+// newPrompter returns a prompter for the missing required flags of cmd, or nil if cmd does
+// not prompt for them or its input is not interactive.
+func newPrompter(cmd *cobra.Command) *prompter {
+	if !promptingEnabled(cmd) {
+		return nil
+	}
+	in, ok := interactiveInput(cmd)
+	if !ok {
+		return nil
+	}
+	return &prompter{cmd: cmd, in: in, reader: bufio.NewReader(in)}
+}
+
+// interactiveInput returns the input of cmd and whether a user may answer prompts on it:
+// it is a terminal, or a reader that is not a file, set with cobra.Command.SetIn.
+func interactiveInput(cmd *cobra.Command) (io.Reader, bool) {
+	in := cmd.InOrStdin()
+	if f, ok := in.(*os.File); ok && !term.IsTerminal(int(f.Fd())) {
+		return in, false
+	}
+	return in, true
+}
+
+// prompt asks for the value of the required flag f and presets it. It returns the
+// required flag error if the answer is empty or cannot be read.
+func (p *prompter) prompt(f *pflag.Flag) error {
+	label := "--" + f.Name
+	if f.Usage != "" {
+		label += " (" + f.Usage + ")"
+	}
+	out := p.cmd.ErrOrStderr()
+	_, _ = fmt.Fprintf(out, "%s: ", label)
+
+	var value string
+	var err error
+	if file, ok := p.in.(*os.File); ok && secretFlag(f) {
+		var b []byte
+		b, err = term.ReadPassword(int(file.Fd()))
+		_, _ = fmt.Fprintln(out)
+		value = string(b)
+	} else {
+		value, err = p.reader.ReadString('\n')
+		if err == io.EOF && value != "" {
+			err = nil
+		}
+	}
+	value = strings.TrimRight(value, "\r\n")
+	if err != nil || value == "" {
+		return requiredError(p.cmd, f)
+	}
+
+	if err := presetValue(p.cmd.Flags(), f, value); err != nil {
+		return fmt.Errorf("required flag %q: %w", f.Name, err)
+	}
+	setAnnotation(f, sourceAnnotation, string(SourcePrompt))
+	return nil
+}
@@ -0,0 +1,92 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestEnablePrompting(t *testing.T) {
+	c := qt.New(t)
+
+	root := newRequiredCommand()
+	cobraflags.EnablePrompting(root)
+
+	var prompts bytes.Buffer
+	root.SetErr(&prompts)
+	root.SetIn(strings.NewReader("8080\nsecret\n"))
+	root.SetArgs([]string{"serve"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(prompts.String(), qt.Equals, "--port: --token: ")
+
+	serve, _, err := root.Find([]string{"serve"})
+	c.Assert(err, qt.IsNil)
+	port, ok := cobraflags.Lookup(serve, "port")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(port.(*cobraflags.IntFlag).GetInt(), qt.Equals, 8080)
+	token, _ := cobraflags.Lookup(serve, "token")
+	c.Assert(token.(*cobraflags.StringFlag).GetString(), qt.Equals, "secret")
+	c.Assert(cobraflags.FlagsOf(root)[0].Source, qt.Equals, cobraflags.SourcePrompt)
+}
+
+func TestEnablePrompting_NoAnswer(t *testing.T) {
+	c := qt.New(t)
+
+	root := newRequiredCommand()
+	cobraflags.EnablePrompting(root)
+
+	var prompts bytes.Buffer
+	root.SetErr(&prompts)
+	root.SetIn(strings.NewReader("\n"))
+	root.SetArgs([]string{"serve", "--port", "80"})
+	c.Assert(root.Execute(), qt.ErrorMatches, `required flag "token" not set, .*`)
+	c.Assert(prompts.String(), qt.Equals, "--token: ")
+}
+
+func TestEnablePrompting_InvalidAnswer(t *testing.T) {
+	c := qt.New(t)
+
+	root := newRequiredCommand()
+	cobraflags.EnablePrompting(root)
+
+	root.SetErr(&bytes.Buffer{})
+	root.SetIn(strings.NewReader("eighty\n"))
+	root.SetArgs([]string{"serve", "--token", "x"})
+	c.Assert(root.Execute(), qt.ErrorMatches, `required flag "port": .*invalid syntax`)
+}
+
+func TestEnablePrompting_NotTerminal(t *testing.T) {
+	c := qt.New(t)
+
+	// Redirected input is not prompted.
+	path := filepath.Join(c.TempDir(), "input")
+	c.Assert(os.WriteFile(path, []byte("secret\n"), 0o600), qt.IsNil)
+	in, err := os.Open(path)
+	c.Assert(err, qt.IsNil)
+	defer in.Close()
+
+	root := newRequiredCommand()
+	cobraflags.EnablePrompting(root)
+
+	var prompts bytes.Buffer
+	root.SetErr(&prompts)
+	root.SetIn(in)
+	root.SetArgs([]string{"serve", "--port", "80"})
+	c.Assert(root.Execute(), qt.ErrorMatches, `required flag "token" not set, .*`)
+	c.Assert(prompts.String(), qt.Equals, "")
+}
+
+func TestEnablePrompting_Disabled(t *testing.T) {
+	c := qt.New(t)
+
+	root := newRequiredCommand()
+	root.SetIn(strings.NewReader("secret\n"))
+	root.SetArgs([]string{"serve", "--port", "80"})
+	c.Assert(root.Execute(), qt.ErrorMatches, `required flag "token" not set, .*`)
+}
@@ -0,0 +1,311 @@
+package cobraflags
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Source describes where the effective value of a flag comes from.
+type Source string
+
+const (
+	SourceDefault Source = "default" // The flag's default value
+	SourceFlag    Source = "flag"    // The command line
+	SourceEnv     Source = "env"     // An environment variable
+	SourceConfig  Source = "config"  // A configuration file read into Viper
+	SourceViper   Source = "viper"   // A value set directly on the Viper instance
+	SourceFile    Source = "file"    // A secret file, see WithFileEnv and WithSecretsDir
+	SourcePrompt  Source = "prompt"  // An answer to a prompt, see EnablePrompting
+	SourceDerived Source = "derived" // Computed from other flags, see FlagBase.DefaultFunc
+)
+
+// FlagInfo describes a registered flag together with its effective value.
+type FlagInfo struct {
+	Name       string `json:"name" yaml:"name"`                                 // Flag name used for command line arguments
+	Shorthand  string `json:"shorthand,omitempty" yaml:"shorthand,omitempty"`   // Single character shorthand, if any
+	Type       string `json:"type" yaml:"type"`                                 // pflag type name, e.g. "string" or "stringSlice"
+	Usage      string `json:"usage,omitempty" yaml:"usage,omitempty"`           // Help text as registered (without the env annotation)
+	Default    any    `json:"default" yaml:"default"`                           // Default value
+	Value      any    `json:"value" yaml:"value"`                               // Current effective value
+	ViperKey   string `json:"viperKey" yaml:"viperKey"`                         // Viper configuration key
+	EnvVar     string `json:"envVar,omitempty" yaml:"envVar,omitempty"`         // Environment variable name (empty until CobraOnInitialize ran, unless set explicitly)
+	Required   bool   `json:"required,omitempty" yaml:"required,omitempty"`     // Whether the flag is required
+	Persistent bool   `json:"persistent,omitempty" yaml:"persistent,omitempty"` // Whether the flag is persistent across subcommands
+	Hidden     bool   `json:"hidden,omitempty" yaml:"hidden,omitempty"`         // Whether the flag is hidden from help output
+	Sensitive  bool   `json:"sensitive,omitempty" yaml:"sensitive,omitempty"`   // Whether the value is a secret, see FlagBase.Sensitive
+	Source     Source `json:"source" yaml:"source"`                             // Where the effective value comes from
+	Enum       []any  `json:"enum,omitempty" yaml:"enum,omitempty"`             // Allowed values, if restricted with OneOf
+}
+
+// registeredFlag is implemented by *FlagBase[T] to let package-level functions
+// operate on registered flags regardless of their value type.
+type registeredFlag interface {
+	info() FlagInfo
+	freeze()
+	unfreeze()
+	isFrozen() bool
+	notifyChange()
+	publish()
+	unpublish()
+	identity() (*pflag.Flag, any)
+	enum() []any
+	bindInto(cmd *cobra.Command, st *store) error
+	resolveDefault(d *Defaults) error
+	current() (any, error)
+}
+
+// registryEntry is a flag registered on a command.
+type registryEntry struct {
+	name       string
+	persistent bool
+	flag       Flag
+	base       registeredFlag
+	site       string // the source location of the registration, see registrationSite
+}
+
+// registry stores the flags registered on every command, in registration order.
+var registry = make(map[*cobra.Command][]registryEntry)
+var registryMutex sync.RWMutex
+
+// addToRegistry records a flag registered on cmd.
+func addToRegistry(cmd *cobra.Command, entry registryEntry) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registry[cmd] = append(registry[cmd], entry)
+}
+
+// registeredOn returns a copy of the registry entries of cmd.
+func registeredOn(cmd *cobra.Command) []registryEntry {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	return append([]registryEntry(nil), registry[cmd]...)
+}
+
+// siteOf returns the source location where the flag named name was registered on cmd,
+// or "" if it was not registered through cobraflags.
+func siteOf(cmd *cobra.Command, name string) string {
+	for _, entry := range registeredOn(cmd) {
+		if entry.name == name {
+			return entry.site
+		}
+	}
+	return ""
+}
+
+// FlagsOf returns information about all flags registered on cmd through cobraflags,
+// in registration order. Flags inherited from parent commands are not included;
+// call FlagsOf on the parent to get them.
+//
+// This enables documentation generation, debug commands and configuration audits
+// without reflection. Values and sources reflect the state at the time of the call,
+// so FlagsOf is most useful after the command has been executed.
+//
+// Example:
+//
+//	for _, info := range cobraflags.FlagsOf(cmd) {
+//		fmt.Printf("%s=%v (from %s)\n", info.Name, info.Value, info.Source)
+//	}
+func FlagsOf(cmd *cobra.Command) []FlagInfo {
+	entries := registeredOn(cmd)
+	infos := make([]FlagInfo, 0, len(entries))
+	for _, entry := range entries {
+		infos = append(infos, entry.base.info())
+	}
+	return infos
+}
+
+// Lookup returns the cobraflags wrapper of the flag with the given name that is available
+// to cmd: a flag registered on cmd itself, or a persistent flag registered on one of its
+// ancestors. This gives middleware and helpers access to typed getters and validation
+// of flags they did not define themselves. The getters of another value type than the
+// flag's fail with ErrFlagType; use LookupAs to get the flag with its concrete type.
+//
+// Example:
+//
+//	if flag, ok := cobraflags.Lookup(cmd, "port"); ok {
+//		port, err := flag.GetIntE()
+//		...
+//	}
+func Lookup(cmd *cobra.Command, name string) (Flag, bool) {
+	for _, entry := range registeredOn(cmd) {
+		if entry.name == name {
+			return entry.flag, true
+		}
+	}
+	for c := cmd.Parent(); c != nil; c = c.Parent() {
+		for _, entry := range registeredOn(c) {
+			if entry.name == name && entry.persistent {
+				return entry.flag, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// LookupAs returns the flag with the given name that is available to cmd, like Lookup,
+// if it is of type F. It returns false if there is no such flag, or if it has another type.
+//
+// Example:
+//
+//	if portFlag, ok := cobraflags.LookupAs[*cobraflags.IntFlag](cmd, "port"); ok {
+//		port, err := portFlag.GetIntE()
+//		...
+//	}
+func LookupAs[F Flag](cmd *cobra.Command, name string) (F, bool) {
+	flag, ok := Lookup(cmd, name)
+	if !ok {
+		var zero F
+		return zero, false
+	}
+	typed, ok := flag.(F)
+	return typed, ok
+}
+
+// mismatchedGetter provides the getters of the value types a flag does not have, which
+// are promoted to the flag types through the embedded flagGetter and flagGetterE.
+type mismatchedGetter struct {
+	name string
+	typ  string
+}
+
+// mismatch returns the getters of the value types other than that of flag f named name.
+func mismatch(name string, f *pflag.Flag) mismatchedGetter {
+	return mismatchedGetter{name: name, typ: f.Value.Type()}
+}
+
+// err returns the error reported when the flag is read as a value of type want.
+func (g mismatchedGetter) err(want string) error {
+	return fmt.Errorf("%w: flag %q has type %s, cannot read it as %s", ErrFlagType, g.name, g.typ, want)
+}
+
+func (g mismatchedGetter) GetString() string        { panic(g.err("string")) }
+func (g mismatchedGetter) GetBool() bool            { panic(g.err("bool")) }
+func (g mismatchedGetter) GetInt() int              { panic(g.err("int")) }
+func (g mismatchedGetter) GetUint8() uint8          { panic(g.err("uint8")) }
+func (g mismatchedGetter) GetStringSlice() []string { panic(g.err("stringSlice")) }
+
+func (g mismatchedGetter) GetStringE() (string, error)        { return "", g.err("string") }
+func (g mismatchedGetter) GetBoolE() (bool, error)            { return false, g.err("bool") }
+func (g mismatchedGetter) GetIntE() (int, error)              { return 0, g.err("int") }
+func (g mismatchedGetter) GetUint8E() (uint8, error)          { return 0, g.err("uint8") }
+func (g mismatchedGetter) GetStringSliceE() ([]string, error) { return nil, g.err("stringSlice") }
+
+// info describes the flag and its current effective value.
+func (s *FlagBase[T]) info() FlagInfo {
+	s.mu.RLock()
+	flag, read := s.flag, s.read
+	s.mu.RUnlock()
+
+	value := s.get(read)
+
+	return FlagInfo{
+		Name:       s.Name,
+		Shorthand:  s.Shorthand,
+		Type:       flag.Value.Type(),
+		Usage:      s.Usage,
+		Default:    s.Value,
+		Value:      value,
+		ViperKey:   s.getViperKey(),
+		EnvVar:     envVarOf(flag),
+		Required:   s.Required,
+		Persistent: s.Persistent,
+		Hidden:     flag.Hidden,
+		Sensitive:  s.Sensitive,
+		Source:     s.source(),
+		Enum:       s.enum(),
+	}
+}
+
+// enum returns the values allowed by the flag's validator, if it is a OneOf validator.
+func (s *FlagBase[T]) enum() []any {
+	if s.ValidateFunc != nil && s.ValidationMode == ValidationFuncOnly {
+		return nil // Validator is ignored.
+	}
+	if e, ok := s.Validator.(enumerator); ok {
+		return e.enum()
+	}
+	return nil
+}
+
+// source returns where the effective value of the flag comes from.
+func (s *FlagBase[T]) source() Source {
+	st, viperKey, err := s.bind()
+	if err != nil {
+		return SourceDefault
+	}
+
+	s.mu.RLock()
+	flag := s.flag
+	s.mu.RUnlock()
+
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	return sourceOf(st.values(), flag, viperKey)
+}
+
+// envVarOf returns the environment variable a flag is bound to: the one resolved
+// by CobraOnInitialize, or the explicit EnvVar if initialization has not run yet.
+func envVarOf(f *pflag.Flag) string {
+	if annotations := f.Annotations[resolvedEnvVarAnnotation]; len(annotations) > 0 {
+		return annotations[0]
+	}
+	if annotations := f.Annotations[envVarAnnotation]; len(annotations) > 0 {
+		return annotations[0]
+	}
+	return ""
+}
+
+// sourceOf determines where the effective value of a flag comes from.
+// Values preset by CobraOnInitialize carry their source as an annotation, since
+// presetting marks the flag as changed; otherwise the Viper lookup order applies.
+func sourceOf(b backend, f *pflag.Flag, viperKey string) Source {
+	derived := false
+	if annotations := f.Annotations[sourceAnnotation]; len(annotations) > 0 {
+		if Source(annotations[0]) != SourceDerived {
+			return Source(annotations[0])
+		}
+		derived = true // Unless set since, see resolveDefault.
+	}
+	if f.Changed {
+		return SourceFlag
+	}
+	if !b.IsSet(viperKey) {
+		if derived {
+			return SourceDerived
+		}
+		return SourceDefault
+	}
+	if envVar := envVarOf(f); envVar != "" {
+		if value, ok := os.LookupEnv(envVar); ok && value != "" {
+			return SourceEnv
+		}
+	}
+	if b.InConfig(viperKey) {
+		return SourceConfig
+	}
+	return SourceViper
+}
+
+// setAnnotation sets a single-valued annotation on a flag.
+func setAnnotation(f *pflag.Flag, key, value string) {
+	if f.Annotations == nil {
+		f.Annotations = make(map[string][]string)
+	}
+	f.Annotations[key] = []string{value}
+}
+
+// setAnnotation sets the annotation key of the registered pflag.Flag of s to value.
+func (s *FlagBase[T]) setAnnotation(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.flag != nil {
+		setAnnotation(s.flag, key, value)
+	}
+}
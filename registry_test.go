@@ -0,0 +1,181 @@
+package cobraflags_test
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestFlagsOf(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("INFOAPP_HOST", "example.com")
+
+	cmd := newCobraCommand()
+	hostFlag := &cobraflags.StringFlag{Name: "host", Usage: "Host", Value: "localhost"}
+	portFlag := &cobraflags.IntFlag{Name: "port", Shorthand: "p", ViperKey: "server.port", Value: 80, Required: true}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags", Value: []string{"a"}, Persistent: true}
+	debugFlag := &cobraflags.BoolFlag{Name: "debug"}
+	cobraflags.Register(cmd, hostFlag, portFlag, tagsFlag, debugFlag)
+	c.Assert(cmd.Flags().MarkHidden("debug"), qt.IsNil)
+	cobraflags.CobraOnInitialize("INFOAPP", cmd)
+
+	cmd.SetArgs([]string{"-p", "8080"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	infos := cobraflags.FlagsOf(cmd)
+	c.Assert(infos, qt.DeepEquals, []cobraflags.FlagInfo{
+		{
+			Name:     "host",
+			Type:     "string",
+			Usage:    "Host",
+			Default:  "localhost",
+			Value:    "example.com",
+			ViperKey: "host",
+			EnvVar:   "INFOAPP_HOST",
+			Source:   cobraflags.SourceEnv,
+		},
+		{
+			Name:      "port",
+			Shorthand: "p",
+			Type:      "int",
+			Default:   80,
+			Value:     8080,
+			ViperKey:  "server.port",
+			EnvVar:    "INFOAPP_SERVER_PORT",
+			Required:  true,
+			Source:    cobraflags.SourceFlag,
+		},
+		{
+			Name:       "tags",
+			Type:       "stringSlice",
+			Default:    []string{"a"},
+			Value:      []string{"a"},
+			ViperKey:   "tags",
+			EnvVar:     "INFOAPP_TAGS",
+			Persistent: true,
+			Source:     cobraflags.SourceDefault,
+		},
+		{
+			Name:     "debug",
+			Type:     "bool",
+			Default:  false,
+			Value:    false,
+			ViperKey: "debug",
+			EnvVar:   "INFOAPP_DEBUG",
+			Hidden:   true,
+			Source:   cobraflags.SourceDefault,
+		},
+	})
+}
+
+func TestFlagsOf_ViperSource(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	flag := &cobraflags.Uint8Flag{Name: "level", Value: 1}
+	flag.Register(cmd)
+	cobraflags.ViperFor(cmd).Set("level", 5)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	infos := cobraflags.FlagsOf(cmd)
+	c.Assert(infos, qt.HasLen, 1)
+	c.Assert(infos[0].Value, qt.Equals, uint8(5))
+	c.Assert(infos[0].Source, qt.Equals, cobraflags.SourceViper)
+	c.Assert(infos[0].EnvVar, qt.Equals, "")
+}
+
+func TestFlagsOf_PerCommand(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "sub"}
+	root.AddCommand(sub)
+	(&cobraflags.StringFlag{Name: "root-flag"}).Register(root)
+	(&cobraflags.StringFlag{Name: "sub-flag"}).Register(sub)
+
+	c.Assert(cobraflags.FlagsOf(root), qt.HasLen, 1)
+	c.Assert(cobraflags.FlagsOf(sub), qt.HasLen, 1)
+	c.Assert(cobraflags.FlagsOf(sub)[0].Name, qt.Equals, "sub-flag")
+	c.Assert(cobraflags.FlagsOf(&cobra.Command{}), qt.HasLen, 0)
+}
+
+func TestLookup(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+
+	verboseFlag := &cobraflags.BoolFlag{Name: "verbose", Persistent: true}
+	localFlag := &cobraflags.StringFlag{Name: "local", Value: "root-only"}
+	portFlag := &cobraflags.IntFlag{
+		Name:  "port",
+		Value: 80,
+		ValidateFunc: func(port int) error {
+			if port < 1024 {
+				return errors.New("privileged port")
+			}
+			return nil
+		},
+	}
+	cobraflags.Register(root, verboseFlag, localFlag)
+	portFlag.Register(sub)
+
+	root.SetArgs([]string{"sub", "--verbose"})
+	c.Assert(root.Execute(), qt.IsNil)
+
+	flag, ok := cobraflags.Lookup(sub, "port")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(flag, qt.Equals, cobraflags.Flag(portFlag))
+	_, err := flag.GetIntE()
+	c.Assert(err, qt.ErrorMatches, "privileged port")
+
+	flag, ok = cobraflags.Lookup(sub, "verbose")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(flag.GetBool(), qt.IsTrue)
+
+	_, ok = cobraflags.Lookup(sub, "local")
+	c.Assert(ok, qt.IsFalse)
+
+	_, ok = cobraflags.Lookup(root, "local")
+	c.Assert(ok, qt.IsTrue)
+
+	_, ok = cobraflags.Lookup(sub, "missing")
+	c.Assert(ok, qt.IsFalse)
+}
+
+func TestLookupAs(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 8080, Persistent: true}
+	portFlag.Register(root)
+
+	root.SetArgs([]string{"sub", "--port", "9090"})
+	c.Assert(root.Execute(), qt.IsNil)
+
+	found, ok := cobraflags.LookupAs[*cobraflags.IntFlag](sub, "port")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(found, qt.Equals, portFlag)
+	c.Assert(found.GetInt(), qt.Equals, 9090)
+
+	_, ok = cobraflags.LookupAs[*cobraflags.StringFlag](sub, "port")
+	c.Assert(ok, qt.IsFalse)
+	_, ok = cobraflags.LookupAs[*cobraflags.IntFlag](sub, "missing")
+	c.Assert(ok, qt.IsFalse)
+
+	// Reading the flag as another type fails instead of dereferencing nil.
+	flag, _ := cobraflags.Lookup(sub, "port")
+	_, err := flag.GetStringE()
+	c.Assert(err, qt.ErrorIs, cobraflags.ErrFlagType)
+	c.Assert(err, qt.ErrorMatches, `flag type mismatch: flag "port" has type int, cannot read it as string`)
+	c.Assert(func() { flag.GetBool() }, qt.PanicMatches, `flag type mismatch: flag "port" has type int, cannot read it as bool`)
+}
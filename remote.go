@@ -0,0 +1,146 @@
+package cobraflags
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// remoteConfig describes a remote key/value store to read configuration from, see WithRemoteConfig.
+type remoteConfig struct {
+	provider string
+	endpoint string
+	path     string
+	format   string
+	keys     map[string]bool // The keys set by the last read, guarded by the store's lock.
+}
+
+// WithRemoteConfig makes CobraOnInitialize read the configuration stored under path in
+// a remote key/value store (e.g. etcd or Consul) before flags are preset. The provider
+// and endpoint are passed to viper's AddRemoteProvider, and format names the encoding
+// of the stored value, e.g. "json" or "yaml".
+//
+// Remote values serve as defaults of the command tree's Viper instance, so they are
+// resolved with the precedence flag default < remote store < config file < environment
+// variable < command line. Their source is reported as SourceViper. If the store cannot
+// be read, the command execution fails. Use WatchRemoteConfig to re-read the store periodically.
+//
+// Remote providers are only available if the program imports viper's remote package:
+//
+//	import _ "github.com/spf13/viper/remote"
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd,
+//		cobraflags.WithRemoteConfig("etcd3", "http://127.0.0.1:2379", "/config/myapp.json", "json"))
+func WithRemoteConfig(provider, endpoint, path, format string) InitOption {
+	return func(c *initConfig) {
+		c.remoteConfig = &remoteConfig{provider: provider, endpoint: endpoint, path: path, format: format}
+	}
+}
+
+// readRemoteConfig reads the remote configuration described by rc into the Viper
+// instance of cmd's command tree. It returns nil if rc is nil.
+func readRemoteConfig(cmd *cobra.Command, rc *remoteConfig) error {
+	if rc == nil {
+		return nil
+	}
+
+	st := storeFor(cmd)
+	rv, err := rc.fetch(st.caseSensitive.Load())
+	if err != nil {
+		return fmt.Errorf("reading remote config: %w", err)
+	}
+
+	st.lock()
+	defer st.mu.Unlock()
+
+	st.remote = rc
+	rc.apply(st.v, rv)
+	return nil
+}
+
+// fetch reads the remote configuration into a Viper instance of its own,
+// so that the network round trip does not hold the store's lock. If escape
+// is set, the keys are escaped, see WithCaseSensitiveKeys.
+func (rc *remoteConfig) fetch(escape bool) (*viper.Viper, error) {
+	rv := newViper(func() bool { return escape })
+	rv.SetConfigType(rc.format)
+	if err := rv.AddRemoteProvider(rc.provider, rc.endpoint, rc.path); err != nil {
+		return nil, err
+	}
+	if err := rv.ReadRemoteConfig(); err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// apply sets the values read into rv as defaults of v, and removes the keys
+// set by the previous read that are no longer present.
+// The caller must hold the write lock of v's store.
+func (rc *remoteConfig) apply(v, rv *viper.Viper) {
+	keys := make(map[string]bool)
+	for _, key := range rv.AllKeys() {
+		v.SetDefault(key, rv.Get(key))
+		keys[key] = true
+	}
+	for key := range rc.keys {
+		if !keys[key] {
+			v.SetDefault(key, nil) // Viper skips nil defaults.
+		}
+	}
+	rc.keys = keys
+}
+
+// WatchRemoteConfig re-reads the remote configuration of cmd's command tree (see
+// WithRemoteConfig) every interval, until the returned function is called. Changes
+// are applied as with WatchConfig: flags whose value came from the store are preset
+// again, and the OnChange hooks and onChange are called for the flags that changed.
+// Failed reads are ignored, and the previous values stay in effect.
+//
+// WatchRemoteConfig must be called after initialization. It does nothing if no
+// remote configuration was read.
+//
+// Example:
+//
+//	stop := cobraflags.WatchRemoteConfig(cmd, time.Minute, nil)
+//	defer stop()
+func WatchRemoteConfig(cmd *cobra.Command, interval time.Duration, onChange func(changed []string)) (stop func()) {
+	root := cmd.Root()
+	st := storeFor(root)
+	st.mu.RLock()
+	rc := st.remote
+	st.mu.RUnlock()
+
+	done := make(chan struct{})
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(done) })
+	}
+	if rc == nil {
+		return stop
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				rv, err := rc.fetch(st.caseSensitive.Load())
+				if err != nil {
+					continue
+				}
+				reloadConfig(root, func(v *viper.Viper) error {
+					rc.apply(v, rv)
+					return nil
+				}, onChange)
+			}
+		}
+	}()
+	return stop
+}
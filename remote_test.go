@@ -0,0 +1,121 @@
+package cobraflags_test
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/viper"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// fakeRemote serves remote configurations from memory, keyed by path.
+type fakeRemote struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (f *fakeRemote) set(path, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[path] = content
+}
+
+func (f *fakeRemote) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return strings.NewReader(f.data[rp.Path()]), nil
+}
+
+func (f *fakeRemote) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	return f.Get(rp)
+}
+
+func (f *fakeRemote) WatchChannel(viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	return nil, nil
+}
+
+func installFakeRemote(c *qt.C) *fakeRemote {
+	remote := &fakeRemote{data: make(map[string]string)}
+	previous := viper.RemoteConfig
+	viper.RemoteConfig = remote
+	c.Cleanup(func() { viper.RemoteConfig = previous })
+	return remote
+}
+
+func TestWithRemoteConfig(t *testing.T) {
+	c := qt.New(t)
+	remote := installFakeRemote(c)
+	remote.set("/config/app.json", `{"port": 9000, "host": "remote.example.com", "name": "remote"}`)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "host: config.example.com\n")
+
+	cmd := newCobraCommand()
+	var names []string
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	hostFlag := &cobraflags.StringFlag{Name: "host", Value: "localhost"}
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default", OnChange: func(name string) {
+		names = append(names, name)
+	}}
+	cobraflags.Register(cmd, portFlag, hostFlag, nameFlag)
+	cobraflags.CobraOnInitialize("REMOTEAPP", cmd,
+		cobraflags.WithConfigFile("config", "yaml", dir),
+		cobraflags.WithRemoteConfig("etcd3", "http://127.0.0.1:2379", "/config/app.json", "json"))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9000)
+	c.Assert(hostFlag.GetString(), qt.Equals, "config.example.com")
+	c.Assert(nameFlag.GetString(), qt.Equals, "remote")
+
+	changes := make(chan []string, 10)
+	stop := cobraflags.WatchRemoteConfig(cmd, 10*time.Millisecond, func(changed []string) {
+		changes <- changed
+	})
+	defer stop()
+
+	remote.set("/config/app.json", `{"port": 9001, "host": "remote.example.com"}`)
+
+	select {
+	case changed := <-changes:
+		c.Assert(changed, qt.DeepEquals, []string{"port", "name"})
+	case <-time.After(10 * time.Second):
+		c.Fatal("timed out waiting for the remote config reload")
+	}
+	stop()
+
+	c.Assert(portFlag.GetInt(), qt.Equals, 9001)
+	c.Assert(hostFlag.GetString(), qt.Equals, "config.example.com")
+	c.Assert(nameFlag.GetString(), qt.Equals, "default")
+	c.Assert(names, qt.DeepEquals, []string{"default"})
+}
+
+func TestWithRemoteConfig_Errors(t *testing.T) {
+	c := qt.New(t)
+	installFakeRemote(c)
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	cobraflags.CobraOnInitialize("REMOTEAPP", cmd,
+		cobraflags.WithRemoteConfig("zookeeper", "127.0.0.1:2181", "/config/app.json", "json"))
+
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `reading remote config: Unsupported Remote Provider Type "zookeeper"`)
+}
+
+func TestWatchRemoteConfig_NoRemoteConfig(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	cobraflags.CobraOnInitialize("REMOTEAPP", cmd)
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	stop := cobraflags.WatchRemoteConfig(cmd, time.Millisecond, func([]string) {
+		c.Error("unexpected change notification")
+	})
+	stop()
+	stop()
+}
@@ -0,0 +1,162 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// renamedFromAnnotation lists the former names of a flag, see FlagBase.RenamedFrom.
+const renamedFromAnnotation = "cobraflags-renamed-from"
+
+// ErrRenamedFlagConflict is returned during initialization when a renamed flag (see
+// FlagBase.RenamedFrom) is set under both its current and a former name: on the command
+// line, in the environment or in the configuration file.
+var ErrRenamedFlagConflict = errors.New("flag set under its current and its former name")
+
+// warnedRenamedKeys holds the former configuration keys that have been warned about.
+var warnedRenamedKeys sync.Map
+
+// renamedValue is the value of a flag defined under a former name. It keeps the arguments
+// given on the command line, which initialization applies to the flag itself, see
+// presetRenamedFlag.
+type renamedValue struct {
+	pflag.Value
+	args []string
+}
+
+// Set parses the argument like the flag itself would, and keeps it.
+func (v *renamedValue) Set(s string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	v.args = append(v.args, s)
+	return nil
+}
+
+// checkRenamed returns an error if a former name of the flag is already used by another
+// flag of cmd.
+func (s *FlagBase[T]) checkRenamed(cmd *cobra.Command) error {
+	for _, old := range s.RenamedFrom {
+		if old == s.Name || cmd.Flags().Lookup(old) != nil || cmd.PersistentFlags().Lookup(old) != nil {
+			return fmt.Errorf("%w: %q (former name of %q) on command %q", ErrDuplicateFlag, old, s.Name, cmd.Name())
+		}
+	}
+	return nil
+}
+
+// defineRenamed defines a hidden flag of the same type for each former name of the flag.
+// pflag reports them as deprecated when they are used.
+func (s *FlagBase[T]) defineRenamed(flags *pflag.FlagSet, define func(flags *pflag.FlagSet)) {
+	for _, old := range s.RenamedFrom {
+		probe := pflag.NewFlagSet(old, pflag.ContinueOnError)
+		define(probe)
+		f := probe.Lookup(s.Name)
+		flags.AddFlag(&pflag.Flag{
+			Name:        old,
+			Usage:       f.Usage,
+			Value:       &renamedValue{Value: f.Value},
+			DefValue:    f.DefValue,
+			NoOptDefVal: f.NoOptDefVal,
+			Hidden:      true,
+			Deprecated:  fmt.Sprintf("use --%s instead", s.Name),
+			Annotations: map[string][]string{noEnvAnnotation: {"true"}},
+		})
+	}
+}
+
+// presetRenamedFlag applies the arguments given on the command line for the former names
+// of f to f, unless f was given as well, which is a conflict.
+func presetRenamedFlag(flags *pflag.FlagSet, f *pflag.Flag) error {
+	for _, old := range f.Annotations[renamedFromAnnotation] {
+		alias := flags.Lookup(old)
+		if alias == nil || !alias.Changed {
+			continue
+		}
+		if f.Changed {
+			return fmt.Errorf("%w: --%s and --%s, use only --%s", ErrRenamedFlagConflict, old, f.Name, f.Name)
+		}
+		value, ok := alias.Value.(*renamedValue)
+		if !ok {
+			continue
+		}
+		for _, arg := range value.args {
+			if err := presetValue(flags, f, arg); err != nil {
+				return fmt.Errorf("flag --%s: %w", old, err)
+			}
+		}
+	}
+	return nil
+}
+
+// presetRenamedEnv presets f from the environment variable of a former name of f, if one
+// is set, and reports whether it did. Setting the variable of the current name as well is
+// a conflict.
+func presetRenamedEnv(envPrefix string, cfg *initConfig, path string, flags *pflag.FlagSet, f *pflag.Flag, envVarName string) (bool, error) {
+	for _, old := range f.Annotations[renamedFromAnnotation] {
+		oldVar, _ := pathEnvVar(envPrefix, cfg, path, &pflag.Flag{Name: old})
+		value, ok := os.LookupEnv(oldVar)
+		if oldVar == envVarName || !ok || value == "" {
+			continue
+		}
+		if current, ok := os.LookupEnv(envVarName); ok && current != "" {
+			return false, fmt.Errorf("%w: environment variables %s and %s, use only %s",
+				ErrRenamedFlagConflict, oldVar, envVarName, envVarName)
+		}
+		warnEnvAlias(cfg, oldVar, envVarName)
+		if err := presetEnvValue(cfg, flags, f, value); err != nil {
+			return false, fmt.Errorf("environment variable %s: %w", oldVar, err)
+		}
+		setAnnotation(f, sourceAnnotation, string(SourceEnv))
+		return true, nil
+	}
+	return false, nil
+}
+
+// renamedConfigKey returns the former name of f that is a key of the configuration, if
+// any. Having the key of the current name in the configuration as well is a conflict.
+func renamedConfigKey(b backend, f *pflag.Flag, viperKey string) (string, error) {
+	for _, old := range f.Annotations[renamedFromAnnotation] {
+		if strings.EqualFold(old, viperKey) || !b.InConfig(old) {
+			continue
+		}
+		if b.InConfig(viperKey) {
+			return "", fmt.Errorf("%w: configuration keys %q and %q, use only %q",
+				ErrRenamedFlagConflict, old, viperKey, viperKey)
+		}
+		return old, nil
+	}
+	return "", nil
+}
+
+// warnRenamedKey logs a warning about the use of a former configuration key, once per key,
+// or writes it to the writer set with WithDeprecationWarnings.
+func warnRenamedKey(cfg *initConfig, old, key string) {
+	if _, warned := warnedRenamedKeys.LoadOrStore(old, true); warned {
+		return
+	}
+	if cfg.deprecationOut != nil {
+		_, _ = fmt.Fprintf(cfg.deprecationOut, "Configuration key %q has been deprecated, use %q instead\n", old, key)
+		return
+	}
+	slog.Warn("deprecated configuration key, use the new name instead", "name", old, "replacement", key)
+}
+
+// presetRenamedKey presets f from the value of old, a former name of f in the configuration.
+func presetRenamedKey(cfg *initConfig, b backend, flags *pflag.FlagSet, f *pflag.Flag, old, key string) error {
+	preset, err := presetStored(b, flags, f, old, trimSpaceOf(cfg, f))
+	if err != nil {
+		return fmt.Errorf("config value %q: %w", old, err)
+	}
+	if preset {
+		warnRenamedKey(cfg, old, key)
+		setAnnotation(f, sourceAnnotation, string(SourceConfig))
+	}
+	return nil
+}
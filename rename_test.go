@@ -0,0 +1,123 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestRenamedFrom(t *testing.T) {
+	type result struct {
+		mode     string
+		tags     []string
+		source   cobraflags.Source
+		warnings string
+		output   string
+		help     string
+	}
+	execute := func(c *qt.C, configFile string, args ...string) (result, error) {
+		cobraflags.ResetState() // Former names are warned about once per process.
+		cmd := newCobraCommand()
+		modeFlag := &cobraflags.StringFlag{Name: "mode", Value: "safe", RenamedFrom: []string{"legacy-mode"}}
+		tagsFlag := &cobraflags.StringSliceFlag{Name: "tags", RenamedFrom: []string{"labels"}}
+		cobraflags.Register(cmd, modeFlag, tagsFlag)
+
+		var warnings bytes.Buffer
+		opts := []cobraflags.InitOption{cobraflags.WithDeprecationWarnings(&warnings)}
+		if configFile != "" {
+			opts = append(opts, cobraflags.WithConfigFiles(configFile))
+		}
+		c.Assert(cobraflags.CobraOnInitializeE("RENAPP", cmd, opts...), qt.IsNil)
+
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			return result{}, err
+		}
+		r := result{
+			mode:     modeFlag.GetString(),
+			tags:     tagsFlag.GetStringSlice(),
+			source:   cobraflags.FlagsOf(cmd)[0].Source,
+			warnings: warnings.String(),
+			output:   out.String(),
+		}
+		out.Reset()
+		c.Assert(cmd.Help(), qt.IsNil)
+		r.help = out.String()
+		return r, nil
+	}
+
+	c := qt.New(t)
+
+	c.Run("command line", func(c *qt.C) {
+		r, err := execute(c, "", "--legacy-mode", "fast", "--labels", "a", "--labels", "b")
+		c.Assert(err, qt.IsNil)
+		c.Assert(r.mode, qt.Equals, "fast")
+		c.Assert(r.tags, qt.DeepEquals, []string{"a", "b"})
+		c.Assert(r.source, qt.Equals, cobraflags.SourceFlag)
+		c.Assert(r.output, qt.Contains, "Flag --legacy-mode has been deprecated, use --mode instead\n")
+		c.Assert(r.help, qt.Contains, "--mode")
+		c.Assert(r.help, qt.Not(qt.Contains), "legacy-mode")
+	})
+
+	c.Run("environment", func(c *qt.C) {
+		c.Setenv("RENAPP_LEGACY_MODE", "fast")
+		r, err := execute(c, "")
+		c.Assert(err, qt.IsNil)
+		c.Assert(r.mode, qt.Equals, "fast")
+		c.Assert(r.source, qt.Equals, cobraflags.SourceEnv)
+		c.Assert(r.warnings, qt.Equals, "Environment variable RENAPP_LEGACY_MODE has been deprecated, use RENAPP_MODE instead\n")
+	})
+
+	c.Run("config", func(c *qt.C) {
+		dir := c.TempDir()
+		writeConfig(c, dir, "config.yaml", "legacy-mode: fast\nlabels: [a, b]\n")
+		r, err := execute(c, filepath.Join(dir, "config.yaml"))
+		c.Assert(err, qt.IsNil)
+		c.Assert(r.mode, qt.Equals, "fast")
+		c.Assert(r.tags, qt.DeepEquals, []string{"a", "b"})
+		c.Assert(r.source, qt.Equals, cobraflags.SourceConfig)
+		c.Assert(r.warnings, qt.Contains, `Configuration key "legacy-mode" has been deprecated, use "mode" instead`)
+	})
+
+	c.Run("current name takes precedence over a lower source", func(c *qt.C) {
+		c.Setenv("RENAPP_MODE", "env")
+		dir := c.TempDir()
+		writeConfig(c, dir, "config.yaml", "legacy-mode: fast\n")
+		r, err := execute(c, filepath.Join(dir, "config.yaml"))
+		c.Assert(err, qt.IsNil)
+		c.Assert(r.mode, qt.Equals, "env")
+		c.Assert(r.warnings, qt.Equals, "")
+	})
+
+	c.Run("conflicts", func(c *qt.C) {
+		_, err := execute(c, "", "--legacy-mode", "fast", "--mode", "slow")
+		c.Assert(err, qt.ErrorIs, cobraflags.ErrRenamedFlagConflict)
+		c.Assert(err, qt.ErrorMatches, `(?s).*--legacy-mode and --mode, use only --mode.*`)
+
+		c.Setenv("RENAPP_LEGACY_MODE", "fast")
+		c.Setenv("RENAPP_MODE", "slow")
+		_, err = execute(c, "")
+		c.Assert(err, qt.ErrorMatches, `(?s).*environment variables RENAPP_LEGACY_MODE and RENAPP_MODE, use only RENAPP_MODE.*`)
+		c.Setenv("RENAPP_MODE", "")
+		c.Setenv("RENAPP_LEGACY_MODE", "")
+
+		dir := c.TempDir()
+		writeConfig(c, dir, "config.yaml", "legacy-mode: fast\nmode: slow\n")
+		_, err = execute(c, filepath.Join(dir, "config.yaml"))
+		c.Assert(err, qt.ErrorMatches, `(?s).*configuration keys "legacy-mode" and "mode", use only "mode".*`)
+	})
+
+	c.Run("former name in use", func(c *qt.C) {
+		cmd := newCobraCommand()
+		(&cobraflags.StringFlag{Name: "legacy-mode"}).Register(cmd)
+		err := (&cobraflags.StringFlag{Name: "mode", RenamedFrom: []string{"legacy-mode"}}).RegisterE(cmd)
+		c.Assert(err, qt.ErrorIs, cobraflags.ErrDuplicateFlag)
+	})
+}
@@ -0,0 +1,134 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// requiredCheckAnnotation marks the commands whose PreRunE has been wrapped to check the
+// required flags, see installRequiredCheck.
+const requiredCheckAnnotation = "cobraflags-required-check"
+
+// installRequiredCheck makes every command of the tree rooted at root check its required
+// flags right after its PreRun hook, just before cobra does. Required flags can thus be
+// satisfied by any source, including configuration read into Viper by PersistentPreRun
+// hooks, and missing ones are reported with the ways to set them, see checkRequiredFlags.
+// Before the PreRun hook runs, experimental flags set while locked are rejected (see
+// checkExperimental). The defaults computed from other flags are resolved earlier, before
+// the PersistentPreRun hooks, in the Args function, which cobra runs with the executed
+// command right after the initializers (see resolveDefaults). It also discards the values
+// cached for the tree, which has been parsed anew, while other trees keep theirs.
+func installRequiredCheck(root *cobra.Command) {
+	walkCommands(root, func(c *cobra.Command) {
+		if c.Annotations[requiredCheckAnnotation] != "" {
+			return
+		}
+		if c.Annotations == nil {
+			c.Annotations = make(map[string]string)
+		}
+		c.Annotations[requiredCheckAnnotation] = "true"
+
+		positional := c.Args
+		c.Args = func(cmd *cobra.Command, args []string) error {
+			InvalidateCache(cmd.Root()) // The command line has been parsed anew.
+			if err := resolveDefaults(cmd); err != nil {
+				return err
+			}
+			return validateArgs(positional, cmd, args)
+		}
+
+		preRunE, preRun := c.PreRunE, c.PreRun
+		c.PreRunE = func(cmd *cobra.Command, args []string) error {
+			if err := checkExperimental(cmd); err != nil {
+				return err
+			}
+			if preRunE != nil {
+				if err := preRunE(cmd, args); err != nil {
+					return err
+				}
+			} else if preRun != nil {
+				preRun(cmd, args)
+			}
+			if err := checkRequiredFlags(cmd); err != nil {
+				return err
+			}
+			if err := checkDependencies(cmd); err != nil {
+				return err
+			}
+			warnDeprecated(cmd)
+			settleStores(cmd)
+			notifySources(cmd)
+			return nil
+		}
+	})
+}
+
+// checkRequiredFlags presets the required flags of cmd that have not been set yet from
+// the values stored in Viper, or prompts for them (see EnablePrompting), and returns an
+// error for each flag that is still not set, naming its flag, environment variable and
+// configuration key.
+func checkRequiredFlags(cmd *cobra.Command) error {
+	if cmd.DisableFlagParsing {
+		return nil
+	}
+
+	var errs []error
+	var p *prompter
+	missing := func(f *pflag.Flag) {
+		if p == nil {
+			p = newPrompter(cmd)
+		}
+		if p == nil {
+			errs = append(errs, requiredError(cmd, f))
+			return
+		}
+		if err := p.prompt(f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if required := f.Annotations[cobra.BashCompOneRequiredFlag]; len(required) == 0 || required[0] != "true" {
+			return
+		}
+		if keptDefault(f) { // Only a blank value was given, see WithTrimSpace.
+			missing(f)
+			return
+		}
+		if f.Changed {
+			return
+		}
+
+		st := storeFor(flagOwner(cmd, f))
+		st.lock()
+		key := viperKeyOf(f)
+		var preset bool
+		var err error
+		if st.values().IsSet(key) { // The default of the bound flag is not a value.
+			source := sourceOf(st.values(), f, key)
+			preset, err = presetStored(st.values(), cmd.Flags(), f, key, trimSpaceOf(configFor(cmd), f))
+			if preset && err == nil {
+				setAnnotation(f, sourceAnnotation, string(source))
+			}
+		}
+		st.mu.Unlock()
+
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("required flag %q: %w", f.Name, err))
+		case !preset:
+			missing(f)
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// requiredError returns the error for the required flag f of cmd that is not set.
+func requiredError(cmd *cobra.Command, f *pflag.Flag) error {
+	if envVar := envVarOf(f); envVar != "" {
+		return fmt.Errorf(translate(cmd, MessageRequiredFlag), f.Name, f.Name, envVar, viperKeyOf(f))
+	}
+	return fmt.Errorf(translate(cmd, MessageRequiredFlagNoEnv), f.Name, f.Name, viperKeyOf(f))
+}
@@ -0,0 +1,105 @@
+package cobraflags_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestRequired_FromConfig(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "server:\n  port: 8080\ntags: [a, b]\n")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Required: true}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags", Required: true}
+	cobraflags.Register(cmd, portFlag, tagsFlag)
+	cobraflags.CobraOnInitialize("REQAPP", cmd, cobraflags.WithConfigFile("config", "yaml", dir))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+	c.Assert(tagsFlag.GetStringSlice(), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(cobraflags.FlagsOf(cmd)[1].Source, qt.Equals, cobraflags.SourceConfig)
+}
+
+func TestRequired_FromConfigReadInPreRun(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "token: secret\n")
+
+	cmd := newCobraCommand()
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		v := cobraflags.ViperFor(cmd)
+		v.SetConfigFile(filepath.Join(dir, "config.yaml"))
+		return v.ReadInConfig()
+	}
+	tokenFlag := &cobraflags.StringFlag{Name: "token", Required: true}
+	tokenFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("REQAPP", cmd)
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(tokenFlag.GetString(), qt.Equals, "secret")
+}
+
+func TestRequired_PreRunStillCalled(t *testing.T) {
+	c := qt.New(t)
+
+	var called bool
+	cmd := newCobraCommand()
+	cmd.PreRun = func(*cobra.Command, []string) { called = true }
+	(&cobraflags.StringFlag{Name: "token", Required: true}).Register(cmd)
+	cobraflags.CobraOnInitialize("REQAPP", cmd)
+
+	cmd.SetArgs([]string{"--token", "x"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(called, qt.IsTrue)
+}
+
+func TestRequired_Missing(t *testing.T) {
+	c := qt.New(t)
+
+	root := newRequiredCommand()
+	root.SetArgs([]string{"serve"})
+	c.Assert(root.Execute(), qt.ErrorMatches, `required flag "port" not set, use --port, the environment variable REQAPP_SERVER_PORT or the config key "server.port"
+required flag "token" not set, use --token, the environment variable REQAPP_TOKEN or the config key "token"`)
+}
+
+func TestRequired_FromEnv(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("REQAPP_TOKEN", "secret")
+	c.Setenv("REQAPP_SERVER_PORT", "8080")
+
+	root := newRequiredCommand()
+	root.SetArgs([]string{"serve"})
+	c.Assert(root.Execute(), qt.IsNil)
+}
+
+// newRequiredCommand returns a command tree with a required persistent flag on the root
+// and a required flag on the "serve" subcommand.
+func newRequiredCommand() *cobra.Command {
+	root := newCobraCommand()
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+	(&cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Required: true}).Register(serve)
+	(&cobraflags.StringFlag{Name: "token", Required: true, Persistent: true}).Register(root)
+	cobraflags.CobraOnInitialize("REQAPP", root)
+	return root
+}
+
+func TestRequired_MissingInt(t *testing.T) {
+	c := qt.New(t)
+
+	// The default of a flag bound to its Viper key is not a value.
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "count", Required: true}).Register(cmd)
+	cobraflags.CobraOnInitialize("REQAPP", cmd)
+
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `required flag "count" not set, .*`)
+}
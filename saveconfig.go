@@ -0,0 +1,107 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// WriteConfig writes the effective values of the flags registered on cmd and its subcommands
+// to a configuration file at path, nested by their Viper keys, so that the file round-trips
+// through WithConfigFile and the other loaders. This lets users bootstrap their configuration
+// from experimenting on the command line. An existing file is overwritten.
+//
+// The format is any encoding supported by Viper, e.g. "yaml", "json" or "toml"; if empty,
+// it is derived from the file extension. Values of secrets (see NewPrintConfigCommand) are
+// left out, so that they do not end up in plain text files, as are the flags of ConfigFileFlag
+// and ConfirmFlag, which are no settings to keep.
+//
+// Example:
+//
+//	err := cobraflags.WriteConfig(rootCmd, "myapp.yaml", "")
+func WriteConfig(cmd *cobra.Command, path, format string) error {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	if !slices.Contains(viper.SupportedExts, format) {
+		return fmt.Errorf("unsupported config format %q", format)
+	}
+
+	// Viper would lower-case case-sensitive keys (see WithCaseSensitiveKeys), so they are
+	// encoded with the codec Viper would use directly.
+	config := effectiveConfig(cmd, false, true)
+	if configFor(cmd).caseSensitive {
+		return writeConfigMap(config, path, format)
+	}
+
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.MergeConfigMap(config); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := v.WriteConfigTo(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing config file %q: %w", path, err)
+	}
+	return f.Close()
+}
+
+// writeConfigMap writes config to a configuration file at path in the given format,
+// keeping the case of its keys.
+func writeConfigMap(config map[string]any, path, format string) error {
+	encoder, err := viper.NewCodecRegistry().Encoder(format)
+	if err != nil {
+		return fmt.Errorf("unsupported config format %q: %w", format, err)
+	}
+	b, err := encoder.Encode(config)
+	if err != nil {
+		return fmt.Errorf("writing config file %q: %w", path, err)
+	}
+	return os.WriteFile(path, b, 0o600)
+}
+
+// NewSaveConfigCommand adds a "save-config <path>" subcommand to root that writes the
+// effective configuration of the command tree to a file, see WriteConfig. The format is
+// derived from the file extension unless given with --format. An existing file is only
+// overwritten with --force.
+//
+// The command is returned so that it can be customized, e.g. renamed or hidden.
+//
+// Example:
+//
+//	cobraflags.NewSaveConfigCommand(rootCmd)
+//	// $ myapp save-config --log-level debug ~/.config/myapp/config.yaml
+func NewSaveConfigCommand(root *cobra.Command) *cobra.Command {
+	var format string
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "save-config <path>",
+		Short: "Write the effective configuration to a file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			path := args[0]
+			if !force {
+				if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+					return fmt.Errorf("config file %q already exists, use --force to overwrite it", path)
+				}
+			}
+			return WriteConfig(root, path, format)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "", "Config file format (derived from the file extension if empty)")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing file")
+	root.AddCommand(cmd)
+	return cmd
+}
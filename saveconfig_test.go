@@ -0,0 +1,102 @@
+package cobraflags_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestWriteConfig(t *testing.T) {
+	c := qt.New(t)
+	dir := c.TempDir()
+
+	newCommand := func() (*cobra.Command, []cobraflags.Flag) {
+		cmd := newCobraCommand()
+		flags := []cobraflags.Flag{
+			&cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80},
+			&cobraflags.StringSliceFlag{Name: "tags"},
+			&cobraflags.Uint8Flag{Name: "level", Value: 1},
+			&cobraflags.StringFlag{Name: "api-token"},
+		}
+		cobraflags.Register(cmd, flags...)
+		return cmd, flags
+	}
+
+	for _, name := range []string{"config.yaml", "config.json", "config.toml"} {
+		c.Run(name, func(c *qt.C) {
+			cmd, _ := newCommand()
+			cobraflags.CobraOnInitialize("SAVEAPP", cmd)
+			cmd.SetArgs([]string{"--port", "9090", "--tags", "a,b", "--level", "3", "--api-token", "s3cret"})
+			c.Assert(cmd.Execute(), qt.IsNil)
+
+			path := filepath.Join(dir, name)
+			c.Assert(cobraflags.WriteConfig(cmd, path, ""), qt.IsNil)
+			content, err := os.ReadFile(path)
+			c.Assert(err, qt.IsNil)
+			c.Assert(string(content), qt.Not(qt.Contains), "s3cret")
+
+			cmd, flags := newCommand()
+			cobraflags.CobraOnInitialize("SAVEAPP", cmd, cobraflags.WithConfigFiles(path))
+			c.Assert(cmd.Execute(), qt.IsNil)
+			c.Assert(flags[0].GetInt(), qt.Equals, 9090)
+			c.Assert(flags[1].GetStringSlice(), qt.DeepEquals, []string{"a", "b"})
+			c.Assert(flags[2].GetUint8(), qt.Equals, uint8(3))
+			c.Assert(flags[3].GetString(), qt.Equals, "")
+		})
+	}
+
+	cmd, _ := newCommand()
+	c.Assert(cobraflags.WriteConfig(cmd, filepath.Join(dir, "config.txt"), ""), qt.ErrorMatches, `unsupported config format "txt"`)
+}
+
+func TestWriteConfig_NoSettings(t *testing.T) {
+	c := qt.New(t)
+	dir := c.TempDir()
+	existing := filepath.Join(dir, "existing.yaml")
+	c.Assert(os.WriteFile(existing, []byte("port: 8080\n"), 0o600), qt.IsNil)
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	(&cobraflags.ConfirmFlag{}).Register(cmd)
+	cobraflags.ConfigFileFlag(cmd)
+	cobraflags.CobraOnInitialize("SAVENOSETTINGSAPP", cmd)
+	cmd.SetArgs([]string{"--config", existing, "--yes"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	// Neither the configuration file nor the confirmation are saved.
+	path := filepath.Join(dir, "config.yaml")
+	c.Assert(cobraflags.WriteConfig(cmd, path, ""), qt.IsNil)
+	content, err := os.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(content), qt.Equals, "port: 8080\n")
+}
+
+func TestNewSaveConfigCommand(t *testing.T) {
+	c := qt.New(t)
+	path := filepath.Join(c.TempDir(), "config.yaml")
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80, Persistent: true}).Register(cmd)
+	cobraflags.NewSaveConfigCommand(cmd)
+	cobraflags.CobraOnInitialize("SAVEAPP", cmd)
+
+	cmd.SetArgs([]string{"save-config", "--port", "9090", path})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	content, err := os.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(content), qt.Equals, "port: 9090\n")
+
+	cmd.SetArgs([]string{"save-config", path})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `config file ".*config.yaml" already exists, use --force to overwrite it`)
+
+	cmd.SetArgs([]string{"save-config", "--force", "--format", "json", path})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	content, err = os.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(content), qt.Equals, "{\n  \"port\": 9090\n}")
+}
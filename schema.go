@@ -0,0 +1,113 @@
+package cobraflags
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// jsonSchemaDialect is the JSON Schema version GenJSONSchema generates.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of JSON Schema used by GenJSONSchema.
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Default     any                    `json:"default,omitempty"`
+	Enum        []any                  `json:"enum,omitempty"`
+	Minimum     *int                   `json:"minimum,omitempty"`
+	Maximum     *int                   `json:"maximum,omitempty"`
+	Items       *jsonSchema            `json:"items,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+}
+
+// GenJSONSchema returns a JSON Schema describing the configuration surface of cmd and its
+// subcommands: one property per flag registered through cobraflags, keyed by its Viper key
+// (dotted keys become nested objects), with its type, default, description, allowed values
+// (see OneOf) and whether it is required. The schema can validate configuration files in
+// CI and power editor autocompletion, e.g. through a yaml-language-server modeline. The
+// defaults of secrets are left out, see FlagBase.Sensitive.
+//
+// Example:
+//
+//	schema, err := cobraflags.GenJSONSchema(rootCmd)
+//	if err != nil {
+//		return err
+//	}
+//	return os.WriteFile("myapp.schema.json", schema, 0o644)
+func GenJSONSchema(cmd *cobra.Command) ([]byte, error) {
+	root := &jsonSchema{
+		Schema: jsonSchemaDialect,
+		Title:  cmd.Name(),
+		Type:   "object",
+	}
+	for _, cf := range CollectFlags(cmd) {
+		for _, info := range cf.Flags {
+			root.add(strings.Split(info.ViperKey, "."), flagSchema(info), info.Required)
+		}
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// add adds the property schema under the key path to the object schema s, creating
+// intermediate objects as needed. The first flag bound to a key wins.
+func (s *jsonSchema) add(path []string, property *jsonSchema, required bool) {
+	if s.Properties == nil {
+		s.Properties = make(map[string]*jsonSchema)
+	}
+
+	name := path[0]
+	existing, ok := s.Properties[name]
+	if len(path) > 1 {
+		if !ok {
+			existing = &jsonSchema{Type: "object"}
+			s.Properties[name] = existing
+		} else if existing.Type != "object" {
+			return
+		}
+		existing.add(path[1:], property, required)
+		return
+	}
+
+	if ok {
+		return
+	}
+	s.Properties[name] = property
+	if required && !slices.Contains(s.Required, name) {
+		s.Required = append(s.Required, name)
+	}
+}
+
+// flagSchema returns the schema of the value of a flag.
+func flagSchema(info FlagInfo) *jsonSchema {
+	s := &jsonSchema{
+		Description: info.Usage,
+		Default:     info.Default,
+		Enum:        info.Enum,
+	}
+	switch info.Type {
+	case "string":
+		s.Type = "string"
+	case "bool":
+		s.Type = "boolean"
+	case "int":
+		s.Type = "integer"
+	case "uint8":
+		minimum, maximum := 0, 255
+		s.Type, s.Minimum, s.Maximum = "integer", &minimum, &maximum
+	case "stringSlice":
+		s.Type, s.Items = "array", &jsonSchema{Type: "string"}
+		if info.Default == nil || len(info.Default.([]string)) == 0 {
+			s.Default = nil
+		}
+	}
+	if isSecret(info) {
+		s.Default = nil // Not shown, see FlagBase.Sensitive.
+	}
+	return s
+}
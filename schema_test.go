@@ -0,0 +1,75 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestGenJSONSchema(t *testing.T) {
+	c := qt.New(t)
+
+	root := &cobra.Command{Use: "schemaapp"}
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+
+	(&cobraflags.StringFlag{Name: "log-level", ViperKey: "log.level", Usage: "Log level", Value: "info",
+		Validator: cobraflags.OneOf("debug", "info", "error")}).Register(root)
+	(&cobraflags.BoolFlag{Name: "verbose", Usage: "Verbose output", Persistent: true}).Register(root)
+	(&cobraflags.IntFlag{Name: "port", Usage: "Port", Value: 80, Required: true}).Register(serve)
+	(&cobraflags.Uint8Flag{Name: "retries", Value: 3}).Register(serve)
+	(&cobraflags.StringSliceFlag{Name: "tags"}).Register(serve)
+
+	schema, err := cobraflags.GenJSONSchema(root)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(schema), qt.Equals, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "schemaapp",
+  "type": "object",
+  "properties": {
+    "log": {
+      "type": "object",
+      "properties": {
+        "level": {
+          "description": "Log level",
+          "type": "string",
+          "default": "info",
+          "enum": [
+            "debug",
+            "info",
+            "error"
+          ]
+        }
+      }
+    },
+    "port": {
+      "description": "Port",
+      "type": "integer",
+      "default": 80
+    },
+    "retries": {
+      "type": "integer",
+      "default": 3,
+      "minimum": 0,
+      "maximum": 255
+    },
+    "tags": {
+      "type": "array",
+      "items": {
+        "type": "string"
+      }
+    },
+    "verbose": {
+      "description": "Verbose output",
+      "type": "boolean",
+      "default": false
+    }
+  },
+  "required": [
+    "port"
+  ]
+}`)
+}
@@ -0,0 +1,89 @@
+package cobraflags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	sensitiveAnnotation = "cobraflags-sensitive" // marks the flags whose values are secrets, see FlagBase.Sensitive
+	defValueAnnotation  = "cobraflags-def-value" // the default before decorateUsage redacted it
+)
+
+// redacted replaces the values of secret flags wherever they are shown.
+const redacted = "***"
+
+// secretFlag reports whether the value of f must not be shown by its name and whether it is
+// sensitive, see isSecret.
+func secretFlag(f *pflag.Flag) bool {
+	return isSecret(FlagInfo{Name: f.Name, Sensitive: len(f.Annotations[sensitiveAnnotation]) > 0})
+}
+
+// secret reports whether the value of the flag must not be shown by its name and whether
+// it is sensitive, see isSecret.
+func (s *FlagBase[T]) secret() bool {
+	return isSecret(FlagInfo{Name: s.Name, Sensitive: s.Sensitive})
+}
+
+// redactedError is an error whose message has secret values replaced, see redactError.
+// It still wraps the original error for errors.Is and errors.As.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError returns err with the given values replaced by redacted in its message, or err
+// itself if its message does not quote any of them.
+func redactError(err error, values ...string) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	for _, v := range values {
+		if v != "" {
+			msg = strings.ReplaceAll(msg, v, redacted)
+		}
+	}
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+// redactSecret returns err with the given values redacted if f is a secret, see secretFlag.
+func redactSecret(f *pflag.Flag, err error, values ...string) error {
+	if !secretFlag(f) {
+		return err
+	}
+	return redactError(err, values...)
+}
+
+// valueStrings returns the string forms of a value that error messages may quote: the
+// items of a slice, or the value as formatted by fmt.
+func valueStrings(v any) []string {
+	if items, ok := v.([]string); ok {
+		return append([]string{fmt.Sprint(items)}, items...)
+	}
+	return []string{fmt.Sprint(v)}
+}
+
+// redactedFlags returns the flags with the values and defaults of secrets redacted.
+func redactedFlags(flags []CommandFlags) []CommandFlags {
+	result := make([]CommandFlags, len(flags))
+	for i, cf := range flags {
+		result[i] = CommandFlags{Command: cf.Command, Flags: make([]FlagInfo, len(cf.Flags))}
+		for j, info := range cf.Flags {
+			if isSecret(info) {
+				info.Value, info.Default = redacted, redacted
+			}
+			result[i].Flags[j] = info
+		}
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestSensitive(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("SENSAPP_DSN", "postgres://admin:hunter2@db")
+
+	cmd := newCobraCommand()
+	dsnFlag := &cobraflags.StringFlag{Name: "dsn", Usage: "Database URL", Value: "postgres://localhost", Sensitive: true}
+	pinFlag := &cobraflags.IntFlag{Name: "pin", Usage: "PIN", Value: 1234, Sensitive: true}
+	cobraflags.Register(cmd, dsnFlag, pinFlag)
+	cobraflags.CobraOnInitialize("SENSAPP", cmd)
+	cmd.SetArgs(nil)
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	// The value itself is not redacted.
+	c.Assert(dsnFlag.GetString(), qt.Equals, "postgres://admin:hunter2@db")
+	provenance := cobraflags.Explain(cmd)
+	c.Assert(provenance[0].Value, qt.Equals, "postgres://admin:hunter2@db")
+	c.Assert(provenance[0].Sensitive, qt.IsTrue)
+
+	c.Run("help", func(c *qt.C) {
+		usage := cmd.UsageString()
+		c.Assert(usage, qt.Contains, `Database URL [env: SENSAPP_DSN] (default "***")`)
+		c.Assert(usage, qt.Contains, `PIN [env: SENSAPP_PIN] (default ***)`)
+		c.Assert(usage, qt.Not(qt.Contains), "localhost")
+		c.Assert(cmd.Flags().Lookup("pin").DefValue, qt.Equals, "1234")
+	})
+
+	c.Run("explain", func(c *qt.C) {
+		var out bytes.Buffer
+		c.Assert(cobraflags.PrintExplain(cmd, &out), qt.IsNil)
+		c.Assert(out.String(), qt.Matches, `(?s).*dsn   \*\*\*    env .*`)
+		c.Assert(out.String(), qt.Not(qt.Contains), "hunter2")
+	})
+
+	c.Run("logs", func(c *qt.C) {
+		var out bytes.Buffer
+		cobraflags.LogEffectiveConfig(slog.New(slog.NewTextHandler(&out, nil)), cmd)
+		c.Assert(out.String(), qt.Contains, `flag=dsn value=*** source=env`)
+		c.Assert(out.String(), qt.Contains, `flag=pin value=*** source=default`)
+	})
+
+	c.Run("dump", func(c *qt.C) {
+		var out bytes.Buffer
+		c.Assert(cobraflags.DumpJSON(cmd, &out), qt.IsNil)
+		c.Assert(out.String(), qt.Contains, `"default": "***",
+        "value": "***",`)
+		c.Assert(out.String(), qt.Contains, `"sensitive": true`)
+		c.Assert(out.String(), qt.Not(qt.Contains), "hunter2")
+		c.Assert(cobraflags.FlagsOf(cmd)[0].Value, qt.Equals, "postgres://admin:hunter2@db")
+	})
+}
+
+func TestSensitive_InstallHelp(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.StringFlag{Name: "dsn", Usage: "Database URL", Value: "postgres://localhost", Sensitive: true}).Register(cmd)
+	cobraflags.InstallHelp(cmd)
+
+	c.Assert(cmd.UsageString(), qt.Contains, "--dsn string   Database URL (default ***)")
+}
+
+func TestSensitive_Errors(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("SENSERRAPP_PIN", "12a4")
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "pin", Sensitive: true}).Register(cmd)
+	c.Assert(cobraflags.CobraOnInitializeE("SENSERRAPP", cmd), qt.IsNil)
+	cmd.SetArgs(nil)
+	err := cmd.Execute()
+	c.Assert(err, qt.ErrorMatches, `environment variable SENSERRAPP_PIN: invalid argument "\*\*\*" for "--pin" flag: .*`)
+	c.Assert(err.Error(), qt.Not(qt.Contains), "12a4")
+
+	errWeak := errors.New("weak")
+	cmd = newCobraCommand()
+	keyFlag := &cobraflags.StringFlag{
+		Name:      "key",
+		Value:     "s3cr3t",
+		Sensitive: true,
+		ValidateFunc: func(v string) error {
+			return errors.Join(errWeak, errors.New("value "+v+" is too short"))
+		},
+	}
+	keyFlag.Register(cmd)
+	cmd.RunE = func(*cobra.Command, []string) error {
+		_, err := keyFlag.GetStringE()
+		return err
+	}
+	cmd.SetArgs(nil)
+	err = cmd.Execute()
+	c.Assert(err, qt.ErrorMatches, "weak\nvalue \\*\\*\\* is too short")
+	c.Assert(err, qt.ErrorIs, errWeak)
+	c.Assert(func() { keyFlag.MustString() }, qt.PanicMatches,
+		`cobraflags: invalid value \*\*\* for flag "key" \(from default\): weak\nvalue \*\*\* is too short`)
+}
+
+func TestSensitive_Names(t *testing.T) {
+	c := qt.New(t)
+
+	secret := map[string]bool{
+		"db-password":       true,
+		"api_key":           true,
+		"auth.token":        true,
+		"client-secret":     true,
+		"aws-secret-key":    true,
+		"Private-Key":       true,
+		"token":             true,
+		"max-tokens":        false,
+		"token-bucket-size": false,
+		"password-file":     false,
+		"monkey":            false,
+		"secretariat":       false,
+	}
+	root := newCobraCommand()
+	for name := range secret {
+		(&cobraflags.StringFlag{Name: name, ViperKey: strings.ReplaceAll(name, ".", "-"), Value: "value"}).Register(root)
+	}
+	cobraflags.NewPrintConfigCommand(root)
+	cobraflags.CobraOnInitialize("SENSNAMESAPP", root)
+
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetArgs([]string{"print-config", "--output", "json"})
+	c.Assert(root.Execute(), qt.IsNil)
+	var config map[string]string
+	c.Assert(json.Unmarshal(buf.Bytes(), &config), qt.IsNil)
+
+	for name, want := range secret {
+		value := config[strings.ReplaceAll(name, ".", "-")]
+		c.Check(value == "***", qt.Equals, want, qt.Commentf("flag %q: %q", name, value))
+	}
+}
@@ -0,0 +1,50 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestWithSetFlag(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("SETAPP_LEVEL", "error")
+	c.Setenv("SETAPP_SET", "ignored=true")
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "server:\n  port: 8080\n  host: config.example.com\n")
+
+	root := &cobra.Command{Use: "setapp"}
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80}
+	hostFlag := &cobraflags.StringFlag{Name: "host", ViperKey: "server.host"}
+	levelFlag := &cobraflags.StringFlag{Name: "level", Value: "info"}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags"}
+	nameFlag := &cobraflags.StringFlag{Name: "name"}
+	cobraflags.Register(serve, portFlag, hostFlag, levelFlag, tagsFlag, nameFlag)
+	cobraflags.CobraOnInitialize("SETAPP", root, cobraflags.WithConfigFile("config", "yaml", dir), cobraflags.WithSetFlag())
+
+	root.SetArgs([]string{"serve", "--set", "server.port=9090", "--set", "server.port=9091",
+		"--set", "level=debug", "--set", "tags=a,b", "--set", "name=from-set", "--name", "from-cli"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9091)
+	c.Assert(hostFlag.GetString(), qt.Equals, "config.example.com")
+	c.Assert(levelFlag.GetString(), qt.Equals, "error")
+	c.Assert(tagsFlag.GetStringSlice(), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(nameFlag.GetString(), qt.Equals, "from-cli")
+	c.Assert(cobraflags.ViperFor(root).IsSet("ignored"), qt.IsFalse)
+}
+
+func TestWithSetFlag_Invalid(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	cobraflags.CobraOnInitialize("SETAPP", cmd, cobraflags.WithSetFlag())
+
+	cmd.SetArgs([]string{"--set", "port"})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `invalid --set value "port", expected key=value`)
+}
@@ -0,0 +1,255 @@
+package cobraflags
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// State is a snapshot of the flag state of a command tree, taken by Snapshot
+// and reinstated by Restore.
+type State struct {
+	flags       map[*pflag.Flag]flagState
+	frozen      map[registeredFlag]bool
+	initialized map[*cobra.Command]bool
+	values      map[*store]map[string]any // the values of each Viper instance by key
+}
+
+// flagState is the captured state of a single pflag.Flag.
+type flagState struct {
+	value       string
+	slice       []string
+	isSlice     bool
+	changed     bool
+	usage       string
+	annotations map[string][]string
+}
+
+// Snapshot captures the values, Changed bits, usage strings and annotations of all flags
+// defined on cmd and its subcommands, together with the CobraOnInitialize state of the
+// tree (whether environment variables have already been applied) and the values of its
+// Viper instances (see ViperFor).
+//
+// Together with Restore, this makes table-driven tests that execute the same command
+// repeatedly deterministic. Restore drops the values set on a Viper instance with Set
+// since the snapshot, and reinstates those of keys not bound to a flag, e.g. of a
+// configuration section that changed when the file was reloaded. Viper cannot forget
+// keys, so keys added to its configuration since the snapshot remain.
+//
+// Example:
+//
+//	state := cobraflags.Snapshot(rootCmd)
+//	for _, tt := range tests {
+//		cobraflags.Restore(rootCmd, state)
+//		rootCmd.SetArgs(tt.args)
+//		...
+//	}
+func Snapshot(cmd *cobra.Command) State {
+	state := State{
+		flags:       make(map[*pflag.Flag]flagState),
+		frozen:      make(map[registeredFlag]bool),
+		initialized: make(map[*cobra.Command]bool),
+		values:      make(map[*store]map[string]any),
+	}
+
+	walkCommands(cmd, func(c *cobra.Command) {
+		visitFlags(c, func(f *pflag.Flag) {
+			if _, ok := state.flags[f]; !ok {
+				state.flags[f] = captureFlag(f)
+			}
+		})
+		for _, entry := range registeredOn(c) {
+			state.frozen[entry.base] = entry.base.isFrozen()
+		}
+	})
+
+	initOnceMutex.Lock()
+	for root, init := range initOnceMap {
+		if isAncestor(root, cmd) || isAncestor(cmd, root) {
+			init.mu.Lock()
+			state.initialized[root] = init.done
+			init.mu.Unlock()
+		}
+	}
+	initOnceMutex.Unlock()
+
+	walkCommands(cmd, func(c *cobra.Command) {
+		if st := storeFor(c); state.values[st] == nil {
+			state.values[st] = st.captureValues()
+		}
+	})
+
+	return state
+}
+
+// Restore reinstates a State taken by Snapshot on the same command tree.
+// Flags defined after the snapshot was taken are left untouched. If the snapshot
+// was taken before the tree was initialized, CobraOnInitialize applies environment
+// variables again on the next execution. Flags that were not frozen at the time of
+// the snapshot are unfrozen.
+func Restore(cmd *cobra.Command, state State) {
+	walkCommands(cmd, func(c *cobra.Command) {
+		visitFlags(c, func(f *pflag.Flag) {
+			if fs, ok := state.flags[f]; ok {
+				restoreFlag(f, fs)
+			}
+		})
+		for _, entry := range registeredOn(c) {
+			if frozen, ok := state.frozen[entry.base]; ok && !frozen {
+				entry.base.unfreeze()
+			}
+		}
+	})
+
+	restored := make(map[*store]bool)
+	walkCommands(cmd, func(c *cobra.Command) {
+		st := storeFor(c)
+		if values, ok := state.values[st]; ok && !restored[st] {
+			restored[st] = true
+			st.restoreValues(values)
+		}
+	})
+
+	InvalidateCache(cmd)
+
+	initOnceMutex.Lock()
+	for root, done := range state.initialized {
+		if init, ok := initOnceMap[root]; ok {
+			init.mu.Lock()
+			init.done = done
+			init.mu.Unlock()
+		}
+	}
+	initOnceMutex.Unlock()
+}
+
+// visitFlags calls fn for every local and persistent flag defined on cmd.
+func visitFlags(cmd *cobra.Command, fn func(*pflag.Flag)) {
+	cmd.Flags().VisitAll(fn)
+	cmd.PersistentFlags().VisitAll(fn)
+}
+
+// isAncestor reports whether ancestor is cmd itself or one of its parents.
+func isAncestor(ancestor, cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// captureFlag records the state of a flag.
+func captureFlag(f *pflag.Flag) flagState {
+	fs := flagState{
+		changed:     f.Changed,
+		usage:       f.Usage,
+		annotations: make(map[string][]string, len(f.Annotations)),
+	}
+	for key, values := range f.Annotations {
+		fs.annotations[key] = slices.Clone(values)
+	}
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		fs.isSlice = true
+		fs.slice = slices.Clone(sv.GetSlice())
+	} else {
+		fs.value = f.Value.String()
+	}
+	return fs
+}
+
+// restoreFlag reinstates the recorded state of a flag.
+func restoreFlag(f *pflag.Flag, fs flagState) {
+	if fs.isSlice {
+		restoreSlice(f, fs.slice)
+	} else {
+		_ = f.Value.Set(fs.value)
+	}
+	f.Changed = fs.changed
+	f.Usage = fs.usage
+	f.Annotations = maps.Clone(fs.annotations)
+}
+
+// captureValues records the values of all keys of the store.
+func (st *store) captureValues() map[string]any {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	b := st.values()
+	values := make(map[string]any)
+	for _, key := range b.AllKeys() {
+		values[key] = cloneValue(b.Get(key))
+	}
+	return values
+}
+
+// restoreValues reinstates the values recorded by captureValues, once the flags have
+// been restored. Viper ignores nil values set with Set, so setting a key to nil drops a
+// value set since. Keys bound to a flag get their value from it, and possibly from the
+// environment, which must not be overridden; others get their recorded value back.
+func (st *store) restoreValues(values map[string]any) {
+	st.lock()
+	defer st.mu.Unlock()
+
+	bound := make(map[string]bool, len(st.bound))
+	for key := range st.bound {
+		bound[st.foldKey(key)] = true
+	}
+	b := st.values()
+	keys := b.AllKeys()
+	for key := range values {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key) // Removed since, e.g. from a reloaded configuration file.
+		}
+	}
+
+	for _, key := range keys {
+		want, ok := values[key]
+		if ok && reflect.DeepEqual(b.Get(key), want) {
+			continue
+		}
+		b.Set(key, nil)
+		if ok && !bound[key] && !reflect.DeepEqual(b.Get(key), want) {
+			b.Set(key, cloneValue(want))
+		}
+	}
+}
+
+// cloneValue returns a copy of a value read from Viper that shares no slices or maps with it.
+func cloneValue(value any) any {
+	switch value := value.(type) {
+	case []string:
+		return slices.Clone(value)
+	case []any:
+		clone := make([]any, len(value))
+		for i, item := range value {
+			clone[i] = cloneValue(item)
+		}
+		return clone
+	case map[string]any:
+		clone := make(map[string]any, len(value))
+		for key, item := range value {
+			clone[key] = cloneValue(item)
+		}
+		return clone
+	default:
+		return value
+	}
+}
+
+// restoreSlice reinstates the value of a slice flag in place, so that variables bound to it,
+// e.g. with StringSliceVar, keep receiving its values. pflag's slice values append on every
+// Set once they have been set, and Replace does not reset that, so the flag is made to
+// replace its items on the next Set, as if it had never been parsed.
+func restoreSlice(f *pflag.Flag, values []string) {
+	v, ok := f.Value.(*trackedSlice)
+	if !ok {
+		track(f, func() {}) // A flag not registered through cobraflags.
+		v = f.Value.(*trackedSlice)
+	}
+	_ = v.Replace(values)
+	v.restart = true
+}
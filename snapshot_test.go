@@ -0,0 +1,158 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	root := &cobra.Command{Use: "snapapp", SilenceUsage: true, SilenceErrors: true}
+	sub := &cobra.Command{Use: "run", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default", Persistent: true}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags", Value: []string{"a"}}
+	countFlag := &cobraflags.IntFlag{Name: "count", Value: 1}
+	nameFlag.Register(root)
+	tagsFlag.Register(sub)
+	countFlag.Register(sub)
+	cobraflags.CobraOnInitialize("SNAPAPP", root)
+
+	state := cobraflags.Snapshot(root)
+
+	tests := []struct {
+		name      string
+		args      []string
+		env       map[string]string
+		wantName  string
+		wantTags  []string
+		wantCount int
+	}{
+		{
+			name:      "args",
+			args:      []string{"run", "--name", "cli", "--tags", "x", "--tags", "y", "--count", "5"},
+			wantName:  "cli",
+			wantTags:  []string{"x", "y"},
+			wantCount: 5,
+		},
+		{
+			name:      "defaults",
+			args:      []string{"run"},
+			wantName:  "default",
+			wantTags:  []string{"a"},
+			wantCount: 1,
+		},
+		{
+			name:      "env",
+			args:      []string{"run", "--tags", "z"},
+			env:       map[string]string{"SNAPAPP_COUNT": "7"},
+			wantName:  "default",
+			wantTags:  []string{"z"},
+			wantCount: 7,
+		},
+		{
+			name:      "defaults_again",
+			args:      []string{"run"},
+			wantName:  "default",
+			wantTags:  []string{"a"},
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			for key, value := range tt.env {
+				c.Setenv(key, value)
+			}
+			cobraflags.Restore(root, state)
+
+			root.SetArgs(tt.args)
+			c.Assert(root.Execute(), qt.IsNil)
+
+			c.Assert(nameFlag.GetString(), qt.Equals, tt.wantName)
+			c.Assert(tagsFlag.GetStringSlice(), qt.DeepEquals, tt.wantTags)
+			c.Assert(countFlag.GetInt(), qt.Equals, tt.wantCount)
+		})
+	}
+}
+
+func TestSnapshotRestore_SliceVar(t *testing.T) {
+	c := qt.New(t)
+
+	var tags []string
+	cmd := newCobraCommand()
+	cmd.Flags().StringSliceVar(&tags, "tags", []string{"a"}, "Tags")
+	labelsFlag := &cobraflags.StringSliceFlag{Name: "labels", Value: []string{"b"}}
+	labelsFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("SNAPVARAPP", cmd)
+	state := cobraflags.Snapshot(cmd)
+
+	cmd.SetArgs([]string{"--tags", "x", "--tags", "y", "--labels", "l"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(tags, qt.DeepEquals, []string{"x", "y"})
+
+	// The bound variable is restored, and the next arguments replace its items.
+	cobraflags.Restore(cmd, state)
+	c.Assert(tags, qt.DeepEquals, []string{"a"})
+	c.Assert(labelsFlag.GetStringSlice(), qt.DeepEquals, []string{"b"})
+
+	cmd.SetArgs([]string{"--tags", "z", "--labels", "m"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(tags, qt.DeepEquals, []string{"z"})
+	c.Assert(labelsFlag.GetStringSlice(), qt.DeepEquals, []string{"m"})
+}
+
+func TestRestore_Unfreezes(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	flag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	flag.Register(cmd)
+
+	state := cobraflags.Snapshot(cmd)
+	cobraflags.Freeze(cmd)
+	cobraflags.ViperFor(cmd).Set("name", "live")
+	c.Assert(flag.GetString(), qt.Equals, "default")
+
+	cobraflags.Restore(cmd, state)
+	c.Assert(flag.GetString(), qt.Equals, "default") // The value set since is dropped.
+	cobraflags.ViperFor(cmd).Set("name", "live")
+	c.Assert(flag.GetString(), qt.Equals, "live")
+}
+
+func TestSnapshotRestore_Viper(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("SNAPVIPERAPP", cmd)
+	v := cobraflags.ViperFor(cmd)
+	v.Set("mode", "dev")
+	v.Set("db.host", "localhost")
+
+	state := cobraflags.Snapshot(cmd)
+
+	v.Set("port", 9000)
+	v.Set("mode", "prod")
+	v.Set("db.host", "db.example.com")
+	v.Set("extra", "value")
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9000)
+
+	cobraflags.Restore(cmd, state)
+	c.Assert(portFlag.GetInt(), qt.Equals, 80)
+	c.Assert(v.GetString("mode"), qt.Equals, "dev")
+	c.Assert(v.GetString("db.host"), qt.Equals, "localhost")
+	c.Assert(v.IsSet("extra"), qt.IsFalse)
+
+	// The command line takes precedence again.
+	cmd.SetArgs([]string{"--port", "8080"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 8080)
+}
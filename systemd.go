@@ -0,0 +1,50 @@
+package cobraflags
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// GenSystemdEnvFile writes a systemd EnvironmentFile covering the environment variables
+// of all flags of cmd and its subcommands. Every variable is preceded by the usage of its
+// flag and commented out with its default value, so that the file documents the available
+// settings and operators uncomment the ones they change. The defaults of secrets (see
+// NewPrintConfigCommand) are left empty, so that they never end up in the file. Values are
+// quoted as needed.
+//
+// Example output:
+//
+//	# Log level
+//	#MYAPP_LOG_LEVEL=info
+//
+//	# Greeting
+//	#MYAPP_GREETING="hello world"
+func GenSystemdEnvFile(cmd *cobra.Command, w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Environment file for %s, see systemd.exec(5).\n", cmd.CommandPath())
+	for _, b := range envBindings(cmd) {
+		sb.WriteString("\n")
+		if b.Usage != "" {
+			fmt.Fprintf(&sb, "# %s\n", b.Usage)
+		}
+		if b.secret {
+			fmt.Fprintf(&sb, "#%s=\n", b.EnvVar)
+			continue
+		}
+		fmt.Fprintf(&sb, "#%s=%s\n", b.EnvVar, systemdQuote(b.defaultValue))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// systemdQuote quotes a value for an EnvironmentFile if it contains whitespace,
+// quotes, backslashes or comment characters.
+func systemdQuote(value string) string {
+	if !strings.ContainsAny(value, " \t\"'\\#;") {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,50 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestGenSystemdEnvFile(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.GenSystemdEnvFile(newDeployCommand(), &buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `# Environment file for deployapp, see systemd.exec(5).
+
+# Log level
+#DEPLOYAPP_LOG_LEVEL=info
+
+# Maximum connections
+#DEPLOYAPP_SERVER_MAX_CONNS=100
+
+# Tags
+#DEPLOYAPP_TAGS=a,b
+
+# Greeting
+#DEPLOYAPP_GREETING="hello world"
+
+# API token
+#DEPLOYAPP_API_TOKEN=
+`)
+}
+
+func TestGenSystemdEnvFile_Sensitive(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.StringFlag{Name: "dsn", Usage: "Database URL", Value: "postgres://admin:hunter2@db", Sensitive: true}).Register(cmd)
+	cobraflags.CobraOnInitialize("SYSTEMDSENSAPP", cmd)
+
+	var buf bytes.Buffer
+	c.Assert(cobraflags.GenSystemdEnvFile(cmd, &buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `# Environment file for myapp, see systemd.exec(5).
+
+# Database URL
+#SYSTEMDSENSAPP_DSN=
+`)
+}
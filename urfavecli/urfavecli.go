@@ -0,0 +1,151 @@
+// Package urfavecli converts cobraflags flag definitions into urfave/cli v3 flags and back,
+// so that organizations with both cobra and urfave/cli commands can maintain one catalogue
+// of flags. Names, shorthands, usage texts, defaults, required markers, groups, environment
+// variables and validators are carried over.
+package urfavecli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// envReplacer derives environment variable names from Viper keys, as CobraOnInitialize
+// does by default.
+var envReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// ToCLI converts the definitions of flags into urfave/cli v3 flags. Each flag reads the
+// environment variable CobraOnInitialize would bind it to with the default settings: its
+// EnvVar, or the one derived from envPrefix and its Viper key, followed by its EnvAliases.
+// Its ValidateFunc and Validator are combined into the Validator of the urfave/cli flag.
+// Flags that are not Persistent become Local.
+//
+// Only the definitions are converted; the returned flags do not share values with flags.
+// It returns an error for flag types it does not know.
+//
+// Example:
+//
+//	cmd := &cli.Command{Name: "myapp"}
+//	cmd.Flags, err = urfavecli.ToCLI("MYAPP", portFlag, hostFlag)
+func ToCLI(envPrefix string, flags ...cobraflags.Flag) ([]cli.Flag, error) {
+	result := make([]cli.Flag, 0, len(flags))
+	for _, flag := range flags {
+		switch f := flag.(type) {
+		case *cobraflags.StringFlag:
+			result = append(result, toCLI(&cli.StringFlag{}, envPrefix, (*cobraflags.FlagBase[string])(f)))
+		case *cobraflags.BoolFlag:
+			result = append(result, toCLI(&cli.BoolFlag{}, envPrefix, (*cobraflags.FlagBase[bool])(f)))
+		case *cobraflags.ConfirmFlag:
+			result = append(result, toCLI(&cli.BoolFlag{}, envPrefix, (*cobraflags.FlagBase[bool])(&f.BoolFlag)))
+		case *cobraflags.IntFlag:
+			result = append(result, toCLI(&cli.IntFlag{}, envPrefix, (*cobraflags.FlagBase[int])(f)))
+		case *cobraflags.Uint8Flag:
+			result = append(result, toCLI(&cli.Uint8Flag{}, envPrefix, (*cobraflags.FlagBase[uint8])(f)))
+		case *cobraflags.StringSliceFlag:
+			result = append(result, toCLI(&cli.StringSliceFlag{}, envPrefix, (*cobraflags.FlagBase[[]string])(f)))
+		default:
+			return nil, fmt.Errorf("urfavecli: unsupported flag type %T", flag)
+		}
+	}
+	return result, nil
+}
+
+// toCLI sets the fields of the urfave/cli flag dst from the definition of f and returns dst.
+func toCLI[T, C any, VC cli.ValueCreator[T, C]](dst *cli.FlagBase[T, C, VC], envPrefix string, f *cobraflags.FlagBase[T]) *cli.FlagBase[T, C, VC] {
+	envVar := f.EnvVar
+	if envVar == "" {
+		key := f.ViperKey
+		if key == "" {
+			key = f.Name
+		}
+		if envPrefix != "" {
+			key = envPrefix + "_" + key
+		}
+		envVar = envReplacer.Replace(strings.ToUpper(key))
+	}
+
+	dst.Name = f.Name
+	if f.Shorthand != "" {
+		dst.Aliases = []string{f.Shorthand}
+	}
+	dst.Usage = f.Usage
+	dst.Category = f.Group
+	dst.Value = f.Value
+	dst.Required = f.Required
+	dst.Local = !f.Persistent
+	dst.Sources = cli.EnvVars(append([]string{envVar}, f.EnvAliases...)...)
+	if f.ValidateFunc != nil || f.Validator != nil {
+		dst.Validator = f.Validate
+	}
+	return dst
+}
+
+// FromCLI converts urfave/cli v3 flags into cobraflags definitions, to be registered on
+// cobra commands. The first environment variable of a flag's sources becomes its EnvVar
+// and the others its EnvAliases; other sources, such as files, are not carried over. A
+// single-character alias becomes the shorthand, the category the group, and the validator
+// the ValidateFunc. Flags that are not Local become Persistent.
+//
+// It returns an error for flag types it does not know.
+//
+// Example:
+//
+//	flags, err := urfavecli.FromCLI(cliCmd.Flags...)
+//	cobraflags.Register(rootCmd, flags...)
+func FromCLI(flags ...cli.Flag) ([]cobraflags.Flag, error) {
+	result := make([]cobraflags.Flag, 0, len(flags))
+	for _, flag := range flags {
+		switch f := flag.(type) {
+		case *cli.StringFlag:
+			dst := &cobraflags.StringFlag{}
+			fromCLI((*cobraflags.FlagBase[string])(dst), f)
+			result = append(result, dst)
+		case *cli.BoolFlag:
+			dst := &cobraflags.BoolFlag{}
+			fromCLI((*cobraflags.FlagBase[bool])(dst), f)
+			result = append(result, dst)
+		case *cli.IntFlag:
+			dst := &cobraflags.IntFlag{}
+			fromCLI((*cobraflags.FlagBase[int])(dst), f)
+			result = append(result, dst)
+		case *cli.Uint8Flag:
+			dst := &cobraflags.Uint8Flag{}
+			fromCLI((*cobraflags.FlagBase[uint8])(dst), f)
+			result = append(result, dst)
+		case *cli.StringSliceFlag:
+			dst := &cobraflags.StringSliceFlag{}
+			fromCLI((*cobraflags.FlagBase[[]string])(dst), f)
+			result = append(result, dst)
+		default:
+			return nil, fmt.Errorf("urfavecli: unsupported flag type %T", flag)
+		}
+	}
+	return result, nil
+}
+
+// fromCLI sets the fields of the definition dst from the urfave/cli flag f.
+func fromCLI[T, C any, VC cli.ValueCreator[T, C]](dst *cobraflags.FlagBase[T], f *cli.FlagBase[T, C, VC]) {
+	dst.Name = f.Name
+	for _, alias := range f.Aliases {
+		if len(alias) == 1 {
+			dst.Shorthand = alias
+			break
+		}
+	}
+	dst.Usage = f.Usage
+	dst.Group = f.Category
+	dst.Value = f.Value
+	dst.Required = f.Required
+	dst.Persistent = !f.Local
+	envVars := f.Sources.EnvKeys()
+	if len(envVars) > 0 {
+		dst.EnvVar = envVars[0]
+	}
+	if len(envVars) > 1 {
+		dst.EnvAliases = envVars[1:]
+	}
+	dst.ValidateFunc = f.Validator
+}
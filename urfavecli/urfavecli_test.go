@@ -0,0 +1,105 @@
+package urfavecli_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+	"github.com/urfave/cli/v3"
+
+	"github.com/go-extras/cobraflags"
+	"github.com/go-extras/cobraflags/urfavecli"
+)
+
+func TestToCLI(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("CLIAPP_SERVER_PORT", "9090")
+	c.Setenv("OLD_HOST", "old.example.com")
+
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Shorthand: "p", Value: 8080,
+		Usage: "Server port", Group: "Server", Validator: cobraflags.OneOf(8080, 9090)}
+	hostFlag := &cobraflags.StringFlag{Name: "host", EnvVar: "CLIAPP_HOST", EnvAliases: []string{"OLD_HOST"}, Persistent: true}
+	tagsFlag := &cobraflags.StringSliceFlag{Name: "tags", Value: []string{"a"}}
+	yesFlag := &cobraflags.ConfirmFlag{BoolFlag: cobraflags.BoolFlag{Name: "yes"}}
+
+	flags, err := urfavecli.ToCLI("CLIAPP", portFlag, hostFlag, tagsFlag, yesFlag)
+	c.Assert(err, qt.IsNil)
+	c.Assert(flags, qt.HasLen, 4)
+	port := flags[0].(*cli.IntFlag)
+	c.Assert(port.Aliases, qt.DeepEquals, []string{"p"})
+	c.Assert(port.Usage, qt.Equals, "Server port")
+	c.Assert(port.Category, qt.Equals, "Server")
+	c.Assert(port.Value, qt.Equals, 8080)
+	c.Assert(port.Local, qt.IsTrue)
+	c.Assert(port.Sources.EnvKeys(), qt.DeepEquals, []string{"CLIAPP_SERVER_PORT"})
+	c.Assert(flags[1].(*cli.StringFlag).Sources.EnvKeys(), qt.DeepEquals, []string{"CLIAPP_HOST", "OLD_HOST"})
+	c.Assert(flags[1].(*cli.StringFlag).Local, qt.IsFalse)
+
+	var gotPort int
+	var gotHost string
+	var gotTags []string
+	cmd := &cli.Command{Name: "cliapp", Flags: flags, Action: func(_ context.Context, cmd *cli.Command) error {
+		gotPort, gotHost, gotTags = cmd.Int("port"), cmd.String("host"), cmd.StringSlice("tags")
+		return nil
+	}}
+	c.Assert(cmd.Run(context.Background(), []string{"cliapp"}), qt.IsNil)
+	c.Assert(gotPort, qt.Equals, 9090)
+	c.Assert(gotHost, qt.Equals, "old.example.com")
+	c.Assert(gotTags, qt.DeepEquals, []string{"a"})
+
+	// The validator is carried over.
+	flags, err = urfavecli.ToCLI("CLIAPP", portFlag)
+	c.Assert(err, qt.IsNil)
+	cmd = &cli.Command{Name: "cliapp", Flags: flags, Writer: &discard{}, ErrWriter: &discard{}}
+	c.Assert(cmd.Run(context.Background(), []string{"cliapp", "-p", "1"}), qt.ErrorMatches, `.*must be one of.*`)
+}
+
+func TestFromCLI(t *testing.T) {
+	c := qt.New(t)
+
+	errOdd := errors.New("odd")
+	flags, err := urfavecli.FromCLI(
+		&cli.IntFlag{Name: "workers", Aliases: []string{"concurrency", "w"}, Value: 4, Usage: "Workers", Category: "Server",
+			Sources: cli.EnvVars("APP_WORKERS", "OLD_WORKERS"), Validator: func(n int) error {
+				if n%2 == 1 {
+					return errOdd
+				}
+				return nil
+			}},
+		&cli.BoolFlag{Name: "verbose", Local: true},
+		&cli.Uint8Flag{Name: "level", Value: 3, Required: true},
+	)
+	c.Assert(err, qt.IsNil)
+	c.Assert(flags, qt.HasLen, 3)
+
+	workers := flags[0].(*cobraflags.IntFlag)
+	c.Assert(workers.Name, qt.Equals, "workers")
+	c.Assert(workers.Shorthand, qt.Equals, "w")
+	c.Assert(workers.Value, qt.Equals, 4)
+	c.Assert(workers.Group, qt.Equals, "Server")
+	c.Assert(workers.Persistent, qt.IsTrue)
+	c.Assert(workers.EnvVar, qt.Equals, "APP_WORKERS")
+	c.Assert(workers.EnvAliases, qt.DeepEquals, []string{"OLD_WORKERS"})
+	c.Assert(flags[1].(*cobraflags.BoolFlag).Persistent, qt.IsFalse)
+	c.Assert(flags[2].(*cobraflags.Uint8Flag).Required, qt.IsTrue)
+
+	cmd := &cobra.Command{Use: "app", Run: func(*cobra.Command, []string) {}}
+	cobraflags.Register(cmd, flags...)
+	cmd.SetArgs([]string{"-w", "3", "--level", "1"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	_, err = workers.GetIntE()
+	c.Assert(err, qt.Equals, errOdd)
+}
+
+func TestUnsupported(t *testing.T) {
+	c := qt.New(t)
+
+	_, err := urfavecli.FromCLI(&cli.DurationFlag{Name: "timeout"})
+	c.Assert(err, qt.ErrorMatches, `urfavecli: unsupported flag type \*cli.FlagBase.*`)
+}
+
+type discard struct{}
+
+func (*discard) Write(p []byte) (int, error) { return len(p), nil }
@@ -2,6 +2,7 @@ package cobraflags
 
 import (
 	"fmt"
+	"slices"
 )
 
 // Validator is an interface that defines a method for validating a value.
@@ -10,6 +11,20 @@ type Validator interface {
 	Validate(any) error
 }
 
+// ValidationMode selects how the ValidateFunc and the Validator of a flag combine
+// when both are set. It has no effect if only one of them is set.
+type ValidationMode int
+
+const (
+	// ValidationFirstError runs ValidateFunc, then Validator if ValidateFunc succeeded,
+	// and returns the first error. This is the default.
+	ValidationFirstError ValidationMode = iota
+	// ValidationRunAll runs both and returns their errors joined with errors.Join.
+	ValidationRunAll
+	// ValidationFuncOnly runs ValidateFunc only; Validator is ignored.
+	ValidationFuncOnly
+)
+
 // ValidatorFunc implements the Validator interface.
 var _ Validator = (*ValidatorFunc[any])(nil)
 
@@ -27,3 +42,42 @@ func (f ValidatorFunc[T]) Validate(value any) error {
 	}
 	return f(v)
 }
+
+// OneOf returns a Validator that accepts only the given values. Unlike other validators,
+// the allowed values are known to cobraflags, so they are listed as the enum of the
+// flag in FlagInfo and in the schema generated by GenJSONSchema, and shells complete them.
+// Since cobra accepts a single completion function per flag, set FlagBase.NoCompletion to
+// register one of your own with cobra.Command.RegisterFlagCompletionFunc instead.
+// Note, T must be the same type as the flag value.
+func OneOf[T comparable](values ...T) Validator {
+	return oneOf[T](values)
+}
+
+// oneOf is the Validator returned by OneOf.
+type oneOf[T comparable] []T
+
+// Validate checks that value is one of the allowed values.
+func (o oneOf[T]) Validate(value any) error {
+	v, ok := value.(T)
+	if !ok {
+		return fmt.Errorf("invalid value type, expected %T, got %T", v, value)
+	}
+	if !slices.Contains(o, v) {
+		return fmt.Errorf("invalid value %v, must be one of %v", v, []T(o))
+	}
+	return nil
+}
+
+// enum returns the allowed values.
+func (o oneOf[T]) enum() []any {
+	values := make([]any, len(o))
+	for i, v := range o {
+		values[i] = v
+	}
+	return values
+}
+
+// enumerator is implemented by validators that accept a fixed set of values.
+type enumerator interface {
+	enum() []any
+}
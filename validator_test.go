@@ -1,6 +1,7 @@
 package cobraflags_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -55,3 +56,86 @@ func TestValidatorFunc_InvalidType(t *testing.T) {
 	c.Assert(err, qt.IsNotNil)
 	c.Assert(err.Error(), qt.Matches, "invalid value type, expected.*")
 }
+
+// TestOneOf tests that OneOf accepts only the given values.
+func TestOneOf(t *testing.T) {
+	c := qt.New(t)
+
+	validator := cobraflags.OneOf("debug", "info")
+	c.Assert(validator.Validate("info"), qt.IsNil)
+	c.Assert(validator.Validate("trace"), qt.ErrorMatches, `invalid value trace, must be one of \[debug info\]`)
+	c.Assert(validator.Validate(1), qt.ErrorMatches, "invalid value type, expected.*")
+}
+
+// TestValidationMode tests how ValidateFunc and Validator combine in each ValidationMode.
+func TestValidationMode(t *testing.T) {
+	errFunc := errors.New("func error")
+	errValidator := errors.New("validator error")
+
+	tests := []struct {
+		name         string
+		mode         cobraflags.ValidationMode
+		funcErr      error
+		validatorErr error
+		want         []error
+		wantRuns     int
+	}{
+		{name: "first error, func fails", mode: cobraflags.ValidationFirstError, funcErr: errFunc, validatorErr: errValidator, want: []error{errFunc}, wantRuns: 1},
+		{name: "first error, validator fails", mode: cobraflags.ValidationFirstError, validatorErr: errValidator, want: []error{errValidator}, wantRuns: 2},
+		{name: "run all, both fail", mode: cobraflags.ValidationRunAll, funcErr: errFunc, validatorErr: errValidator, want: []error{errFunc, errValidator}, wantRuns: 2},
+		{name: "run all, success", mode: cobraflags.ValidationRunAll, wantRuns: 2},
+		{name: "func only, validator ignored", mode: cobraflags.ValidationFuncOnly, validatorErr: errValidator, wantRuns: 1},
+		{name: "func only, func fails", mode: cobraflags.ValidationFuncOnly, funcErr: errFunc, validatorErr: errValidator, want: []error{errFunc}, wantRuns: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			runs := 0
+			cmd := newCobraCommand()
+			flag := &cobraflags.IntFlag{
+				Name:           "port",
+				ValidationMode: tt.mode,
+				ValidateFunc: func(int) error {
+					runs++
+					return tt.funcErr
+				},
+				Validator: cobraflags.ValidatorFunc[int](func(int) error {
+					runs++
+					return tt.validatorErr
+				}),
+			}
+			flag.Register(cmd)
+			c.Assert(cmd.Execute(), qt.IsNil)
+
+			_, err := flag.GetIntE()
+			c.Assert(runs, qt.Equals, tt.wantRuns)
+			if len(tt.want) == 0 {
+				c.Assert(err, qt.IsNil)
+				return
+			}
+			for _, want := range tt.want {
+				c.Assert(errors.Is(err, want), qt.IsTrue, qt.Commentf("%v", err))
+			}
+		})
+	}
+}
+
+// TestValidationMode_ValidatorOnly tests that Validator runs in every mode if ValidateFunc is not set.
+func TestValidationMode_ValidatorOnly(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	flag := &cobraflags.StringFlag{
+		Name:           "level",
+		Value:          "trace",
+		ValidationMode: cobraflags.ValidationFuncOnly,
+		Validator:      cobraflags.OneOf("debug", "info"),
+	}
+	flag.Register(cmd)
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	_, err := flag.GetStringE()
+	c.Assert(err, qt.ErrorMatches, `invalid value trace, must be one of \[debug info\]`)
+}
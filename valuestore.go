@@ -0,0 +1,112 @@
+package cobraflags
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// ValueStore is a configuration store that a command tree exchanges values with, see
+// WithValueStore. Viper implements it through NewViperStore; the koanfstore package
+// implements it with koanf, for applications that have standardized on koanf. A value
+// store complements the Viper instance of the tree, it does not replace it.
+type ValueStore interface {
+	// Get returns the value stored under key, or nil if there is none.
+	Get(key string) any
+	// Set stores value under key.
+	Set(key string, value any) error
+	// IsSet reports whether a value is stored under key.
+	IsSet(key string) bool
+	// BindFlag makes the value of flag available under key once it is set.
+	BindFlag(key string, flag *pflag.Flag) error
+}
+
+// viperStore is the ValueStore of a Viper instance.
+type viperStore struct {
+	v *viper.Viper
+}
+
+// NewViperStore returns the ValueStore of the Viper instance v.
+func NewViperStore(v *viper.Viper) ValueStore {
+	return viperStore{v: v}
+}
+
+func (s viperStore) Get(key string) any {
+	return s.v.Get(key)
+}
+
+func (s viperStore) Set(key string, value any) error {
+	s.v.Set(key, value)
+	return nil
+}
+
+func (s viperStore) IsSet(key string) bool {
+	return s.v.IsSet(key)
+}
+
+func (s viperStore) BindFlag(key string, flag *pflag.Flag) error {
+	return s.v.BindPFlag(key, flag)
+}
+
+// WithValueStore exchanges values between the command tree and the application's own
+// configuration store vs, e.g. a koanf instance (see the koanfstore package). When the
+// tree is initialized, the values vs holds for the Viper keys of the flags are merged
+// into the configuration, so they are resolved with the precedence default < config
+// file < value store < environment variable < command line, and their source is reported
+// as SourceConfig. In turn, every flag is bound to vs under its Viper key, so that the
+// application can read the values given on the command line or in the environment from vs.
+//
+// Viper remains the store the flags are read from; vs complements it. cobraflags is built
+// on Viper and imports it either way, so a value store does not spare an application
+// Viper's dependencies, only the koanfstore module keeps koanf out of those that do not
+// use it.
+//
+// Example:
+//
+//	k := koanf.New(".")
+//	_ = k.Load(file.Provider("config.toml"), toml.Parser())
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd, cobraflags.WithValueStore(koanfstore.New(k)))
+func WithValueStore(vs ValueStore) InitOption {
+	return func(c *initConfig) {
+		c.valueStore = vs
+	}
+}
+
+// readValueStore merges the values vs holds for the flags of cmd's command tree into its
+// configuration and binds the flags to vs, see WithValueStore.
+func readValueStore(cmd *cobra.Command, vs ValueStore) error {
+	if vs == nil {
+		return nil
+	}
+
+	config := make(map[string]any)
+	var errs []error
+	walkCommands(cmd, func(c *cobra.Command) {
+		for _, entry := range registeredOn(c) {
+			f, _ := entry.base.identity()
+			if f == nil {
+				continue
+			}
+			key := viperKeyOf(f)
+			if vs.IsSet(key) {
+				setNested(config, strings.Split(key, "."), vs.Get(key))
+			}
+			if err := vs.BindFlag(key, f); err != nil {
+				errs = append(errs, fmt.Errorf("binding flag %q to the value store: %w", f.Name, err))
+			}
+		}
+	})
+	if len(errs) > 0 || len(config) == 0 {
+		return errors.Join(errs...)
+	}
+
+	st := storeFor(cmd)
+	st.lock()
+	defer st.mu.Unlock()
+
+	return st.mergeConfigMap(config)
+}
@@ -0,0 +1,45 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestWithValueStore(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("STOREAPP_LEVEL", "debug")
+
+	ext := viper.New()
+	ext.SetConfigType("yaml")
+	ext.SetDefault("server.port", 9090)
+	ext.SetDefault("level", "error")
+	store := cobraflags.NewViperStore(ext)
+
+	root := newCobraCommand()
+	serve := &cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(serve)
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 80}
+	levelFlag := &cobraflags.StringFlag{Name: "level", Value: "info", Persistent: true}
+	hostFlag := &cobraflags.StringFlag{Name: "host", Value: "localhost"}
+	portFlag.Register(serve)
+	hostFlag.Register(serve)
+	levelFlag.Register(root)
+	cobraflags.CobraOnInitialize("STOREAPP", root, cobraflags.WithValueStore(store))
+
+	root.SetArgs([]string{"serve", "--host", "cli.example.com"})
+	c.Assert(root.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9090)
+	c.Assert(cobraflags.FlagsOf(serve)[0].Source, qt.Equals, cobraflags.SourceConfig)
+	c.Assert(levelFlag.GetString(), qt.Equals, "debug") // The environment ranks above the store.
+
+	// The flags are bound to the store.
+	c.Assert(store.IsSet("host"), qt.IsTrue)
+	c.Assert(store.Get("host"), qt.Equals, "cli.example.com")
+	c.Assert(store.Set("extra", 1), qt.IsNil)
+	c.Assert(ext.GetInt("extra"), qt.Equals, 1)
+}
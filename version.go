@@ -0,0 +1,73 @@
+package cobraflags
+
+import (
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// Names of the annotations holding the build information set by VersionFlag.
+const (
+	versionCommitAnnotation = "cobraflags-version-commit"
+	versionDateAnnotation   = "cobraflags-version-date"
+)
+
+// DefaultVersionTemplate is the template VersionFlag prints the build information with.
+// Besides the fields of the command, such as .Version, templates can use the functions
+// buildCommit and buildDate, which return the commit and date given to VersionFlag.
+const DefaultVersionTemplate = `{{.DisplayName}} version {{.Version}}
+{{- with buildCommit .}}
+commit: {{.}}{{end}}
+{{- with buildDate .}}
+built: {{.}}{{end}}
+`
+
+var addVersionTemplateFuncs sync.Once
+
+// VersionFlag adds a --version/-V flag to cmd, which prints the build information and
+// exits without running the command, its hooks or the initializers of CobraOnInitialize,
+// so that required flags need not be set. The output follows DefaultVersionTemplate; to
+// customize it, call cmd.SetVersionTemplate after VersionFlag. The commit and date are
+// left out if empty. The -V shorthand is only added if it is not taken.
+//
+// The flag is never preset from the environment or shown with an environment variable.
+// VersionFlag does nothing but set the build information if cmd already has a version flag.
+//
+// Example:
+//
+//	var version, commit, date string // Set with -ldflags "-X main.version=..."
+//
+//	cobraflags.VersionFlag(rootCmd, version, commit, date)
+//	// $ myapp --version
+//	// myapp version 1.2.0
+//	// commit: 3f2c1ab
+//	// built: 2024-05-01T10:00:00Z
+func VersionFlag(cmd *cobra.Command, version, commit, date string) {
+	addVersionTemplateFuncs.Do(func() {
+		cobra.AddTemplateFunc("buildCommit", func(c *cobra.Command) string {
+			return c.Annotations[versionCommitAnnotation]
+		})
+		cobra.AddTemplateFunc("buildDate", func(c *cobra.Command) string {
+			return c.Annotations[versionDateAnnotation]
+		})
+	})
+
+	cmd.Version = version
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[versionCommitAnnotation] = commit
+	cmd.Annotations[versionDateAnnotation] = date
+	cmd.SetVersionTemplate(DefaultVersionTemplate)
+
+	// cobra prints the version of the command when its local version flag is set.
+	if cmd.Flags().Lookup("version") != nil {
+		return
+	}
+	shorthand := "V"
+	if cmd.Flags().ShorthandLookup(shorthand) != nil {
+		shorthand = ""
+	}
+	cmd.Flags().BoolP("version", shorthand, false, "version for "+cmd.DisplayName())
+	setAnnotation(cmd.Flags().Lookup("version"), noEnvAnnotation, "true")
+}
@@ -0,0 +1,66 @@
+package cobraflags_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestVersionFlag(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("VERAPP_VERSION", "true")
+
+	newCommand := func(args ...string) (*cobra.Command, *bytes.Buffer, *bool) {
+		cmd := newCobraCommand()
+		ran := false
+		cmd.RunE = func(*cobra.Command, []string) error {
+			ran = true
+			return nil
+		}
+		(&cobraflags.StringFlag{Name: "token", Required: true}).Register(cmd)
+		cobraflags.VersionFlag(cmd, "1.2.0", "3f2c1ab", "2024-05-01")
+		cobraflags.CobraOnInitialize("VERAPP", cmd)
+
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		return cmd, &out, &ran
+	}
+
+	// The required flag is not checked.
+	cmd, out, ran := newCommand("-V")
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Equals, "myapp version 1.2.0\ncommit: 3f2c1ab\nbuilt: 2024-05-01\n")
+	c.Assert(*ran, qt.IsFalse)
+
+	// The environment does not set the flag.
+	cmd, out, ran = newCommand("--token", "secret")
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Equals, "")
+	c.Assert(*ran, qt.IsTrue)
+	c.Assert(cmd.Flags().Lookup("version").Usage, qt.Equals, "version for myapp")
+
+	cmd, out, _ = newCommand("--version")
+	cmd.SetVersionTemplate("{{.Version}}+{{buildCommit .}}\n")
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Equals, "1.2.0+3f2c1ab\n")
+}
+
+func TestVersionFlag_ShorthandTaken(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.BoolFlag{Name: "verbose", Shorthand: "V"}).Register(cmd)
+	cobraflags.VersionFlag(cmd, "1.2.0", "", "")
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--version"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(out.String(), qt.Equals, "myapp version 1.2.0\n")
+	c.Assert(cmd.Flags().Lookup("version").Shorthand, qt.Equals, "")
+}
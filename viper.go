@@ -0,0 +1,191 @@
+package cobraflags
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// store wraps a Viper instance together with the lock that serializes
+// access to it, since Viper itself is not safe for concurrent use.
+//
+// Reads of flag values take the read lock; binding and initialization,
+// which mutate the instance or the bound pflag values, take the write lock
+// (see lock).
+type store struct {
+	mu          sync.RWMutex
+	v           *viper.Viper
+	b           backend                // Replaces v for the key-level accesses if set, see values.
+	configFiles []string               // The config files read during initialization, in merge order, see ConfigFilesUsed.
+	remote      *remoteConfig          // The remote configuration read during initialization, see WithRemoteConfig.
+	secrets     map[string]string      // The secrets read during initialization by lower-cased key, see WithSecretsDir.
+	sets        []string               // The key=value overrides given with --set, see WithSetFlag.
+	bound       map[string]*pflag.Flag // The flag bound to each key, see bindFlag.
+
+	// caseSensitive is set if the keys preserve their case, see WithCaseSensitiveKeys.
+	caseSensitive atomic.Bool
+
+	// resolved is the generation of the values once they have settled (see settle), or 0
+	// while they may change. Flags cache the values they read in a generation.
+	resolved atomic.Uint64
+}
+
+// vipers stores the Viper instance of every command tree, keyed by its root command,
+// so that independent command trees in one process never share configuration state.
+var vipers = make(map[*cobra.Command]*store)
+var vipersMutex sync.Mutex
+
+// assignments counts the changes to which store belongs to which command, e.g. by
+// WithViper or ResetState, so that bound flags notice when to bind anew, see FlagBase.bind.
+var assignments atomic.Uint64
+
+// scopedRoots marks the root commands whose subcommands get a Viper instance each,
+// see WithCommandScopedViper.
+var scopedRoots = make(map[*cobra.Command]bool)
+
+// ViperFor returns the Viper instance that flags registered on cmd's command tree bind into.
+//
+// Every command tree gets its own instance, created on first use and keyed by the
+// root command, unless one is injected with WithViper. This means that two
+// cobraflags-based CLIs embedded in one binary do not interfere with each other,
+// and that the global viper singleton is never touched. Since the instance is
+// resolved through cmd.Root(), ViperFor should be called after the command tree
+// has been assembled.
+//
+// If the tree was initialized with WithCommandScopedViper, every command has its
+// own instance instead, and ViperFor returns the one of cmd itself.
+//
+// Example:
+//
+//	cobraflags.ViperFor(rootCmd).SetConfigFile("config.yaml")
+//	_ = cobraflags.ViperFor(rootCmd).ReadInConfig()
+func ViperFor(cmd *cobra.Command) *viper.Viper {
+	return storeFor(cmd).v
+}
+
+// SetValue sets the value of key in the Viper instance of cmd's command tree (see ViperFor),
+// where it overrides flags, the environment and configuration files, like calling Set on
+// the instance. Unlike that, it discards the values cached for the flags of the tree (see
+// InvalidateCache), so that they return the new value while the command is running.
+//
+// Example:
+//
+//	cobraflags.SetValue(cmd, "log.level", "debug")
+func SetValue(cmd *cobra.Command, key string, value any) {
+	st := storeFor(cmd)
+	st.lock()
+	st.values().Set(key, value)
+	st.mu.Unlock()
+	InvalidateCache(cmd.Root())
+}
+
+// storeFor returns the store of cmd's command tree (or of cmd itself, if command scoped), creating it if necessary.
+func storeFor(cmd *cobra.Command) *store {
+	vipersMutex.Lock()
+	defer vipersMutex.Unlock()
+
+	key := storeKey(cmd)
+	st, ok := vipers[key]
+	if !ok {
+		st = &store{}
+		st.v = newViper(st.caseSensitive.Load)
+		st.caseSensitive.Store(caseSensitiveRoots[key.Root()])
+		vipers[key] = st
+	}
+	return st
+}
+
+// existingStoreFor returns the store of cmd like storeFor, or nil if it has none yet.
+func existingStoreFor(cmd *cobra.Command) *store {
+	vipersMutex.Lock()
+	defer vipersMutex.Unlock()
+
+	return vipers[storeKey(cmd)]
+}
+
+// storeKey returns the command the store of cmd is kept for in vipers: the root command,
+// or cmd itself if command scoped. vipersMutex must be held.
+func storeKey(cmd *cobra.Command) *cobra.Command {
+	root := cmd.Root()
+	if scopedRoots[root] {
+		return cmd
+	}
+	return root
+}
+
+// WithCommandScopedViper gives every command of the tree a dedicated Viper instance
+// instead of sharing one per root command. Flags bind into the instance of the command
+// they are registered on, so flags named "port" on two sibling subcommands no longer
+// share a value through the common "port" key. Persistent flags are read through the
+// instance of the command that defines them.
+//
+// Environment variables still map to the same names, so the prefix and Viper keys
+// should be chosen accordingly. The instance of the root command is the one set by
+// WithViper, if given.
+//
+// Example:
+//
+//	cobraflags.CobraOnInitialize("MYAPP", rootCmd, cobraflags.WithCommandScopedViper())
+//	serverViper := cobraflags.ViperFor(serverCmd)
+func WithCommandScopedViper() InitOption {
+	return func(c *initConfig) {
+		c.commandScoped = true
+	}
+}
+
+// setCommandScoped marks cmd's command tree as using one Viper instance per command.
+func setCommandScoped(cmd *cobra.Command) {
+	vipersMutex.Lock()
+	defer vipersMutex.Unlock()
+
+	scopedRoots[cmd.Root()] = true
+	assignments.Add(1)
+}
+
+// setViper attaches the given Viper instance to cmd's command tree.
+func setViper(cmd *cobra.Command, v *viper.Viper) {
+	vipersMutex.Lock()
+	defer vipersMutex.Unlock()
+
+	st := &store{v: v}
+	st.caseSensitive.Store(caseSensitiveRoots[cmd.Root()])
+	vipers[cmd.Root()] = st
+	assignments.Add(1)
+}
+
+// configureEnv sets up how the Viper instance of the store derives environment variable
+// names from keys. The write lock must be held.
+func (st *store) configureEnv(envPrefix string, cfg *initConfig) {
+	if !cfg.bindEnvExplicitly() {
+		st.v.AutomaticEnv() // Enable automatic detection of environment variables.
+	}
+	st.v.SetEnvPrefix(envPrefix)              // Set the prefix for environment variables.
+	st.v.SetEnvKeyReplacer(cfg.keyReplacer()) // Set the replacer for environment variable names.
+}
+
+// mergeConfigMap merges the nested map config into the configuration of the Viper
+// instance, escaping its keys if they are case-sensitive. The write lock must be held.
+func (st *store) mergeConfigMap(config map[string]any) error {
+	if st.caseSensitive.Load() {
+		config = mapKeys(config, escapeKey).(map[string]any)
+	}
+	return st.v.MergeConfigMap(config)
+}
+
+// bindFlag binds the flag to key, unless it is bound already. The write lock must be held.
+func (st *store) bindFlag(key string, f *pflag.Flag) error {
+	if st.bound[key] == f {
+		return nil
+	}
+	if err := st.values().BindPFlag(key, f); err != nil {
+		return err
+	}
+	if st.bound == nil {
+		st.bound = make(map[string]*pflag.Flag)
+	}
+	st.bound[key] = f
+	return nil
+}
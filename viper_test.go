@@ -0,0 +1,166 @@
+package cobraflags_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/go-extras/cobraflags"
+)
+
+func TestViperFor_SameTree(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "sub"}
+	root.AddCommand(sub)
+
+	v := cobraflags.ViperFor(root)
+
+	c.Assert(v, qt.Not(qt.IsNil))
+	c.Assert(cobraflags.ViperFor(sub), qt.Equals, v)
+	c.Assert(v, qt.Not(qt.Equals), viper.GetViper())
+}
+
+func TestViperFor_IndependentTrees(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("FIRSTCLI_PORT", "1111")
+	c.Setenv("SECONDCLI_PORT", "2222")
+
+	first := newCobraCommand()
+	firstPort := &cobraflags.IntFlag{Name: "port", Value: 80}
+	firstPort.Register(first)
+	cobraflags.CobraOnInitialize("FIRSTCLI", first)
+
+	second := newCobraCommand()
+	secondPort := &cobraflags.IntFlag{Name: "port", Value: 80}
+	secondPort.Register(second)
+	cobraflags.CobraOnInitialize("SECONDCLI", second)
+
+	first.SetArgs(make([]string, 0))
+	c.Assert(first.Execute(), qt.IsNil)
+	second.SetArgs([]string{"--port", "3333"})
+	c.Assert(second.Execute(), qt.IsNil)
+
+	c.Assert(firstPort.GetInt(), qt.Equals, 1111)
+	c.Assert(secondPort.GetInt(), qt.Equals, 3333)
+	c.Assert(viper.IsSet("port"), qt.IsFalse)
+}
+
+func TestWithViper(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("INJECTED_NAME", "from-env")
+
+	v := viper.New()
+	v.Set("timeout", 30)
+
+	cmd := newCobraCommand()
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	timeoutFlag := &cobraflags.IntFlag{Name: "timeout", Value: 10}
+	cobraflags.Register(cmd, nameFlag, timeoutFlag)
+	cobraflags.CobraOnInitialize("INJECTED", cmd, cobraflags.WithViper(v))
+
+	c.Assert(cobraflags.ViperFor(cmd), qt.Equals, v)
+
+	cmd.SetArgs(make([]string, 0))
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	c.Assert(nameFlag.GetString(), qt.Equals, "from-env")
+	c.Assert(timeoutFlag.GetInt(), qt.Equals, 30)
+	c.Assert(v.GetString("name"), qt.Equals, "from-env")
+}
+
+func TestWithCommandScopedViper(t *testing.T) {
+	c := qt.New(t)
+
+	root := newCobraCommand()
+	server := &cobra.Command{Use: "server", Run: func(*cobra.Command, []string) {}}
+	client := &cobra.Command{Use: "client", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(server, client)
+
+	verboseFlag := &cobraflags.BoolFlag{Name: "verbose", Persistent: true}
+	serverPort := &cobraflags.IntFlag{Name: "port", Value: 8080}
+	clientPort := &cobraflags.IntFlag{Name: "port", Value: 9090}
+	verboseFlag.Register(root)
+	serverPort.Register(server)
+	clientPort.Register(client)
+	cobraflags.CobraOnInitialize("SCOPED", root, cobraflags.WithCommandScopedViper())
+
+	c.Assert(cobraflags.ViperFor(server), qt.Not(qt.Equals), cobraflags.ViperFor(client))
+	c.Assert(cobraflags.ViperFor(server), qt.Not(qt.Equals), cobraflags.ViperFor(root))
+
+	root.SetArgs([]string{"server", "--port", "1234", "--verbose"})
+	c.Assert(root.Execute(), qt.IsNil)
+
+	c.Assert(serverPort.GetInt(), qt.Equals, 1234)
+	c.Assert(clientPort.GetInt(), qt.Equals, 9090)
+	c.Assert(verboseFlag.GetBool(), qt.IsTrue)
+}
+
+func TestViperKey_CaseInsensitive(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "config.yaml", "APP:\n  configfile: from-config.yaml\n")
+
+	cmd := newCobraCommand()
+	fileFlag := &cobraflags.StringFlag{Name: "config-file", ViperKey: "app.ConfigFile"}
+	fileFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("CASEAPP", cmd, cobraflags.WithConfigFile("config", "yaml", dir))
+
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(fileFlag.GetString(), qt.Equals, "from-config.yaml")
+	c.Assert(cobraflags.FlagsOf(cmd)[0].ViperKey, qt.Equals, "app.ConfigFile")
+	c.Assert(cobraflags.FlagsOf(cmd)[0].EnvVar, qt.Equals, "CASEAPP_APP_CONFIGFILE")
+}
+
+func TestViperKey_CaseCollision(t *testing.T) {
+	c := qt.New(t)
+
+	// Keys that differ only in case are reported when the command tree is initialized,
+	// since they only collide unless WithCaseSensitiveKeys is given.
+	cmd := newCobraCommand()
+	(&cobraflags.StringFlag{Name: "config-file", ViperKey: "app.ConfigFile"}).Register(cmd)
+	c.Assert((&cobraflags.StringFlag{Name: "configfile", ViperKey: "app.configfile"}).RegisterE(cmd), qt.IsNil)
+	cobraflags.CobraOnInitialize("CASEAPP", cmd, cobraflags.WithStrictEnv())
+
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `(?s)flags --config-file of "myapp" and --configfile of "myapp" share the Viper key "app.configfile" \(Viper keys are case-insensitive\).*`)
+
+	// The same goes across commands.
+	root := newCobraCommand()
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+	(&cobraflags.StringFlag{Name: "config-file", ViperKey: "app.ConfigFile", Persistent: true}).Register(root)
+	(&cobraflags.StringFlag{Name: "configfile", ViperKey: "app.configfile"}).Register(sub)
+	cobraflags.CobraOnInitialize("CASEAPP", root, cobraflags.WithStrictEnv())
+
+	c.Assert(root.Execute(), qt.ErrorMatches, `(?s)flags --config-file of "myapp" and --configfile of "myapp sub" share the Viper key "app.configfile" \(Viper keys are case-insensitive\).*`)
+}
+
+func TestEagerBind(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	v := cobraflags.ViperFor(cmd)
+	portFlag := &cobraflags.IntFlag{Name: "port", ViperKey: "server.port", Value: 8080, EagerBind: true}
+	portFlag.Register(cmd)
+	lazyFlag := &cobraflags.IntFlag{Name: "workers", Value: 4}
+	lazyFlag.Register(cmd)
+
+	// Only the eager flag is known to Viper before it is read.
+	c.Assert(v.GetInt("server.port"), qt.Equals, 8080)
+	c.Assert(v.Get("workers"), qt.IsNil)
+
+	cmd.SetArgs([]string{"--port", "9090"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9090)
+
+	// Without a Viper instance, the flag is bound on first read.
+	other := newCobraCommand()
+	otherFlag := &cobraflags.IntFlag{Name: "port", Value: 80, EagerBind: true}
+	otherFlag.Register(other)
+	c.Assert(otherFlag.GetInt(), qt.Equals, 80)
+	c.Assert(cobraflags.ViperFor(other).GetInt("port"), qt.Equals, 80)
+}
@@ -0,0 +1,290 @@
+package cobraflags
+
+import (
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// WatchConfig watches the configuration files read during initialization of cmd's command
+// tree (see ConfigFilesUsed) and reloads them whenever one of them changes. Flags whose value
+// came from the files are preset again; flags set on the command line or through environment
+// variables keep their value, and flags removed from the files fall back to their default.
+//
+// After a reload, the OnChange hook of every flag whose effective value changed is called
+// with the new value, and then onChange (if not nil) with the names of those flags.
+// Nothing is called if no value changed. A file that cannot be read or parsed is ignored,
+// and the previous configuration stays in effect. Frozen flags (see Freeze) do not change.
+//
+// WatchConfig must be called after initialization, e.g. from Run. It does nothing
+// if no configuration file was read. The returned function stops watching, and waits for
+// a reload in progress to finish, so it must not be called from the change hooks. Calling
+// WatchConfig again for the same command tree stops the previous watcher.
+//
+// Example:
+//
+//	RunE: func(cmd *cobra.Command, _ []string) error {
+//		stop := cobraflags.WatchConfig(cmd, func(changed []string) {
+//			log.Printf("configuration changed: %v", changed)
+//		})
+//		defer stop()
+//		return serve()
+//	}
+func WatchConfig(cmd *cobra.Command, onChange func(changed []string)) (stop func()) {
+	root := cmd.Root()
+	files := ConfigFilesUsed(root)
+	st := storeFor(root)
+	load := func(v *viper.Viper) error {
+		if err := mergeConfigFiles(v, files); err != nil {
+			return err
+		}
+		return st.applySets(st.sets) // Called with the store's lock held.
+	}
+
+	stopWatching(root)
+	if len(files) == 0 {
+		return func() {}
+	}
+	w, err := watchFiles(files, func() {
+		reloadConfig(root, load, onChange)
+	})
+	if err != nil {
+		slog.Warn("cannot watch the configuration files", "error", err)
+		return func() {}
+	}
+	watchersMutex.Lock()
+	watchers[root] = w
+	watchersMutex.Unlock()
+
+	return func() {
+		w.stop()
+		watchersMutex.Lock()
+		defer watchersMutex.Unlock()
+		if watchers[root] == w {
+			delete(watchers, root)
+		}
+	}
+}
+
+// watchers holds the configuration watcher of each command tree, see WatchConfig.
+var watchers = make(map[*cobra.Command]*configWatcher)
+var watchersMutex sync.Mutex
+
+// stopWatching stops the configuration watcher of the command tree rooted at root, if any.
+func stopWatching(root *cobra.Command) {
+	watchersMutex.Lock()
+	w, ok := watchers[root]
+	delete(watchers, root)
+	watchersMutex.Unlock()
+	if ok {
+		w.stop()
+	}
+}
+
+// configWatcher watches configuration files and reloads them when they change.
+type configWatcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+	once    sync.Once
+}
+
+// watchFiles starts watching files, calling reload whenever one of them changes. The
+// directories of the files are watched rather than the files themselves, so that files
+// replaced atomically, or through a symbolic link as Kubernetes does for ConfigMaps, are
+// noticed as well.
+func watchFiles(files []string, reload func()) (*configWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+		paths = append(paths, path)
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+
+	w := &configWatcher{watcher: watcher, done: make(chan struct{})}
+	go w.run(paths, reload)
+	return w, nil
+}
+
+// run calls reload for every change of one of the files, until the watcher is closed.
+func (w *configWatcher) run(paths []string, reload func()) {
+	defer close(w.done)
+
+	targets := make(map[string]string, len(paths)) // the files the paths resolve to
+	for _, path := range paths {
+		targets[path], _ = filepath.EvalSymlinks(path)
+	}
+	changed := func(event fsnotify.Event) bool {
+		for _, path := range paths {
+			if filepath.Clean(event.Name) == path && event.Has(fsnotify.Write|fsnotify.Create) {
+				return true
+			}
+			if target, _ := filepath.EvalSymlinks(path); target != "" && target != targets[path] {
+				targets[path] = target
+				return true
+			}
+		}
+		return false
+	}
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if changed(event) {
+				reload()
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// stop stops watching and waits for a reload in progress to finish.
+func (w *configWatcher) stop() {
+	w.once.Do(func() {
+		_ = w.watcher.Close()
+		<-w.done
+	})
+}
+
+// reloadConfig reloads the configuration of the tree rooted at root with load,
+// presets the flags again and calls the change hooks.
+func reloadConfig(root *cobra.Command, load func(*viper.Viper) error, onChange func(changed []string)) {
+	before := make(map[registeredFlag]any)
+	walkCommands(root, func(c *cobra.Command) {
+		for _, entry := range registeredOn(c) {
+			before[entry.base] = entry.base.info().Value
+		}
+	})
+
+	st := storeFor(root)
+	settled := st.resolved.Load() != 0
+	if err := represetConfig(root, load); err != nil {
+		return
+	}
+	if settled {
+		settleStores(root) // Cache the reloaded values, the command is still running.
+	}
+
+	var changed []string
+	seen := make(map[string]bool)
+	walkCommands(root, func(c *cobra.Command) {
+		for _, entry := range registeredOn(c) {
+			if reflect.DeepEqual(before[entry.base], entry.base.info().Value) {
+				continue
+			}
+			entry.base.notifyChange()
+			if !seen[entry.name] {
+				seen[entry.name] = true
+				changed = append(changed, entry.name)
+			}
+		}
+	})
+
+	if onChange != nil && len(changed) > 0 {
+		onChange(changed)
+	}
+}
+
+// represetConfig reloads the configuration into the Viper instance of the tree rooted
+// at root with load, and updates the flags that were preset from the configuration.
+func represetConfig(root *cobra.Command, load func(*viper.Viper) error) error {
+	st := storeFor(root)
+	st.lock()
+	defer st.mu.Unlock()
+
+	if err := load(st.v); err != nil {
+		return err
+	}
+	b := st.values()
+
+	visited := make(map[*pflag.Flag]bool)
+	walkCommands(root, func(c *cobra.Command) {
+		visitFlags(c, func(f *pflag.Flag) {
+			// Values not preset are read from Viper, and thus already up to date, except those
+			// of clones, which are not bound to their Viper key, see FlagBase.bindingKey.
+			clone := len(f.Annotations[cloneAnnotation]) > 0
+			if visited[f] || !f.Changed && !clone {
+				return
+			}
+			visited[f] = true
+
+			if annotations := f.Annotations[sourceAnnotation]; f.Changed && (len(annotations) == 0 ||
+				Source(annotations[0]) != SourceConfig && Source(annotations[0]) != SourceViper) {
+				return
+			}
+
+			// The flag must be unchanged for Viper to look past it.
+			preset := f.Changed
+			f.Changed = false
+			key := viperKeyOf(f)
+			if b.IsSet(key) && b.GetString(key) != "" {
+				delete(f.Annotations, sourceAnnotation)
+				source := sourceOf(b, f, key)
+				setValue(f, b.GetString(key))
+				f.Changed = true
+				setAnnotation(f, sourceAnnotation, string(source))
+				return
+			}
+			if !preset {
+				return // A clone that keeps its default.
+			}
+			delete(f.Annotations, sourceAnnotation)
+
+			value := f.DefValue
+			if _, ok := f.Value.(pflag.SliceValue); ok {
+				value = strings.Trim(value, "[]")
+			}
+			setValue(f, value)
+		})
+	})
+	return nil
+}
+
+// setValue replaces the value of a flag. Slice flags start over instead of
+// appending to their current values.
+func setValue(f *pflag.Flag, value string) {
+	if _, ok := f.Value.(pflag.SliceValue); ok {
+		restoreSlice(f, nil)
+		if value == "" {
+			return
+		}
+	}
+	_ = f.Value.Set(value)
+}
+
+// notifyChange calls the OnChange hook of the flag with its current value.
+func (s *FlagBase[T]) notifyChange() {
+	if s.OnChange == nil {
+		return
+	}
+
+	s.mu.RLock()
+	read := s.read
+	s.mu.RUnlock()
+
+	s.OnChange(s.get(read))
+}
@@ -0,0 +1,165 @@
+package cobraflags_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/go-extras/cobraflags"
+)
+
+// replaceConfig atomically replaces the content of a config file.
+func replaceConfig(c *qt.C, path, content string) {
+	tmp := path + ".tmp"
+	c.Assert(os.WriteFile(tmp, []byte(content), 0o600), qt.IsNil)
+	c.Assert(os.Rename(tmp, path), qt.IsNil)
+}
+
+func TestWatchConfig(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig(c, dir, "config.yaml", "port: 9000\nhost: config.example.com\nname: from-config\nlevel: 3\n")
+
+	cmd := newCobraCommand()
+	var ports []int
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80, OnChange: func(port int) {
+		ports = append(ports, port)
+	}}
+	hostFlag := &cobraflags.StringFlag{Name: "host", Value: "localhost"}
+	nameFlag := &cobraflags.StringFlag{Name: "name", Value: "default"}
+	levelFlag := &cobraflags.Uint8Flag{Name: "level", Value: 1}
+	cobraflags.Register(cmd, portFlag, hostFlag, nameFlag, levelFlag)
+	cobraflags.CobraOnInitialize("WATCHAPP", cmd, cobraflags.WithConfigFile("config", "yaml", dir))
+
+	cmd.SetArgs([]string{"--name", "from-cli"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9000)
+
+	changes := make(chan []string, 10)
+	stop := cobraflags.WatchConfig(cmd, func(changed []string) {
+		changes <- changed
+	})
+	defer stop()
+
+	replaceConfig(c, path, "port: 9001\nname: changed\nlevel: 3\n")
+
+	select {
+	case changed := <-changes:
+		c.Assert(changed, qt.DeepEquals, []string{"port", "host"})
+	case <-time.After(10 * time.Second):
+		c.Fatal("timed out waiting for the config reload")
+	}
+
+	c.Assert(portFlag.GetInt(), qt.Equals, 9001)
+	c.Assert(hostFlag.GetString(), qt.Equals, "localhost")
+	c.Assert(nameFlag.GetString(), qt.Equals, "from-cli")
+	c.Assert(levelFlag.GetUint8(), qt.Equals, uint8(3))
+	c.Assert(ports, qt.DeepEquals, []int{9001})
+
+	sources := make(map[string]cobraflags.Source)
+	for _, info := range cobraflags.FlagsOf(cmd) {
+		sources[info.Name] = info.Source
+	}
+	c.Assert(sources["port"], qt.Equals, cobraflags.SourceConfig)
+	c.Assert(sources["host"], qt.Equals, cobraflags.SourceDefault)
+	c.Assert(sources["name"], qt.Equals, cobraflags.SourceFlag)
+}
+
+func TestWatchConfig_NoConfigFile(t *testing.T) {
+	c := qt.New(t)
+
+	cmd := newCobraCommand()
+	(&cobraflags.IntFlag{Name: "port", Value: 80}).Register(cmd)
+	cobraflags.CobraOnInitialize("WATCHAPP", cmd)
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	stop := cobraflags.WatchConfig(cmd, func([]string) {
+		c.Error("unexpected change notification")
+	})
+	stop()
+}
+
+func TestWatchConfig_ConfigFiles(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	writeConfig(c, dir, "base.yaml", "port: 9000\nhost: base.example.com\n")
+	writeConfig(c, dir, "override.yaml", "port: 9001\n")
+
+	cmd := newCobraCommand()
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80}
+	hostFlag := &cobraflags.StringFlag{Name: "host", Value: "localhost"}
+	cobraflags.Register(cmd, portFlag, hostFlag)
+	cobraflags.CobraOnInitialize("WATCHAPP", cmd,
+		cobraflags.WithConfigFiles(filepath.Join(dir, "base.yaml"), filepath.Join(dir, "override.yaml")))
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	changes := make(chan []string, 10)
+	stop := cobraflags.WatchConfig(cmd, func(changed []string) {
+		changes <- changed
+	})
+	defer stop()
+
+	replaceConfig(c, filepath.Join(dir, "base.yaml"), "port: 9000\nhost: changed.example.com\n")
+
+	select {
+	case changed := <-changes:
+		c.Assert(changed, qt.DeepEquals, []string{"host"})
+	case <-time.After(10 * time.Second):
+		c.Fatal("timed out waiting for the config reload")
+	}
+
+	c.Assert(portFlag.GetInt(), qt.Equals, 9001)
+	c.Assert(hostFlag.GetString(), qt.Equals, "changed.example.com")
+}
+
+func TestWatchConfig_Stop(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig(c, dir, "config.yaml", "port: 9000\n")
+
+	cmd := newCobraCommand()
+	var ports []int
+	portFlag := &cobraflags.IntFlag{Name: "port", Value: 80, OnChange: func(port int) {
+		ports = append(ports, port)
+	}}
+	portFlag.Register(cmd)
+	cobraflags.CobraOnInitialize("WATCHSTOPAPP", cmd, cobraflags.WithConfigFile("config", "yaml", dir))
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	// The second call replaces the watcher of the first one.
+	first := make(chan []string, 10)
+	cobraflags.WatchConfig(cmd, func(changed []string) {
+		first <- changed
+	})
+	changes := make(chan []string, 10)
+	stop := cobraflags.WatchConfig(cmd, func(changed []string) {
+		changes <- changed
+	})
+
+	replaceConfig(c, path, "port: 9001\n")
+
+	select {
+	case changed := <-changes:
+		c.Assert(changed, qt.DeepEquals, []string{"port"})
+	case <-time.After(10 * time.Second):
+		c.Fatal("timed out waiting for the config reload")
+	}
+	stop()
+	c.Assert(ports, qt.DeepEquals, []int{9001})
+	c.Assert(first, qt.HasLen, 0)
+
+	// Once stopped, changes are no longer applied.
+	replaceConfig(c, path, "port: 9002\n")
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(portFlag.GetInt(), qt.Equals, 9001)
+	c.Assert(ports, qt.DeepEquals, []int{9001})
+	c.Assert(changes, qt.HasLen, 0)
+}